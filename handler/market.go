@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+	"discordBot/service/stock"
+)
+
+// Market : 取得指數/ETF 與 ETH 市場概況
+func Market(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $market
+	embed, err := stock.MarketOverview(context.Background(), "市場概況")
+	if err != nil {
+		if err := discord.SendMessage(
+			s,
+			&discord.SendMessageInput{
+				ChannelID: m.ChannelID,
+				Content:   fmt.Sprintf("錯誤: %v", err),
+			},
+		); err != nil {
+			logger.Error("發送訊息失敗", "error", err)
+		}
+		return
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
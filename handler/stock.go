@@ -7,6 +7,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 
+	"discordBot/model/dto"
 	"discordBot/pkg/logger"
 	"discordBot/service/discord"
 	"discordBot/service/stock"
@@ -40,6 +41,20 @@ func Quote(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 }
 
+// Watch : 管理個人或伺服器觀察清單
+func Watch(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $watch add TSLA NVDA
+	res, err := stock.WatchCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Alert : 管理價格警示
+func Alert(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $alert TSLA above 300
+	res, err := stock.AlertCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
 // SetStock : 新增股票到 DB
 func SetStock(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// example : $set_stock TSLA units price
@@ -68,7 +83,45 @@ func SetStock(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 }
 
-// GetStock : 取得 DB 中股票
+// Buy : 新增買進交易
+func Buy(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $buy TSLA units price [fee] [currency]
+	trade(s, m, dto.StockSideBuy, "買進")
+}
+
+// Sell : 新增賣出交易
+func Sell(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $sell TSLA units price [fee] [currency]
+	trade(s, m, dto.StockSideSell, "賣出")
+}
+
+func trade(s *discordgo.Session, m *discordgo.MessageCreate, side string, label string) {
+	res, err := stock.Trade(context.Background(), m, side)
+	if err != nil {
+		if err := discord.SendMessage(
+			s,
+			&discord.SendMessageInput{
+				ChannelID: m.ChannelID,
+				Content:   fmt.Sprintf("錯誤: %v", err),
+			},
+		); err != nil {
+			logger.Error("發送訊息失敗", "error", err)
+		}
+		return
+	}
+
+	if err := discord.SendMessage(
+		s,
+		&discord.SendMessageInput{
+			ChannelID: m.ChannelID,
+			Content:   fmt.Sprintf("%s成功: %s %v 股 @ %.2f %s (%s)", label, res.Symbol, res.Units, res.Price, res.Currency, stock.FormatTradeCosts(res)),
+		},
+	); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
+
+// GetStock : 取得 DB 中呼叫者的交易紀錄
 func GetStock(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// example : $get_stock TSLA
 	res, err := stock.GetStock(context.Background(), m)
@@ -109,3 +162,181 @@ func GetStock(s *discordgo.Session, m *discordgo.MessageCreate) {
 		logger.Error("發送訊息失敗", "error", err)
 	}
 }
+
+// CostMethod : 查詢或設定成本計算方式
+func CostMethod(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $cost_method fifo
+	res, err := stock.CostMethodCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Broker : 查詢券商費率或設定預設券商
+func Broker(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $broker sinopac
+	res, err := stock.BrokerCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Currency : 查詢或設定基準幣別
+func Currency(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $currency TWD
+	res, err := stock.CurrencyCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Report : 查詢或設定每日收益報告
+func Report(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $report dm
+	res, err := stock.ReportCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// EditStock : 修改交易紀錄（需確認）
+func EditStock(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $edit_stock 12 units=10 price=100
+	res, err := stock.EditStock(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// DelStock : 刪除交易紀錄（需確認）
+func DelStock(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $del_stock 12
+	res, err := stock.DelStock(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Confirm : 確認待確認的交易紀錄異動
+func Confirm(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $confirm
+	res, err := stock.ConfirmStockChange(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Cancel : 取消待確認的交易紀錄異動
+func Cancel(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $cancel
+	res, err := stock.CancelStockChange(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Position : 取得單一標的合併持倉
+func Position(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $position TSLA
+	res, err := stock.GetPosition(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Portfolio : 取得所有持倉與配置比例
+func Portfolio(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $portfolio
+	res, err := stock.GetPortfolio(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// History : 查詢區間投資組合表現
+func History(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $history 1M
+	res, err := stock.History(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Performance : 繪製區間績效圖
+func Performance(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $perf 1M SPY
+	msg, err := stock.Performance(context.Background(), m)
+	if err != nil {
+		reply(s, m, "", err)
+		return
+	}
+
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, msg); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
+
+// Allocation : 依類股、產業、國家與幣別列出資產配置
+func Allocation(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $allocation sector chart
+	msg, err := stock.Allocation(context.Background(), m)
+	if err != nil {
+		reply(s, m, "", err)
+		return
+	}
+
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, msg); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
+
+// Target : 查詢或設定目標配置
+func Target(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $target AAPL 30%
+	res, err := stock.TargetCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Rebalance : 計算回到目標配置所需的交易
+func Rebalance(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $rebalance 10000
+	res, err := stock.Rebalance(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Dividend : 登記收到的股息
+func Dividend(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $dividend AAPL 12.5 2026-05-15
+	res, err := stock.DividendCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Income : 依月份或年度彙總股息收入
+func Income(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $income month 2026
+	res, err := stock.IncomeCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Split : 查詢或預覽股票分割調整
+func Split(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $split AAPL 4:1 2020-08-31
+	res, err := stock.SplitCommand(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Import : 預覽匯入 CSV 附件中的交易紀錄
+func Import(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $import schwab（附加 CSV 檔）
+	res, err := stock.ImportStock(context.Background(), m)
+	reply(s, m, res, err)
+}
+
+// Export : 以 CSV 附件匯出交易紀錄
+func Export(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// example : $export
+	msg, err := stock.ExportStock(context.Background(), m)
+	if err != nil {
+		reply(s, m, "", err)
+		return
+	}
+
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, msg); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
+
+// reply 回覆指令結果，發生錯誤時回覆錯誤訊息
+func reply(s *discordgo.Session, m *discordgo.MessageCreate, content string, err error) {
+	if err != nil {
+		content = fmt.Sprintf("錯誤: %v", err)
+	}
+
+	if err := discord.SendMessage(
+		s,
+		&discord.SendMessageInput{
+			ChannelID: m.ChannelID,
+			Content:   content,
+		},
+	); err != nil {
+		logger.Error("發送訊息失敗", "error", err)
+	}
+}
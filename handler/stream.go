@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/stock"
+	"discordBot/service/stream"
+)
+
+var streamCancel context.CancelFunc
+
+// StartStream : 啟動觀察清單即時報價串流（STREAM_ENABLED=true 時）
+func StartStream(s *discordgo.Session) {
+	streamConfig := config.GetStreamConfig()
+	if !streamConfig.Enabled {
+		logger.Info("即時串流未啟用")
+		return
+	}
+
+	alerter := stock.NewTradeAlerter(s, taskDurationFromSeconds(streamConfig.EvaluateIntervalSeconds, 5*time.Second))
+
+	client, err := stream.NewClient(
+		streamConfig.URL,
+		streamConfig.APIKey,
+		func(trade stream.Trade) {
+			alerter.HandleTrade(trade.Symbol, trade.Price)
+		},
+		stream.WithBackoff(time.Second, taskDurationFromSeconds(streamConfig.MaxBackoffSeconds, time.Minute)),
+	)
+	if err != nil {
+		logger.Error("建立即時串流失敗", "error", err)
+		return
+	}
+
+	// 延長時段查價使用串流最新成交價
+	stock.SetLastPriceSource(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	streamCancel = cancel
+
+	refreshInterval := taskDurationFromSeconds(streamConfig.RefreshIntervalSeconds, time.Minute)
+	refreshStreamSymbols(ctx, client)
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refreshStreamSymbols(ctx, client)
+			}
+		}
+	}()
+
+	go client.Run(ctx)
+	logger.Info("即時串流已啟動", "url", streamConfig.URL)
+}
+
+// refreshStreamSymbols 依觀察清單同步訂閱標的
+func refreshStreamSymbols(ctx context.Context, client *stream.Client) {
+	fetchCtx, fetchCancel := context.WithTimeout(ctx, 10*time.Second)
+	watchList, err := stock.WatchSymbols(fetchCtx)
+	fetchCancel()
+	if err != nil {
+		logger.Error("同步串流訂閱失敗", "error", err)
+		return
+	}
+
+	client.SetSymbols(watchList)
+}
+
+// StopStream 停止即時報價串流
+func StopStream() {
+	if streamCancel != nil {
+		streamCancel()
+		stock.SetLastPriceSource(nil)
+		logger.Info("即時串流已停止")
+	}
+}
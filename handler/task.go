@@ -9,6 +9,7 @@ import (
 	"discordBot/pkg/logger"
 	"discordBot/service/crypto"
 	"discordBot/service/discord"
+	"discordBot/service/market"
 	"discordBot/service/stock"
 
 	"github.com/bwmarrin/discordgo"
@@ -37,26 +38,98 @@ func Task(s *discordgo.Session) {
 	taskCron = cron.New(cron.WithLocation(taipeiLoc))
 	registeredCount := 0
 
-	// 週一到週五 23:00 - 23:59 每 10 分鐘啟動
-	if registerTask("*/10 23 * * 1-5", "check_change_weekday_late", func() {
+	// 美股行事曆（依美東時間與實際開收盤，含假日與提前收盤）
+	usMarket := market.US()
+
+	// 美股正規交易時段每 10 分鐘啟動
+	if registerSchedule(market.NewIntervalSchedule(usMarket, 10*time.Minute), "check_change_regular_session", func() {
 		logger.Info("執行股票漲跌幅檢查任務")
 		stock.CheckChange(s)
+		stock.CheckPriceAlerts(s)
 	}) {
 		registeredCount++
 	}
 
-	// 週二到週六 00:00 - 04 : 59 每 10 分鐘啟動
-	if registerTask("*/10 0-4 * * 2-6", "check_change_weekday_early", func() {
-		logger.Info("執行股票漲跌幅檢查任務")
-		stock.CheckChange(s)
+	// 美股收盤後 2 小時啟動
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorClose, 2*time.Hour), "calculate_profit_daily", func() {
+		logger.Info("執行收益計算任務")
+		stock.CalculateProfit(s)
 	}) {
 		registeredCount++
 	}
 
-	// 週二到週六 06:00 啟動
-	if registerTask("0 6 * * 2-6", "calculate_profit_daily", func() {
-		logger.Info("執行收益計算任務")
-		stock.CalculateProfit(s)
+	// 美股收盤後 1 小時自動登記股息（需在收益計算前完成，資料來源需支援配息資料）
+	if taskConfig.DividendAutoDiscovery {
+		if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorClose, time.Hour), "dividend_discovery_daily", func() {
+			logger.Info("執行自動登記股息任務")
+			stock.DiscoverDividends(s)
+		}) {
+			registeredCount++
+		}
+	}
+
+	// 美股開盤 30 分鐘後偵測股票分割，讓持有者在收益計算前確認調整
+	if taskConfig.SplitAutoDetection {
+		if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorOpen, 30*time.Minute), "split_detection_daily", func() {
+			logger.Info("執行股票分割偵測任務")
+			stock.DetectSplits(s)
+		}) {
+			registeredCount++
+		}
+	}
+
+	// 美股開盤 5 分鐘後發送市場概況
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorOpen, 5*time.Minute), "market_overview_open", func() {
+		logger.Info("執行開盤市場概況任務")
+		postMarketOverview(s, "開盤市場概況", stock.MarketOverview)
+	}) {
+		registeredCount++
+	}
+
+	// 美股收盤 5 分鐘後發送市場概況（正規時段漲跌幅）
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorClose, 5*time.Minute), "market_overview_close", func() {
+		logger.Info("執行收盤市場概況任務")
+		postMarketOverview(s, "收盤市場概況", stock.MarketCloseOverview)
+	}) {
+		registeredCount++
+	}
+
+	// 週六 09:00 發送每週投資組合報告（附績效圖）
+	if registerTask("0 9 * * 6", "weekly_performance_report", func() {
+		logger.Info("執行每週報告任務")
+		stock.WeeklyReport(s)
+	}) {
+		registeredCount++
+	}
+
+	// 每週第一個交易日美股開盤前 90 分鐘發送本週財報預告
+	if registerSchedule(market.NewWeeklyOffsetSchedule(usMarket, market.AnchorOpen, -90*time.Minute), "earnings_weekly_preview", func() {
+		logger.Info("執行財報預告任務")
+		stock.EarningsPreview(s)
+	}) {
+		registeredCount++
+	}
+
+	// 美股收盤 30 分鐘後提醒下一個交易日財報
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorClose, 30*time.Minute), "earnings_day_before_reminder", func() {
+		logger.Info("執行財報提醒任務")
+		stock.EarningsReminder(s)
+	}) {
+		registeredCount++
+	}
+
+	// 盤前財報於開盤前公布，開盤前 30 分鐘檢查
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorOpen, -30*time.Minute), "earnings_results_pre_market", func() {
+		logger.Info("執行盤前財報結果檢查任務")
+		stock.EarningsResults(s)
+	}) {
+		registeredCount++
+	}
+
+	// 盤後財報於收盤後公布，收盤後 2 小時檢查
+	if registerSchedule(market.NewOffsetSchedule(usMarket, market.AnchorClose, 2*time.Hour), "earnings_results_after_hours", func() {
+		logger.Info("執行盤後財報結果檢查任務")
+		stock.EarningsResults(s)
 	}) {
 		registeredCount++
 	}
@@ -94,6 +167,31 @@ func Task(s *discordgo.Session) {
 	logger.Info("定時任務已啟動", "registeredCount", registeredCount)
 }
 
+// postMarketOverview 發送市場概況到市場概況頻道
+func postMarketOverview(s *discordgo.Session, title string, overview func(context.Context, string) (*discordgo.MessageEmbed, error)) {
+	taskConfig := config.GetTaskConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), taskDurationFromSeconds(taskConfig.CheckChangeTimeoutSeconds, 2*time.Minute))
+	defer cancel()
+
+	embed, err := overview(ctx, title)
+	if err != nil {
+		logger.Error("取得市場概況失敗", "error", err)
+		cronErrorReporter.Notify(
+			s,
+			"task:market_overview",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.MarketOverviewChannelID,
+				Content:   fmt.Sprintf("取得市場概況錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(taskConfig.MarketOverviewChannelID, embed); err != nil {
+		logger.Error("發送市場概況失敗", "error", err)
+	}
+}
+
 func registerTask(spec string, name string, fn func()) bool {
 	if _, err := taskCron.AddFunc(spec, fn); err != nil {
 		logger.Error("註冊定時任務失敗", "task", name, "spec", spec, "error", err)
@@ -104,6 +202,17 @@ func registerTask(spec string, name string, fn func()) bool {
 	return true
 }
 
+func registerSchedule(schedule cron.Schedule, name string, fn func()) bool {
+	if schedule.Next(time.Now()).IsZero() {
+		logger.Error("註冊定時任務失敗，找不到下一次執行時間", "task", name)
+		return false
+	}
+
+	taskCron.Schedule(schedule, cron.FuncJob(fn))
+	logger.Info("註冊定時任務成功", "task", name, "next", schedule.Next(time.Now()).String())
+	return true
+}
+
 func taskDurationFromSeconds(seconds int, defaultDuration time.Duration) time.Duration {
 	if seconds <= 0 {
 		return defaultDuration
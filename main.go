@@ -41,8 +41,33 @@ func main() {
 
 	// 註冊股票指令
 	router.Register("$+", handler.Quote)
+	router.Register("$watch", handler.Watch)
+	router.Register("$alert", handler.Alert)
 	router.Register("$set_stock", handler.SetStock)
 	router.Register("$get_stock", handler.GetStock)
+	router.Register("$position", handler.Position)
+	router.Register("$portfolio", handler.Portfolio)
+	router.Register("$allocation", handler.Allocation)
+	router.Register("$target", handler.Target)
+	router.Register("$rebalance", handler.Rebalance)
+	router.Register("$history", handler.History)
+	router.Register("$perf", handler.Performance)
+	router.Register("$dividend", handler.Dividend)
+	router.Register("$income", handler.Income)
+	router.Register("$buy", handler.Buy)
+	router.Register("$sell", handler.Sell)
+	router.Register("$cost_method", handler.CostMethod)
+	router.Register("$broker", handler.Broker)
+	router.Register("$currency", handler.Currency)
+	router.Register("$report", handler.Report)
+	router.Register("$edit_stock", handler.EditStock)
+	router.Register("$del_stock", handler.DelStock)
+	router.Register("$split", handler.Split)
+	router.Register("$import", handler.Import)
+	router.Register("$export", handler.Export)
+	router.Register("$confirm", handler.Confirm)
+	router.Register("$cancel", handler.Cancel)
+	router.Register("$market", handler.Market)
 
 	// 註冊 Redis 指令
 	router.Register("$setRedis", handler.SetRedis)
@@ -57,6 +82,9 @@ func main() {
 	// 啟動定時任務
 	handler.Task(dg)
 
+	// 啟動即時報價串流（可選）
+	handler.StartStream(dg)
+
 	// 設置只監聽訊息事件
 	dg.Identify.Intents = discordgo.IntentsGuildMessages
 
@@ -88,6 +116,9 @@ func main() {
 		handler.StopTasks()
 		logger.Info("定時任務已停止")
 
+		// 停止即時報價串流
+		handler.StopStream()
+
 		// 關閉資料庫連線
 		if err := postgresql.Close(); err != nil {
 			logger.Error("關閉資料庫連線失敗", "error", err)
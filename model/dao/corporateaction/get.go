@@ -0,0 +1,75 @@
+package corporateaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// GetInput :
+type GetInput struct {
+	UserID string
+	Symbol string
+	Type   string
+}
+
+// Get : 取得 d9fdq7n9q3delq.corporate_action，依生效日由舊到新排序
+func Get(ctx context.Context, input *GetInput) (ret []*dto.CorporateAction, err error) {
+	if input == nil || input.UserID == "" {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	params := []interface{}{input.UserID}
+	wheres := []string{" user_id = $1 "}
+
+	if input.Symbol != "" {
+		params = append(params, input.Symbol)
+		wheres = append(wheres, fmt.Sprintf(" symbol = $%d ", len(params)))
+	}
+
+	if input.Type != "" {
+		params = append(params, input.Type)
+		wheres = append(wheres, fmt.Sprintf(" action_type = $%d ", len(params)))
+	}
+
+	sql := `SELECT id, user_id, symbol, action_type, effective_date, from_factor, to_factor, created_at FROM corporate_action WHERE` +
+		strings.Join(wheres, " AND ") + ` ORDER BY effective_date, id`
+
+	rows, err := dbS.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		data := &dto.CorporateAction{}
+		if err := rows.Scan(
+			&data.ID,
+			&data.UserID,
+			&data.Symbol,
+			&data.Type,
+			&data.EffectiveDate,
+			&data.FromFactor,
+			&data.ToFactor,
+			&data.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, rows.Err()
+}
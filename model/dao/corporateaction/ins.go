@@ -0,0 +1,61 @@
+package corporateaction
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Ins : 新增公司行動紀錄 ins d9fdq7n9q3delq.corporate_action
+// 同一標的同一生效日已有紀錄時回傳錯誤，避免重複調整；Transaction 為選填
+func Ins(ctx context.Context, tx *dbSQL.Tx, input *dto.CorporateAction) (err error) {
+	if input == nil || input.UserID == "" || input.Symbol == "" || input.EffectiveDate.IsZero() || input.FromFactor <= 0 || input.ToFactor <= 0 {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	actionType := input.Type
+	if actionType == "" {
+		actionType = dto.CorporateActionSplit
+	}
+
+	sql := `INSERT INTO corporate_action (
+		user_id,
+		symbol,
+		action_type,
+		effective_date,
+		from_factor,
+		to_factor
+	) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	params := []interface{}{
+		input.UserID,
+		input.Symbol,
+		actionType,
+		input.EffectiveDate.Format("2006-01-02"),
+		input.FromFactor,
+		input.ToFactor,
+	}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("ins錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package dividend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// GetInput :
+type GetInput struct {
+	UserID string
+	Symbol string
+	// 發放日期區間（含），零值表示不限制
+	From time.Time
+	To   time.Time
+}
+
+// Get : 取得 d9fdq7n9q3delq.dividend，依發放日期由舊到新排序
+func Get(ctx context.Context, input *GetInput) (ret []*dto.Dividend, err error) {
+	if input == nil || input.UserID == "" {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	params := []interface{}{input.UserID}
+	wheres := []string{" user_id = $1 "}
+
+	if input.Symbol != "" {
+		params = append(params, input.Symbol)
+		wheres = append(wheres, fmt.Sprintf(" symbol = $%d ", len(params)))
+	}
+
+	if !input.From.IsZero() {
+		params = append(params, input.From.Format("2006-01-02"))
+		wheres = append(wheres, fmt.Sprintf(" pay_date >= $%d ", len(params)))
+	}
+
+	if !input.To.IsZero() {
+		params = append(params, input.To.Format("2006-01-02"))
+		wheres = append(wheres, fmt.Sprintf(" pay_date <= $%d ", len(params)))
+	}
+
+	sql := `SELECT id, user_id, symbol, amount, tax, currency, pay_date, source FROM dividend WHERE` +
+		strings.Join(wheres, " AND ") + ` ORDER BY pay_date, id`
+
+	rows, err := dbS.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		data := &dto.Dividend{}
+		if err := rows.Scan(
+			&data.ID,
+			&data.UserID,
+			&data.Symbol,
+			&data.Amount,
+			&data.Tax,
+			&data.Currency,
+			&data.PayDate,
+			&data.Source,
+		); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, rows.Err()
+}
@@ -0,0 +1,68 @@
+package dividend
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// InsIgnore : 新增股息紀錄，同一標的同一發放日已有紀錄（例如手動登記）時略過 ins d9fdq7n9q3delq.dividend
+// 回傳實際新增的筆數；Transaction 為選填
+func InsIgnore(ctx context.Context, tx *dbSQL.Tx, input []*dto.Dividend) (inserted int64, err error) {
+	if len(input) == 0 {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO dividend (
+		user_id,
+		symbol,
+		amount,
+		tax,
+		currency,
+		pay_date,
+		source
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id, symbol, pay_date) DO NOTHING`
+
+	for _, data := range input {
+		params := []interface{}{
+			data.UserID,
+			data.Symbol,
+			data.Amount,
+			data.Tax,
+			currencyOrDefault(data.Currency),
+			data.PayDate.Format("2006-01-02"),
+			sourceOrDefault(data.Source),
+		}
+
+		var res dbSQL.Result
+		if tx == nil {
+			res, err = dbM.ExecContext(ctx, sql, params...)
+		} else {
+			res, err = tx.ExecContext(ctx, sql, params...)
+		}
+		if err != nil {
+			return inserted, fmt.Errorf("ins錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return inserted, fmt.Errorf("取得影響筆數錯誤: %v", err)
+		}
+		inserted += affected
+	}
+
+	return inserted, nil
+}
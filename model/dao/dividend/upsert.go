@@ -0,0 +1,77 @@
+package dividend
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Upsert : 手動登記股息，同一標的同一發放日已有紀錄時覆蓋 upsert d9fdq7n9q3delq.dividend
+// Transaction 為選填
+func Upsert(ctx context.Context, tx *dbSQL.Tx, input *dto.Dividend) (err error) {
+	if input == nil || input.UserID == "" || input.Symbol == "" || input.PayDate.IsZero() {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO dividend (
+		user_id,
+		symbol,
+		amount,
+		tax,
+		currency,
+		pay_date,
+		source
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id, symbol, pay_date) DO UPDATE SET
+		amount = EXCLUDED.amount,
+		tax = EXCLUDED.tax,
+		currency = EXCLUDED.currency,
+		source = EXCLUDED.source`
+
+	params := []interface{}{
+		input.UserID,
+		input.Symbol,
+		input.Amount,
+		input.Tax,
+		currencyOrDefault(input.Currency),
+		input.PayDate.Format("2006-01-02"),
+		sourceOrDefault(input.Source),
+	}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+func sourceOrDefault(source string) string {
+	if source == "" {
+		return dto.DividendSourceManual
+	}
+	return source
+}
@@ -0,0 +1,41 @@
+package pricealert
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/postgresql"
+)
+
+// Del : 刪除使用者的價格警示 del d9fdq7n9q3delq.price_alert，回傳影響筆數
+// Transaction 為選填
+func Del(ctx context.Context, tx *dbSQL.Tx, userID string, id int64) (affected int64, err error) {
+	if userID == "" || id <= 0 {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `DELETE FROM price_alert WHERE user_id = $1 AND id = $2`
+	params := []interface{}{userID, id}
+
+	var res dbSQL.Result
+	if tx == nil {
+		res, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		res, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("del錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package pricealert
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+	"strings"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// GetInput :
+type GetInput struct {
+	UserID string
+	// 只取需要檢查的警示（啟用中或可重新啟用）
+	Active bool
+}
+
+// Get : 取得 d9fdq7n9q3delq.price_alert，依標的與建立順序排序
+// UserID 為空時取得所有使用者的警示（定時檢查用）
+func Get(ctx context.Context, input *GetInput) (ret []*dto.PriceAlert, err error) {
+	if input == nil {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	var params []interface{}
+	wheres := []string{" TRUE "}
+
+	if input.UserID != "" {
+		params = append(params, input.UserID)
+		wheres = append(wheres, fmt.Sprintf(" user_id = $%d ", len(params)))
+	}
+
+	if input.Active {
+		wheres = append(wheres, " (armed OR rearm_percent > 0) ")
+	}
+
+	sql := `SELECT id, user_id, channel_id, symbol, condition, price, rearm_percent, armed, last_price, triggered_at, created_at FROM price_alert WHERE` +
+		strings.Join(wheres, " AND ") + ` ORDER BY symbol, id`
+
+	rows, err := dbS.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		data := &dto.PriceAlert{}
+		var triggeredAt dbSQL.NullTime
+		if err := rows.Scan(
+			&data.ID,
+			&data.UserID,
+			&data.ChannelID,
+			&data.Symbol,
+			&data.Condition,
+			&data.Price,
+			&data.RearmPercent,
+			&data.Armed,
+			&data.LastPrice,
+			&triggeredAt,
+			&data.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		if triggeredAt.Valid {
+			data.TriggeredAt = triggeredAt.Time
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, rows.Err()
+}
@@ -0,0 +1,63 @@
+package pricealert
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Ins : 新增價格警示 ins d9fdq7n9q3delq.price_alert，回傳流水號
+// Transaction 為選填
+func Ins(ctx context.Context, tx *dbSQL.Tx, input *dto.PriceAlert) (id int64, err error) {
+	if input == nil || input.UserID == "" || input.ChannelID == "" || input.Symbol == "" || input.Price <= 0 || input.RearmPercent < 0 {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+	switch input.Condition {
+	case dto.PriceAlertAbove, dto.PriceAlertBelow, dto.PriceAlertCrosses:
+	default:
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO price_alert (
+		user_id,
+		channel_id,
+		symbol,
+		condition,
+		price,
+		rearm_percent,
+		last_price
+	) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	params := []interface{}{
+		input.UserID,
+		input.ChannelID,
+		input.Symbol,
+		input.Condition,
+		input.Price,
+		input.RearmPercent,
+		input.LastPrice,
+	}
+
+	if tx == nil {
+		err = dbM.QueryRowContext(ctx, sql, params...).Scan(&id)
+	} else {
+		err = tx.QueryRowContext(ctx, sql, params...).Scan(&id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ins錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return id, nil
+}
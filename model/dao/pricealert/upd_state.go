@@ -0,0 +1,46 @@
+package pricealert
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// UpdState : 更新價格警示的檢查狀態 upd d9fdq7n9q3delq.price_alert
+// 只更新 armed、last_price 與 triggered_at；Transaction 為選填
+func UpdState(ctx context.Context, tx *dbSQL.Tx, input *dto.PriceAlert) (err error) {
+	if input == nil || input.ID <= 0 {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	var triggeredAt interface{}
+	if !input.TriggeredAt.IsZero() {
+		triggeredAt = input.TriggeredAt
+	}
+
+	sql := `UPDATE price_alert SET armed = $1, last_price = $2, triggered_at = $3 WHERE id = $4`
+	params := []interface{}{input.Armed, input.LastPrice, triggeredAt, input.ID}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upd錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
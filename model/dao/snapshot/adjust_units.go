@@ -0,0 +1,42 @@
+package snapshot
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+	"time"
+
+	"discordBot/model/postgresql"
+)
+
+// AdjustUnits : 依分割比例調整生效日前的快照持有數量 upd d9fdq7n9q3delq.portfolio_snapshot
+// 成本、市值與損益不受分割影響，只調整數量；回傳影響筆數，Transaction 為選填
+func AdjustUnits(ctx context.Context, tx *dbSQL.Tx, userID string, symbol string, before time.Time, ratio float64) (affected int64, err error) {
+	if userID == "" || symbol == "" || before.IsZero() || ratio <= 0 {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `UPDATE portfolio_snapshot SET units = units * $1 WHERE user_id = $2 AND symbol = $3 AND snapshot_date < $4`
+	params := []interface{}{ratio, userID, symbol, before.Format("2006-01-02")}
+
+	var res dbSQL.Result
+	if tx == nil {
+		res, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		res, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("upd錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// GetInput :
+type GetInput struct {
+	UserID string
+	Symbol string
+	// 日期區間（含），零值表示不限制
+	From time.Time
+	To   time.Time
+	// 依日期由新到舊排序
+	Desc  bool
+	Limit int
+}
+
+// Get : 取得 d9fdq7n9q3delq.portfolio_snapshot
+func Get(ctx context.Context, input *GetInput) (ret []*dto.PortfolioSnapshot, err error) {
+	if input == nil || input.UserID == "" {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	params := []interface{}{input.UserID}
+	wheres := []string{" user_id = $1 "}
+
+	if input.Symbol != "" {
+		params = append(params, input.Symbol)
+		wheres = append(wheres, fmt.Sprintf(" symbol = $%d ", len(params)))
+	}
+
+	if !input.From.IsZero() {
+		params = append(params, input.From.Format("2006-01-02"))
+		wheres = append(wheres, fmt.Sprintf(" snapshot_date >= $%d ", len(params)))
+	}
+
+	if !input.To.IsZero() {
+		params = append(params, input.To.Format("2006-01-02"))
+		wheres = append(wheres, fmt.Sprintf(" snapshot_date <= $%d ", len(params)))
+	}
+
+	order := "ASC"
+	if input.Desc {
+		order = "DESC"
+	}
+
+	sql := `SELECT user_id, snapshot_date, symbol, units, cost, value, profit, realized_profit, dividend_income, currency FROM portfolio_snapshot WHERE` +
+		strings.Join(wheres, " AND ") + ` ORDER BY snapshot_date ` + order + `, symbol`
+
+	if input.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", input.Limit)
+	}
+
+	rows, err := dbS.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		data := &dto.PortfolioSnapshot{}
+		if err := rows.Scan(
+			&data.UserID,
+			&data.Date,
+			&data.Symbol,
+			&data.Units,
+			&data.Cost,
+			&data.Value,
+			&data.Profit,
+			&data.RealizedProfit,
+			&data.DividendIncome,
+			&data.Currency,
+		); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, rows.Err()
+}
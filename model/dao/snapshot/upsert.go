@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Upsert : 寫入每日快照，同一天重複執行時覆蓋 upsert d9fdq7n9q3delq.portfolio_snapshot
+// Transaction 為選填
+func Upsert(ctx context.Context, tx *dbSQL.Tx, input []*dto.PortfolioSnapshot) (err error) {
+	if len(input) == 0 {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO portfolio_snapshot (
+		user_id,
+		snapshot_date,
+		symbol,
+		units,
+		cost,
+		value,
+		profit,
+		realized_profit,
+		dividend_income,
+		currency
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (user_id, symbol, snapshot_date) DO UPDATE SET
+		units = EXCLUDED.units,
+		cost = EXCLUDED.cost,
+		value = EXCLUDED.value,
+		profit = EXCLUDED.profit,
+		realized_profit = EXCLUDED.realized_profit,
+		dividend_income = EXCLUDED.dividend_income,
+		currency = EXCLUDED.currency,
+		created_at = NOW()`
+
+	for _, data := range input {
+		params := []interface{}{
+			data.UserID,
+			data.Date.Format("2006-01-02"),
+			data.Symbol,
+			data.Units,
+			data.Cost,
+			data.Value,
+			data.Profit,
+			data.RealizedProfit,
+			data.DividendIncome,
+			data.Currency,
+		}
+
+		if tx == nil {
+			_, err = dbM.ExecContext(ctx, sql, params...)
+		} else {
+			_, err = tx.ExecContext(ctx, sql, params...)
+		}
+		if err != nil {
+			return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package stock
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/postgresql"
+)
+
+// Del : 刪除使用者的交易紀錄 del d9fdq7n9q3delq.stock
+// 只會刪除 user_id 相符的資料，回傳影響筆數
+// Transaction 為選填
+func Del(ctx context.Context, tx *dbSQL.Tx, userID string, id int64) (affected int64, err error) {
+	if userID == "" || id <= 0 {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `DELETE FROM stock WHERE id = $1 AND user_id = $2`
+
+	var res dbSQL.Result
+	if tx == nil {
+		res, err = dbM.ExecContext(ctx, sql, id, userID)
+	} else {
+		res, err = tx.ExecContext(ctx, sql, id, userID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("del錯誤 error: %v, sql: %v, id: %v, userID: %v ", err, sql, id, userID)
+	}
+
+	return res.RowsAffected()
+}
@@ -45,7 +45,7 @@ func Get(ctx context.Context, input *GetInput) (ret []*dto.Stock, err error) {
 		return nil, fmt.Errorf("sql 語法錯誤")
 	}
 
-	sql := `SELECT id, user_id, symbol, units, price FROM stock WHERE` + strings.Join(wheres, " AND ")
+	sql := `SELECT id, user_id, symbol, side, units, price, fee, tax, broker, currency, traded_at FROM stock WHERE` + strings.Join(wheres, " AND ") + ` ORDER BY traded_at, id`
 
 	rows, err := dbS.QueryContext(ctx, sql, params...)
 	if err != nil {
@@ -63,8 +63,14 @@ func Get(ctx context.Context, input *GetInput) (ret []*dto.Stock, err error) {
 			&data.ID,
 			&data.UserID,
 			&data.Symbol,
+			&data.Side,
 			&data.Units,
 			&data.Price,
+			&data.Fee,
+			&data.Tax,
+			&data.Broker,
+			&data.Currency,
+			&data.TradedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scan 錯誤: %v", err)
 		}
@@ -0,0 +1,48 @@
+package stock
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"errors"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// GetByID : 取得使用者的單筆交易紀錄 d9fdq7n9q3delq.stock
+// 查無資料（或不屬於該使用者）時回傳 nil
+func GetByID(ctx context.Context, userID string, id int64) (*dto.Stock, error) {
+	if userID == "" || id <= 0 {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	sql := `SELECT id, user_id, symbol, side, units, price, fee, tax, broker, currency, traded_at FROM stock WHERE id = $1 AND user_id = $2`
+
+	data := &dto.Stock{}
+	if err := dbS.QueryRowContext(ctx, sql, id, userID).Scan(
+		&data.ID,
+		&data.UserID,
+		&data.Symbol,
+		&data.Side,
+		&data.Units,
+		&data.Price,
+		&data.Fee,
+		&data.Tax,
+		&data.Broker,
+		&data.Currency,
+		&data.TradedAt,
+	); err != nil {
+		if errors.Is(err, dbSQL.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// GetUserIDs : 取得 d9fdq7n9q3delq.stock 中所有有交易紀錄的使用者（不重複）
+func GetUserIDs(ctx context.Context) (ret []string, err error) {
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	sql := `SELECT DISTINCT user_id FROM stock ORDER BY user_id`
+
+	rows, err := dbS.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		ret = append(ret, userID)
+	}
+
+	return ret, rows.Err()
+}
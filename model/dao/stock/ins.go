@@ -4,12 +4,13 @@ import (
 	"context"
 	dbSQL "database/sql"
 	"fmt"
+	"time"
 
 	"discordBot/model/dto"
 	"discordBot/model/postgresql"
 )
 
-// Ins : 新增股票 ins d9fdq7n9q3delq.stock
+// Ins : 新增交易紀錄 ins d9fdq7n9q3delq.stock
 // Transaction 為選填
 func Ins(ctx context.Context, tx *dbSQL.Tx, input *dto.Stock) (err error) {
 	if input == nil {
@@ -25,19 +26,46 @@ func Ins(ctx context.Context, tx *dbSQL.Tx, input *dto.Stock) (err error) {
 		}
 	}
 
+	side := input.Side
+	if side == "" {
+		side = dto.StockSideBuy
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	tradedAt := input.TradedAt
+	if tradedAt.IsZero() {
+		tradedAt = time.Now()
+	}
+
 	sql := `INSERT INTO stock (
 		user_id,
 		symbol,
+		side,
 		units,
-		price
-	) VALUES ($1, $2, $3, $4)`
+		price,
+		fee,
+		tax,
+		broker,
+		currency,
+		traded_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
 	var params []interface{}
 
 	params = append(params, input.UserID)
 	params = append(params, input.Symbol)
+	params = append(params, side)
 	params = append(params, input.Units)
 	params = append(params, input.Price)
+	params = append(params, input.Fee)
+	params = append(params, input.Tax)
+	params = append(params, input.Broker)
+	params = append(params, currency)
+	params = append(params, tradedAt)
 
 	// 執行sql
 
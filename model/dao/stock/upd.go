@@ -0,0 +1,66 @@
+package stock
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Upd : 修改使用者的交易紀錄 upd d9fdq7n9q3delq.stock
+// 只會修改 user_id 相符的資料，回傳影響筆數
+// Transaction 為選填
+func Upd(ctx context.Context, tx *dbSQL.Tx, input *dto.Stock) (affected int64, err error) {
+	if input == nil || input.ID <= 0 || input.UserID == "" {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `UPDATE stock SET
+		symbol = $1,
+		side = $2,
+		units = $3,
+		price = $4,
+		fee = $5,
+		tax = $6,
+		broker = $7,
+		currency = $8,
+		traded_at = $9
+	WHERE id = $10 AND user_id = $11`
+
+	var params []interface{}
+
+	params = append(params, input.Symbol)
+	params = append(params, input.Side)
+	params = append(params, input.Units)
+	params = append(params, input.Price)
+	params = append(params, input.Fee)
+	params = append(params, input.Tax)
+	params = append(params, input.Broker)
+	params = append(params, input.Currency)
+	params = append(params, input.TradedAt)
+	params = append(params, input.ID)
+	params = append(params, input.UserID)
+
+	var res dbSQL.Result
+	if tx == nil {
+		res, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		res, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("upd錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package stockhistory
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"encoding/json"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Ins : 新增交易紀錄異動歷程 ins d9fdq7n9q3delq.stock_history
+// Transaction 為選填
+func Ins(ctx context.Context, tx *dbSQL.Tx, input *dto.StockHistory) (err error) {
+	if input == nil || input.Before == nil {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	before, err := json.Marshal(input.Before)
+	if err != nil {
+		return fmt.Errorf("json 轉換錯誤: %v", err)
+	}
+
+	var after []byte
+	if input.After != nil {
+		if after, err = json.Marshal(input.After); err != nil {
+			return fmt.Errorf("json 轉換錯誤: %v", err)
+		}
+	}
+
+	sql := `INSERT INTO stock_history (
+		stock_id,
+		user_id,
+		action,
+		before_data,
+		after_data
+	) VALUES ($1, $2, $3, $4, $5)`
+
+	var params []interface{}
+
+	params = append(params, input.StockID)
+	params = append(params, input.UserID)
+	params = append(params, input.Action)
+	params = append(params, string(before))
+	if after == nil {
+		params = append(params, nil)
+	} else {
+		params = append(params, string(after))
+	}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("ins錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package targetweight
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/postgresql"
+)
+
+// Del : 刪除目標配置權重 del d9fdq7n9q3delq.target_weight
+// target 為空字串時刪除使用者所有目標配置，回傳影響筆數
+// Transaction 為選填
+func Del(ctx context.Context, tx *dbSQL.Tx, userID string, target string) (affected int64, err error) {
+	if userID == "" {
+		return 0, fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `DELETE FROM target_weight WHERE user_id = $1`
+	params := []interface{}{userID}
+	if target != "" {
+		sql += ` AND target = $2`
+		params = append(params, target)
+	}
+
+	var res dbSQL.Result
+	if tx == nil {
+		res, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		res, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("del錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return res.RowsAffected()
+}
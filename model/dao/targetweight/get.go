@@ -0,0 +1,49 @@
+package targetweight
+
+import (
+	"context"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/logger"
+)
+
+// Get : 取得使用者的目標配置 d9fdq7n9q3delq.target_weight，依權重由大到小排序
+func Get(ctx context.Context, userID string) (ret []*dto.TargetWeight, err error) {
+	if userID == "" {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	sql := `SELECT user_id, target, weight, updated_at FROM target_weight WHERE user_id = $1 ORDER BY weight DESC, target`
+
+	rows, err := dbS.QueryContext(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error("關閉資料庫查詢結果失敗", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		data := &dto.TargetWeight{}
+		if err := rows.Scan(
+			&data.UserID,
+			&data.Target,
+			&data.Weight,
+			&data.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan 錯誤: %v", err)
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, rows.Err()
+}
@@ -0,0 +1,44 @@
+package targetweight
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Upsert : 設定目標配置權重 upsert d9fdq7n9q3delq.target_weight
+// Transaction 為選填
+func Upsert(ctx context.Context, tx *dbSQL.Tx, input *dto.TargetWeight) (err error) {
+	if input == nil || input.UserID == "" || input.Target == "" || input.Weight < 0 || input.Weight > 100 {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO target_weight (user_id, target, weight, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, target) DO UPDATE SET weight = EXCLUDED.weight, updated_at = NOW()`
+
+	params := []interface{}{input.UserID, input.Target, input.Weight}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package usersetting
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"errors"
+	"fmt"
+
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// Get : 取得 d9fdq7n9q3delq.user_setting
+// 查無資料時回傳 nil
+func Get(ctx context.Context, userID string) (*dto.UserSetting, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("參數錯誤")
+	}
+
+	dbS, err := postgresql.GetConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	sql := `SELECT user_id, cost_method, report_enabled, report_channel_id, broker, base_currency, updated_at FROM user_setting WHERE user_id = $1`
+
+	data := &dto.UserSetting{}
+	if err := dbS.QueryRowContext(ctx, sql, userID).Scan(
+		&data.UserID,
+		&data.CostMethod,
+		&data.ReportEnabled,
+		&data.ReportChannelID,
+		&data.Broker,
+		&data.BaseCurrency,
+		&data.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, dbSQL.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("select 錯誤: %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,145 @@
+package usersetting
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"fmt"
+
+	"discordBot/model/postgresql"
+)
+
+// UpsertCostMethod : 設定使用者成本計算方式 upsert d9fdq7n9q3delq.user_setting
+// Transaction 為選填
+func UpsertCostMethod(ctx context.Context, tx *dbSQL.Tx, userID string, costMethod string) (err error) {
+	if userID == "" || costMethod == "" {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO user_setting (user_id, cost_method, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET cost_method = EXCLUDED.cost_method, updated_at = NOW()`
+
+	params := []interface{}{userID, costMethod}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
+
+// UpsertReport : 設定使用者收益報告開關與頻道 upsert d9fdq7n9q3delq.user_setting
+// Transaction 為選填
+func UpsertReport(ctx context.Context, tx *dbSQL.Tx, userID string, enabled bool, channelID string) (err error) {
+	if userID == "" {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO user_setting (user_id, report_enabled, report_channel_id, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET report_enabled = EXCLUDED.report_enabled, report_channel_id = EXCLUDED.report_channel_id, updated_at = NOW()`
+
+	params := []interface{}{userID, enabled, channelID}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
+
+// UpsertBroker : 設定使用者預設券商 upsert d9fdq7n9q3delq.user_setting
+// broker 為空字串時改用系統預設；Transaction 為選填
+func UpsertBroker(ctx context.Context, tx *dbSQL.Tx, userID string, broker string) (err error) {
+	if userID == "" {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO user_setting (user_id, broker, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET broker = EXCLUDED.broker, updated_at = NOW()`
+
+	params := []interface{}{userID, broker}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
+
+// UpsertBaseCurrency : 設定使用者基準幣別 upsert d9fdq7n9q3delq.user_setting
+// currency 為空字串時改用系統預設；Transaction 為選填
+func UpsertBaseCurrency(ctx context.Context, tx *dbSQL.Tx, userID string, currency string) (err error) {
+	if userID == "" {
+		return fmt.Errorf("參數錯誤")
+	}
+
+	var dbM *dbSQL.DB
+
+	if tx == nil {
+		dbM, err = postgresql.GetConn()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+	}
+
+	sql := `INSERT INTO user_setting (user_id, base_currency, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET base_currency = EXCLUDED.base_currency, updated_at = NOW()`
+
+	params := []interface{}{userID, currency}
+
+	if tx == nil {
+		_, err = dbM.ExecContext(ctx, sql, params...)
+	} else {
+		_, err = tx.ExecContext(ctx, sql, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert錯誤 error: %v, sql: %v, params: %v ", err, sql, params)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// 公司行動類型
+const (
+	CorporateActionSplit = "split"
+)
+
+// CorporateAction 公司行動（股票分割/反分割）
+type CorporateAction struct {
+	ID            int64     // 流水號
+	UserID        string    // 用戶 ID
+	Symbol        string    // 標的
+	Type          string    // 類型 (split)
+	EffectiveDate time.Time // 生效日（此日起以分割後價格交易）
+	FromFactor    float64   // 分割前股數
+	ToFactor      float64   // 分割後股數（4:1 分割為 From 1、To 4；1:10 反分割為 From 10、To 1）
+	CreatedAt     time.Time // 建立時間
+}
+
+// Ratio 每股分割後的股數
+func (c *CorporateAction) Ratio() float64 {
+	return c.ToFactor / c.FromFactor
+}
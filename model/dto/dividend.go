@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// 股息來源
+const (
+	DividendSourceManual = "manual"
+	DividendSourceAuto   = "auto"
+)
+
+// Dividend 股息紀錄
+type Dividend struct {
+	ID       int64     // 流水號
+	UserID   string    // 用戶 ID
+	Symbol   string    // 標的
+	Amount   float64   // 股息總金額（稅前）
+	Tax      float64   // 預扣稅額
+	Currency string    // 幣別
+	PayDate  time.Time // 發放日期
+	Source   string    // 來源 (manual/auto)
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// PortfolioSnapshotTotal 總計快照使用的 symbol
+const PortfolioSnapshotTotal = "*"
+
+// PortfolioSnapshot 每日投資組合快照
+type PortfolioSnapshot struct {
+	UserID         string    // 用戶 ID
+	Date           time.Time // 快照日期（美東交易日）
+	Symbol         string    // 標的（* 為總計）
+	Units          float64   // 持有數量
+	Cost           float64   // 持股成本
+	Value          float64   // 市值
+	Profit         float64   // 未實現損益
+	RealizedProfit float64   // 累計已實現損益
+	DividendIncome float64   // 累計股息收入
+	Currency       string    // 金額幣別（各標的為持倉幣別，總計為基準幣別；空字串為舊資料）
+}
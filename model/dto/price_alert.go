@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// 價格警示條件
+const (
+	PriceAlertAbove   = "above"
+	PriceAlertBelow   = "below"
+	PriceAlertCrosses = "crosses"
+)
+
+// PriceAlert 價格警示
+type PriceAlert struct {
+	ID           int64     // 流水號
+	UserID       string    // 用戶 ID
+	ChannelID    string    // 觸發時發送的頻道
+	Symbol       string    // 標的
+	Condition    string    // 條件 (above/below/crosses)
+	Price        float64   // 目標價格
+	RearmPercent float64   // 觸發後價格回到另一側超過此百分比時重新啟用，0 表示只觸發一次
+	Armed        bool      // 是否啟用中（觸發後停用，直到重新啟用）
+	LastPrice    float64   // 上次檢查的價格（crosses 判斷穿越用），0 表示尚未檢查
+	TriggeredAt  time.Time // 最後觸發時間，零值表示未觸發
+	CreatedAt    time.Time // 建立時間
+}
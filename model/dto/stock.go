@@ -1,9 +1,24 @@
 package dto
 
+import "time"
+
+// 交易方向
+const (
+	StockSideBuy  = "buy"
+	StockSideSell = "sell"
+)
+
+// Stock 交易紀錄（買進/賣出）
 type Stock struct {
-	ID     int64   // 流水號
-	UserID string  // 用戶 ID
-	Symbol string  // 標的
-	Units  float64 // 數量(股)
-	Price  float64 // 價格
+	ID       int64     // 流水號
+	UserID   string    // 用戶 ID
+	Symbol   string    // 標的
+	Side     string    // 交易方向 (buy/sell)
+	Units    float64   // 數量(股)
+	Price    float64   // 價格
+	Fee      float64   // 手續費
+	Tax      float64   // 交易稅
+	Broker   string    // 券商（空字串為未指定）
+	Currency string    // 幣別
+	TradedAt time.Time // 交易時間
 }
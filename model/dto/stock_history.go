@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// 交易紀錄異動類型
+const (
+	StockHistoryActionUpdate = "update"
+	StockHistoryActionDelete = "delete"
+)
+
+// StockHistory 交易紀錄異動歷程
+type StockHistory struct {
+	ID        int64     // 流水號
+	StockID   int64     // 交易紀錄流水號
+	UserID    string    // 用戶 ID
+	Action    string    // 異動類型 (update/delete)
+	Before    *Stock    // 異動前資料
+	After     *Stock    // 異動後資料（刪除時為 nil）
+	ChangedAt time.Time // 異動時間
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// TargetWeight 目標配置權重
+type TargetWeight struct {
+	UserID    string    // 用戶 ID
+	Target    string    // 標的代號，或 <分組方式>:<名稱> 表示分類（例如 sector:Energy）
+	Weight    float64   // 佔總資產（持倉市值加現金）的百分比
+	UpdatedAt time.Time // 更新時間
+}
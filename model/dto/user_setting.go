@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// ReportChannelDM 收益報告改以私訊發送
+const ReportChannelDM = "dm"
+
+// UserSetting 使用者設定
+type UserSetting struct {
+	UserID          string    // 用戶 ID
+	CostMethod      string    // 成本計算方式 (fifo/lifo/average)
+	ReportEnabled   bool      // 是否接收每日收益報告
+	ReportChannelID string    // 收益報告頻道（空字串為預設頻道，dm 為私訊）
+	Broker          string    // 預設券商（空字串為使用系統預設）
+	BaseCurrency    string    // 基準幣別（空字串為使用系統預設）
+	UpdatedAt       time.Time // 更新時間
+}
@@ -0,0 +1,10 @@
+-- 將 stock 表擴充為交易紀錄（買進/賣出）
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS side VARCHAR(4) NOT NULL DEFAULT 'buy';
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS fee DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS traded_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE stock DROP CONSTRAINT IF EXISTS stock_side_check;
+ALTER TABLE stock ADD CONSTRAINT stock_side_check CHECK (side IN ('buy', 'sell'));
+
+CREATE INDEX IF NOT EXISTS stock_user_symbol_traded_at_idx ON stock (user_id, symbol, traded_at);
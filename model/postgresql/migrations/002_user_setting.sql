@@ -0,0 +1,7 @@
+-- 使用者設定（成本計算方式等）
+CREATE TABLE IF NOT EXISTS user_setting (
+	user_id VARCHAR(32) PRIMARY KEY,
+	cost_method VARCHAR(10) NOT NULL DEFAULT 'average',
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT user_setting_cost_method_check CHECK (cost_method IN ('fifo', 'lifo', 'average'))
+);
@@ -0,0 +1,3 @@
+-- 收益報告的個人設定：是否接收與發送位置（空字串為預設頻道，'dm' 為私訊）
+ALTER TABLE user_setting ADD COLUMN IF NOT EXISTS report_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE user_setting ADD COLUMN IF NOT EXISTS report_channel_id VARCHAR(32) NOT NULL DEFAULT '';
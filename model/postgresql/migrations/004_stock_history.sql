@@ -0,0 +1,13 @@
+-- 交易紀錄異動歷程（修改/刪除前後的資料）
+CREATE TABLE IF NOT EXISTS stock_history (
+	id BIGSERIAL PRIMARY KEY,
+	stock_id BIGINT NOT NULL,
+	user_id VARCHAR(32) NOT NULL,
+	action VARCHAR(6) NOT NULL,
+	before_data JSONB NOT NULL,
+	after_data JSONB,
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT stock_history_action_check CHECK (action IN ('update', 'delete'))
+);
+
+CREATE INDEX IF NOT EXISTS stock_history_user_stock_idx ON stock_history (user_id, stock_id);
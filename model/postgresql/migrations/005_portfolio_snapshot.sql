@@ -0,0 +1,13 @@
+-- 每日投資組合快照（每位使用者每個標的一筆，symbol = '*' 為總計）
+CREATE TABLE IF NOT EXISTS portfolio_snapshot (
+	user_id VARCHAR(32) NOT NULL,
+	snapshot_date DATE NOT NULL,
+	symbol VARCHAR(16) NOT NULL,
+	units DOUBLE PRECISION NOT NULL DEFAULT 0,
+	cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+	value DOUBLE PRECISION NOT NULL DEFAULT 0,
+	profit DOUBLE PRECISION NOT NULL DEFAULT 0,
+	realized_profit DOUBLE PRECISION NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, symbol, snapshot_date)
+);
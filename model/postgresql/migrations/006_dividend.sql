@@ -0,0 +1,16 @@
+-- 股息紀錄（amount 為實際收到的總金額；同一標的同一發放日只保留一筆）
+CREATE TABLE IF NOT EXISTS dividend (
+	id BIGSERIAL PRIMARY KEY,
+	user_id VARCHAR(32) NOT NULL,
+	symbol VARCHAR(16) NOT NULL,
+	amount DOUBLE PRECISION NOT NULL,
+	currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+	pay_date DATE NOT NULL,
+	source VARCHAR(6) NOT NULL DEFAULT 'manual',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT dividend_source_check CHECK (source IN ('manual', 'auto')),
+	CONSTRAINT dividend_user_symbol_date_key UNIQUE (user_id, symbol, pay_date)
+);
+
+-- 每日快照加入累計股息收入，讓區間損益與報酬率計入股息
+ALTER TABLE portfolio_snapshot ADD COLUMN IF NOT EXISTS dividend_income DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- 公司行動（目前為股票分割/反分割）；確認後會調整分割日前的交易紀錄與快照數量
+CREATE TABLE IF NOT EXISTS corporate_action (
+	id BIGSERIAL PRIMARY KEY,
+	user_id VARCHAR(32) NOT NULL,
+	symbol VARCHAR(16) NOT NULL,
+	action_type VARCHAR(8) NOT NULL DEFAULT 'split',
+	effective_date DATE NOT NULL,
+	from_factor DOUBLE PRECISION NOT NULL,
+	to_factor DOUBLE PRECISION NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT corporate_action_type_check CHECK (action_type IN ('split')),
+	CONSTRAINT corporate_action_factor_check CHECK (from_factor > 0 AND to_factor > 0),
+	CONSTRAINT corporate_action_user_symbol_date_key UNIQUE (user_id, symbol, action_type, effective_date)
+);
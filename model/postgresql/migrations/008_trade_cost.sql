@@ -0,0 +1,9 @@
+-- 交易稅與券商：tax 為交易稅（例如賣出證交稅），broker 為計算手續費所依據的券商（空字串為未指定）
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS tax DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE stock ADD COLUMN IF NOT EXISTS broker VARCHAR(32) NOT NULL DEFAULT '';
+
+-- 股息預扣稅（amount 為稅前總金額，實際收入為 amount - tax）
+ALTER TABLE dividend ADD COLUMN IF NOT EXISTS tax DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- 使用者預設券商（空字串為使用 DEFAULT_BROKER 設定）
+ALTER TABLE user_setting ADD COLUMN IF NOT EXISTS broker VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- 使用者的基準幣別（空字串為使用 DEFAULT_BASE_CURRENCY 設定），收益報告與總計快照以此幣別計算
+ALTER TABLE user_setting ADD COLUMN IF NOT EXISTS base_currency VARCHAR(3) NOT NULL DEFAULT '';
+
+-- 快照金額的幣別：各標的為持倉幣別，總計為基準幣別（空字串為舊資料，未換算）
+ALTER TABLE portfolio_snapshot ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '';
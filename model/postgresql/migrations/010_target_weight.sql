@@ -0,0 +1,9 @@
+-- 目標配置權重；target 為標的代號（例如 AAPL）或分類（例如 sector:Information Technology、country:US、currency:TWD）
+CREATE TABLE IF NOT EXISTS target_weight (
+	user_id VARCHAR(32) NOT NULL,
+	target VARCHAR(128) NOT NULL,
+	weight DOUBLE PRECISION NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT target_weight_weight_check CHECK (weight >= 0 AND weight <= 100),
+	PRIMARY KEY (user_id, target)
+);
@@ -0,0 +1,18 @@
+-- 價格警示（above/below/crosses）；rearm_percent 為 0 時只觸發一次，大於 0 時價格回到門檻另一側超過此百分比後重新啟用
+CREATE TABLE IF NOT EXISTS price_alert (
+	id BIGSERIAL PRIMARY KEY,
+	user_id VARCHAR(32) NOT NULL,
+	channel_id VARCHAR(32) NOT NULL,
+	symbol VARCHAR(16) NOT NULL,
+	condition VARCHAR(8) NOT NULL,
+	price DOUBLE PRECISION NOT NULL,
+	rearm_percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+	armed BOOLEAN NOT NULL DEFAULT TRUE,
+	last_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+	triggered_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT price_alert_condition_check CHECK (condition IN ('above', 'below', 'crosses')),
+	CONSTRAINT price_alert_price_check CHECK (price > 0 AND rearm_percent >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS price_alert_user_idx ON price_alert (user_id);
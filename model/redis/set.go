@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+)
+
+// SAdd : 將成員加入集合 key，回傳實際新增（原本不存在）的數量
+func SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	conn, err := getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	return conn.SAdd(ctx, key, values...).Result()
+}
+
+// SRem : 從集合 key 移除成員，回傳實際移除的數量
+func SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	conn, err := getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	return conn.SRem(ctx, key, values...).Result()
+}
+
+// SMembers : 返回集合 key 的所有成員（無順序）
+func SMembers(ctx context.Context, key string) ([]string, error) {
+	conn, err := getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return conn.SMembers(ctx, key).Result()
+}
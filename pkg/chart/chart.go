@@ -0,0 +1,240 @@
+// Package chart 以標準庫繪製簡單的 PNG 折線圖，不依賴外部服務或字型
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// 常用顏色
+var (
+	Blue   = color.RGBA{R: 0x34, G: 0x98, B: 0xdb, A: 0xff}
+	Gray   = color.RGBA{R: 0x95, G: 0xa5, B: 0xa6, A: 0xff}
+	Orange = color.RGBA{R: 0xe6, G: 0x7e, B: 0x22, A: 0xff}
+	Green  = color.RGBA{R: 0x2e, G: 0xcc, B: 0x71, A: 0xff}
+	Red    = color.RGBA{R: 0xe7, G: 0x4c, B: 0x3c, A: 0xff}
+
+	background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	axisColor  = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	gridColor  = color.RGBA{R: 0xe5, G: 0xe5, B: 0xe5, A: 0xff}
+)
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 400
+	textScale     = 2
+	gridLines     = 5
+	marginLeft    = 90
+	marginRight   = 20
+	marginTop     = 20
+	marginBottom  = 40
+)
+
+// Series 一條折線，Values 與 LineChart.Labels 一一對應，NaN 表示該點沒有資料
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Values []float64
+}
+
+// LineChart 折線圖
+type LineChart struct {
+	Width  int
+	Height int
+	// X 軸標籤（例如日期），數量需與每條 Series 的 Values 相同
+	Labels []string
+	Series []Series
+}
+
+// Render 將折線圖以 PNG 格式寫入 w
+func (c *LineChart) Render(w io.Writer) error {
+	img, err := c.Draw()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Draw 繪製折線圖
+func (c *LineChart) Draw() (*image.RGBA, error) {
+	if len(c.Series) == 0 {
+		return nil, fmt.Errorf("沒有資料")
+	}
+
+	points := len(c.Labels)
+	if points < 2 {
+		return nil, fmt.Errorf("資料點不足，至少需要 2 個")
+	}
+	for _, s := range c.Series {
+		if len(s.Values) != points {
+			return nil, fmt.Errorf("%s 資料點數量 %d 與標籤數量 %d 不符", s.Name, len(s.Values), points)
+		}
+	}
+
+	minV, maxV, ok := c.valueRange()
+	if !ok {
+		return nil, fmt.Errorf("沒有有效的資料點")
+	}
+
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = defaultWidth
+	}
+	if height <= 0 {
+		height = defaultHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, background)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+
+	xAt := func(i int) int {
+		return plot.Min.X + int(math.Round(float64(i)*float64(plot.Dx())/float64(points-1)))
+	}
+	yAt := func(v float64) int {
+		return plot.Max.Y - int(math.Round((v-minV)/(maxV-minV)*float64(plot.Dy())))
+	}
+
+	// 水平格線與 Y 軸標籤
+	for i := 0; i <= gridLines; i++ {
+		v := minV + (maxV-minV)*float64(i)/gridLines
+		y := yAt(v)
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, gridColor, 1)
+		label := FormatValue(v)
+		drawText(img, plot.Min.X-8-textWidth(label, textScale), y-glyphHeight*textScale/2, label, axisColor, textScale)
+	}
+
+	// X 軸標籤：頭、尾與中間平均取樣，避免重疊
+	step := int(math.Ceil(float64(points) / 6))
+	for i := 0; i < points; i += step {
+		drawXLabel(img, c.Labels[i], xAt(i), plot.Max.Y+8)
+	}
+	if rest := (points - 1) % step; rest != 0 && rest*2 >= step {
+		drawXLabel(img, c.Labels[points-1], xAt(points-1), plot.Max.Y+8)
+	}
+
+	// 座標軸
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, axisColor, 1)
+	drawLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, axisColor, 1)
+
+	// 折線（NaN 會中斷線段）
+	for _, s := range c.Series {
+		prevX, prevY, hasPrev := 0, 0, false
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				hasPrev = false
+				continue
+			}
+			x, y := xAt(i), yAt(v)
+			if hasPrev {
+				drawLine(img, prevX, prevY, x, y, s.Color, 2)
+			} else {
+				fillRect(img, x-1, y-1, 2, 2, s.Color)
+			}
+			prevX, prevY, hasPrev = x, y, true
+		}
+	}
+
+	return img, nil
+}
+
+// valueRange 取得所有資料點的最小與最大值，並保留上下留白
+func (c *LineChart) valueRange() (minV, maxV float64, ok bool) {
+	minV, maxV = math.Inf(1), math.Inf(-1)
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			minV = math.Min(minV, v)
+			maxV = math.Max(maxV, v)
+			ok = true
+		}
+	}
+	if !ok {
+		return 0, 0, false
+	}
+
+	padding := (maxV - minV) * 0.05
+	if padding == 0 {
+		padding = math.Max(math.Abs(maxV)*0.05, 1)
+	}
+	return minV - padding, maxV + padding, true
+}
+
+// FormatValue Y 軸標籤格式，大數值以 K/M 縮寫
+func FormatValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e6:
+		return fmt.Sprintf("%.2fM", v/1e6)
+	case abs >= 1e4:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	case abs >= 100:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
+
+// drawXLabel 置中繪製 X 軸標籤，並限制在畫布範圍內
+func drawXLabel(img *image.RGBA, label string, centerX, y int) {
+	w := textWidth(label, textScale)
+	x := centerX - w/2
+	if maxX := img.Bounds().Max.X - 2 - w; x > maxX {
+		x = maxX
+	}
+	drawText(img, x, y, label, axisColor, textScale)
+}
+
+// fillRect 填滿矩形，超出畫布的部分會被裁切
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	rect := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for px := rect.Min.X; px < rect.Max.X; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
+
+// drawLine 以 Bresenham 演算法繪製線段
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, thickness int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+
+	for {
+		fillRect(img, x0-thickness/2, y0-thickness/2, thickness, thickness, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,157 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestLineChart_Render(t *testing.T) {
+	c := &LineChart{
+		Width:  400,
+		Height: 200,
+		Labels: []string{"07-01", "07-02", "07-03", "07-06"},
+		Series: []Series{
+			{Name: "value", Color: Blue, Values: []float64{100, 120, 90, 130}},
+			{Name: "cost", Color: Gray, Values: []float64{100, 100, 100, 100}},
+			{Name: "SPY", Color: Orange, Values: []float64{math.NaN(), 105, 110, 108}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := c.Render(&buf); err != nil {
+		t.Fatalf("Render() unexpected error = %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("Render() size = %v, want 400x200", b)
+	}
+
+	found := map[string]bool{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			for _, s := range c.Series {
+				sr, sg, sb, _ := s.Color.RGBA()
+				if r == sr && g == sg && bl == sb {
+					found[s.Name] = true
+				}
+			}
+		}
+	}
+	for _, s := range c.Series {
+		if !found[s.Name] {
+			t.Errorf("Render() series %s not drawn", s.Name)
+		}
+	}
+}
+
+func TestLineChart_Draw_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart *LineChart
+	}{
+		{name: "no series", chart: &LineChart{Labels: []string{"a", "b"}}},
+		{name: "single point", chart: &LineChart{Labels: []string{"a"}, Series: []Series{{Values: []float64{1}}}}},
+		{name: "length mismatch", chart: &LineChart{Labels: []string{"a", "b"}, Series: []Series{{Values: []float64{1}}}}},
+		{name: "all NaN", chart: &LineChart{Labels: []string{"a", "b"}, Series: []Series{{Values: []float64{math.NaN(), math.NaN()}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.chart.Draw(); err == nil {
+				t.Errorf("Draw() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{v: 12.345, want: "12.35"},
+		{v: 1234.6, want: "1235"},
+		{v: 56789, want: "56.8K"},
+		{v: -2500000, want: "-2.50M"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.v); got != tt.want {
+			t.Errorf("FormatValue(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestPieChart_Render(t *testing.T) {
+	c := &PieChart{
+		Width:  400,
+		Height: 200,
+		Slices: []Slice{
+			{Name: "Technology", Color: Blue, Value: 60},
+			{Name: "Financial", Color: Orange, Value: 30},
+			{Name: "Energy", Color: Green, Value: 10},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := c.Render(&buf); err != nil {
+		t.Fatalf("Render() unexpected error = %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("Render() size = %v, want 400x200", b)
+	}
+
+	// 圓心上方為第一個區塊，圓心左上方（約 315 度）為最後一個區塊
+	cx, cy, radius := 100, 100, 80
+	tests := []struct {
+		name string
+		x, y int
+		want Slice
+	}{
+		{name: "first slice at 12 o'clock", x: cx + 2, y: cy - radius/2, want: c.Slices[0]},
+		{name: "second slice at 8 o'clock", x: cx - radius/2, y: cy + radius/4, want: c.Slices[1]},
+		{name: "last slice at 11 o'clock", x: cx - radius/4, y: cy - radius/2, want: c.Slices[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, _ := img.At(tt.x, tt.y).RGBA()
+			wr, wg, wb, _ := tt.want.Color.RGBA()
+			if r != wr || g != wg || b != wb {
+				t.Errorf("pixel (%d, %d) is not %s", tt.x, tt.y, tt.want.Name)
+			}
+		})
+	}
+}
+
+func TestPieChart_Draw_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart *PieChart
+	}{
+		{name: "no slices", chart: &PieChart{}},
+		{name: "all zero", chart: &PieChart{Slices: []Slice{{Value: 0}}}},
+		{name: "negative value", chart: &PieChart{Slices: []Slice{{Value: 10}, {Value: -1}}}},
+		{name: "canvas too small", chart: &PieChart{Width: 100, Height: 30, Slices: []Slice{{Value: 1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.chart.Draw(); err == nil {
+				t.Errorf("Draw() error = nil, want error")
+			}
+		})
+	}
+}
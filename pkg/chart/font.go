@@ -0,0 +1,60 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// glyphs 3x5 點陣字型，只涵蓋座標軸標籤需要的字元
+// 每個字元 5 列，每列以 3 個 bit 表示（由左到右）
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'/': {0b001, 0b001, 0b010, 0b100, 0b100},
+	'%': {0b101, 0b001, 0b010, 0b100, 0b101},
+	'K': {0b101, 0b110, 0b100, 0b110, 0b101},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	' ': {},
+}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+// textWidth 文字寬度（像素）
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+1) - 1) * scale
+}
+
+// drawText 以點陣字型繪製文字，(x, y) 為左上角；不支援的字元略過
+func drawText(img *image.RGBA, x, y int, text string, c color.Color, scale int) {
+	for _, r := range text {
+		glyph, ok := glyphs[r]
+		if ok {
+			for row := 0; row < glyphHeight; row++ {
+				for col := 0; col < glyphWidth; col++ {
+					if glyph[row]&(1<<(glyphWidth-1-col)) == 0 {
+						continue
+					}
+					fillRect(img, x+col*scale, y+row*scale, scale, scale, c)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
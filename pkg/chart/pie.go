@@ -0,0 +1,128 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// 圓餅圖額外使用的顏色
+var (
+	Purple = color.RGBA{R: 0x9b, G: 0x59, B: 0xb6, A: 0xff}
+	Yellow = color.RGBA{R: 0xf1, G: 0xc4, B: 0x0f, A: 0xff}
+	Teal   = color.RGBA{R: 0x1a, G: 0xbc, B: 0x9c, A: 0xff}
+	Navy   = color.RGBA{R: 0x34, G: 0x49, B: 0x5e, A: 0xff}
+)
+
+// Palette 依序分配給圓餅圖各區塊的顏色
+var Palette = []color.RGBA{Blue, Orange, Green, Red, Purple, Yellow, Teal, Navy, Gray}
+
+const (
+	defaultPieWidth  = 600
+	defaultPieHeight = 400
+	pieMargin        = 20
+	legendWidth      = 160
+	legendSwatch     = 16
+	legendRowHeight  = 28
+)
+
+// Slice 圓餅圖的一個區塊
+type Slice struct {
+	Name  string
+	Color color.RGBA
+	Value float64
+}
+
+// PieChart 圓餅圖，右側圖例以編號（由 1 起算）與百分比標示各區塊
+// 點陣字型不支援中英文字，區塊名稱需由呼叫端依編號另外說明
+type PieChart struct {
+	Width  int
+	Height int
+	Slices []Slice
+}
+
+// Render 將圓餅圖以 PNG 格式寫入 w
+func (c *PieChart) Render(w io.Writer) error {
+	img, err := c.Draw()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Draw 繪製圓餅圖，區塊由 12 點鐘方向順時針排列
+func (c *PieChart) Draw() (*image.RGBA, error) {
+	if len(c.Slices) == 0 {
+		return nil, fmt.Errorf("沒有資料")
+	}
+
+	var total float64
+	for _, s := range c.Slices {
+		if s.Value < 0 || math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			return nil, fmt.Errorf("%s 數值無效: %v", s.Name, s.Value)
+		}
+		total += s.Value
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("沒有有效的資料")
+	}
+
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = defaultPieWidth
+	}
+	if height <= 0 {
+		height = defaultPieHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, background)
+
+	// 各區塊結束位置佔整圓的比例
+	ends := make([]float64, len(c.Slices))
+	var sum float64
+	for i, s := range c.Slices {
+		sum += s.Value
+		ends[i] = sum / total
+	}
+
+	radius := (min(width-legendWidth, height) - 2*pieMargin) / 2
+	if radius <= 0 {
+		return nil, fmt.Errorf("畫布過小: %dx%d", width, height)
+	}
+	cx, cy := pieMargin+radius, height/2
+
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			dx, dy := float64(x-cx), float64(y-cy)
+			if dx*dx+dy*dy > float64(radius*radius) {
+				continue
+			}
+			angle := math.Atan2(dx, -dy)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+			fraction := angle / (2 * math.Pi)
+			i := 0
+			for i < len(ends)-1 && fraction >= ends[i] {
+				i++
+			}
+			img.Set(x, y, c.Slices[i].Color)
+		}
+	}
+
+	// 圖例：色塊、編號與百分比
+	legendX := cx + radius + pieMargin*2
+	legendY := cy - len(c.Slices)*legendRowHeight/2
+	for i, s := range c.Slices {
+		y := legendY + i*legendRowHeight
+		fillRect(img, legendX, y, legendSwatch, legendSwatch, s.Color)
+		label := fmt.Sprintf("%d %.1f%%", i+1, s.Value/total*100)
+		drawText(img, legendX+legendSwatch+8, y+(legendSwatch-glyphHeight*textScale)/2, label, axisColor, textScale)
+	}
+
+	return img, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // GetDiscordToken 獲取Discord Token
@@ -18,6 +19,18 @@ type TaskConfig struct {
 	WatchListChannelID string
 	// 收益報告頻道
 	ProfitReportChannelID string
+	// 市場概況頻道（未設定時使用觀察清單頻道）
+	MarketOverviewChannelID string
+	// 市場概況顯示的指數/ETF
+	MarketOverviewSymbols []string
+	// 績效圖與每週報告的比較基準
+	PerformanceBenchmark string
+	// 是否依資料來源的配息資料自動登記股息
+	DividendAutoDiscovery bool
+	// 是否依資料來源的分割資料提醒持有者調整
+	SplitAutoDetection bool
+	// 使用者未設定基準幣別時，收益報告使用的幣別
+	DefaultBaseCurrency string
 	// 默認用戶ID（用於收益報告）
 	DefaultUserID string
 	// CheckChange 任務併發上限
@@ -32,6 +45,16 @@ type TaskConfig struct {
 	ExternalCallTimeoutSeconds int
 	// 任務錯誤通知節流間隔（秒）
 	ErrorNotifyCooldownSeconds int
+	// 觀察清單未自訂門檻時的上漲警告門檻（%）
+	WatchAlertUpPercent float64
+	// 觀察清單未自訂門檻時的下跌警告門檻（%）
+	WatchAlertDownPercent float64
+	// 觀察清單漲跌幅警告級距（%），每天每個級距只通知一次
+	WatchAlertTiers []float64
+	// 今日成交量超過日均量此倍數時發出成交量異常警告，0 表示不檢查
+	VolumeSpikeMultiplier float64
+	// 日均量計算的交易日數
+	VolumeAverageDays int
 }
 
 // GetTaskConfig 獲取定時任務配置
@@ -40,6 +63,12 @@ func GetTaskConfig() *TaskConfig {
 		CryptoPriceChannelID:          getEnv("CRYPTO_PRICE_CHANNEL_ID", ""),
 		WatchListChannelID:            getEnv("WATCH_LIST_CHANNEL_ID", ""),
 		ProfitReportChannelID:         getEnv("PROFIT_REPORT_CHANNEL_ID", ""),
+		MarketOverviewChannelID:       getEnv("MARKET_OVERVIEW_CHANNEL_ID", getEnv("WATCH_LIST_CHANNEL_ID", "")),
+		MarketOverviewSymbols:         getEnvList("MARKET_OVERVIEW_SYMBOLS", []string{"SPY", "QQQ", "SOXX", "EWT"}),
+		PerformanceBenchmark:          getEnv("PERFORMANCE_BENCHMARK", "SPY"),
+		DividendAutoDiscovery:         getEnvBool("DIVIDEND_AUTO_DISCOVERY", false),
+		SplitAutoDetection:            getEnvBool("SPLIT_AUTO_DETECTION", false),
+		DefaultBaseCurrency:           strings.ToUpper(getEnv("DEFAULT_BASE_CURRENCY", "TWD")),
 		DefaultUserID:                 getEnv("DEFAULT_USER_ID", ""),
 		CheckChangeMaxConcurrency:     getEnvInt("TASK_CHECK_CHANGE_MAX_CONCURRENCY", 5),
 		CalculateProfitMaxConcurrency: getEnvInt("TASK_CALCULATE_PROFIT_MAX_CONCURRENCY", 5),
@@ -47,6 +76,90 @@ func GetTaskConfig() *TaskConfig {
 		CalculateProfitTimeoutSeconds: getEnvInt("TASK_CALCULATE_PROFIT_TIMEOUT_SECONDS", 180),
 		ExternalCallTimeoutSeconds:    getEnvInt("TASK_EXTERNAL_CALL_TIMEOUT_SECONDS", 15),
 		ErrorNotifyCooldownSeconds:    getEnvInt("TASK_ERROR_NOTIFY_COOLDOWN_SECONDS", 60),
+		WatchAlertUpPercent:           getEnvFloat("WATCH_ALERT_UP_PERCENT", 3),
+		WatchAlertDownPercent:         getEnvFloat("WATCH_ALERT_DOWN_PERCENT", 3),
+		WatchAlertTiers:               getEnvFloatList("WATCH_ALERT_TIERS", []float64{3, 5, 10}),
+		VolumeSpikeMultiplier:         getEnvFloat("VOLUME_SPIKE_MULTIPLIER", 3),
+		VolumeAverageDays:             getEnvInt("VOLUME_AVERAGE_DAYS", 20),
+	}
+}
+
+// StreamConfig 即時報價串流相關配置
+type StreamConfig struct {
+	// 是否啟用 WebSocket 即時串流
+	Enabled bool
+	// Finnhub WebSocket 位址
+	URL string
+	// Finnhub API Key
+	APIKey string
+	// 同一標的兩次警告判斷的最短間隔（秒）
+	EvaluateIntervalSeconds int
+	// 觀察清單重新同步訂閱的間隔（秒）
+	RefreshIntervalSeconds int
+	// 重新連線的最長等待時間（秒）
+	MaxBackoffSeconds int
+}
+
+// GetStreamConfig 獲取即時報價串流配置
+func GetStreamConfig() *StreamConfig {
+	return &StreamConfig{
+		Enabled:                 getEnvBool("STREAM_ENABLED", false),
+		URL:                     getEnv("FINNHUB_WS_URL", "wss://ws.finnhub.io"),
+		APIKey:                  getEnv("APIKey", ""),
+		EvaluateIntervalSeconds: getEnvInt("STREAM_EVALUATE_INTERVAL_SECONDS", 5),
+		RefreshIntervalSeconds:  getEnvInt("STREAM_REFRESH_INTERVAL_SECONDS", 60),
+		MaxBackoffSeconds:       getEnvInt("STREAM_MAX_BACKOFF_SECONDS", 60),
+	}
+}
+
+// MarketConfig 交易所行事曆相關配置
+type MarketConfig struct {
+	// 美股額外休市日（逗號分隔 YYYY-MM-DD，例如臨時休市）
+	USExtraHolidays string
+	// 台股休市日（逗號分隔 YYYY-MM-DD）
+	TWHolidays string
+}
+
+// GetMarketConfig 獲取交易所行事曆配置
+func GetMarketConfig() *MarketConfig {
+	return &MarketConfig{
+		USExtraHolidays: getEnv("MARKET_US_EXTRA_HOLIDAYS", ""),
+		TWHolidays:      getEnv("MARKET_TW_HOLIDAYS", ""),
+	}
+}
+
+// FeeConfig 交易成本相關配置
+type FeeConfig struct {
+	// 各券商的預設費率（逗號分隔，每筆格式 <券商>:<手續費率>:<最低手續費>[:<賣出交易稅率>[:<股息預扣稅率>]]）
+	BrokerFeeSchedules []string
+	// 使用者未設定券商時使用的券商
+	DefaultBroker string
+}
+
+// GetFeeConfig 獲取交易成本配置
+func GetFeeConfig() *FeeConfig {
+	return &FeeConfig{
+		BrokerFeeSchedules: getEnvList("BROKER_FEE_SCHEDULES", nil),
+		DefaultBroker:      getEnv("DEFAULT_BROKER", ""),
+	}
+}
+
+// AllocationConfig 資產配置報告相關配置
+type AllocationConfig struct {
+	// 單一持倉佔總市值超過此百分比時提示集中風險
+	ConcentrationPercent int
+	// 公司基本資料快取時間（小時）
+	ProfileCacheHours int
+	// 再平衡建議的最小交易金額（基準幣別）
+	RebalanceMinTrade int
+}
+
+// GetAllocationConfig 獲取資產配置報告配置
+func GetAllocationConfig() *AllocationConfig {
+	return &AllocationConfig{
+		ConcentrationPercent: getEnvInt("ALLOCATION_CONCENTRATION_PERCENT", 25),
+		ProfileCacheHours:    getEnvInt("COMPANY_PROFILE_CACHE_HOURS", 168),
+		RebalanceMinTrade:    getEnvInt("REBALANCE_MIN_TRADE", 100),
 	}
 }
 
@@ -66,3 +179,54 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if floatVal, err := strconv.ParseFloat(val, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultVal
+}
+
+func getEnvList(key string, defaultVal []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return defaultVal
+	}
+	return list
+}
+
+func getEnvFloatList(key string, defaultVal []float64) []float64 {
+	var list []float64
+	for _, item := range getEnvList(key, nil) {
+		floatVal, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return defaultVal
+		}
+		list = append(list, floatVal)
+	}
+	if len(list) == 0 {
+		return defaultVal
+	}
+	return list
+}
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			return boolVal
+		}
+	}
+	return defaultVal
+}
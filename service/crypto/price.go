@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"discordBot/service/client"
 )
@@ -25,6 +27,12 @@ const (
 
 var httpClient = client.NewHTTPClientWithEnv("CRYPTO")
 
+var (
+	lastMu        sync.RWMutex
+	lastPrice     float64
+	lastFetchedAt time.Time
+)
+
 // GetPrice 獲取ETH當前價格
 func GetPrice() (float64, error) {
 	return GetPriceWithContext(context.Background())
@@ -45,5 +53,23 @@ func GetPriceWithContext(ctx context.Context) (float64, error) {
 		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	lastMu.Lock()
+	lastPrice = info.Ethereum.Price
+	lastFetchedAt = time.Now()
+	lastMu.Unlock()
+
 	return info.Ethereum.Price, nil
 }
+
+// GetCachedPriceWithContext 取得 ETH 價格，maxAge 內已取得過則直接使用快取
+func GetCachedPriceWithContext(ctx context.Context, maxAge time.Duration) (float64, error) {
+	lastMu.RLock()
+	price, fetchedAt := lastPrice, lastFetchedAt
+	lastMu.RUnlock()
+
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < maxAge {
+		return price, nil
+	}
+
+	return GetPriceWithContext(ctx)
+}
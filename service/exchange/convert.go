@@ -2,16 +2,16 @@ package exchange
 
 import (
 	"context"
+	"fmt"
 
 	"discordBot/service/client"
 )
 
 type (
-	apiInfo struct {
-		USDTWD usdtwd `json:"USDTWD"`
-	}
+	// apiInfo 以幣別組合為 key，例如 USDTWD
+	apiInfo map[string]quote
 
-	usdtwd struct {
+	quote struct {
 		Exrate float64 `json:"Exrate"`
 	}
 )
@@ -68,3 +68,29 @@ func ConvertExchangeWithContext(ctx context.Context, oldMoney []float64) ([]floa
 
 	return newMoney, nil
 }
+
+// Rates 各幣別兌美元匯率（1 美元可兌換的數量），key 為幣別代碼
+type Rates map[string]float64
+
+// Convert 將金額由 from 幣別換算為 to 幣別
+func (r Rates) Convert(amount float64, from string, to string) (float64, error) {
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+
+	fromRate, ok := r[from]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("查無 %s 匯率", from)
+	}
+	toRate, ok := r[to]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("查無 %s 匯率", to)
+	}
+
+	return amount / fromRate * toRate, nil
+}
+
+// GetRatesWithContext : 取得各幣別兌美元匯率
+func GetRatesWithContext(ctx context.Context) (Rates, error) {
+	return rateProvider.GetRates(ctx)
+}
@@ -158,6 +158,72 @@ func Test_ConvertExchangeWithContext(t *testing.T) {
 	}
 }
 
+func Test_RatesConvert(t *testing.T) {
+	rates := Rates{"USD": 1, "TWD": 32, "JPY": 160}
+
+	tests := []struct {
+		name    string
+		amount  float64
+		from    string
+		to      string
+		want    float64
+		wantErr bool
+	}{
+		{name: "usd to twd", amount: 10, from: "USD", to: "TWD", want: 320},
+		{name: "twd to usd", amount: 320, from: "TWD", to: "USD", want: 10},
+		{name: "cross rate", amount: 1000, from: "JPY", to: "TWD", want: 200},
+		{name: "same currency", amount: 5, from: "EUR", to: "EUR", want: 5},
+		{name: "unknown currency", amount: 5, from: "EUR", to: "TWD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.amount, tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Convert() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Convert() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// stubHTTPClient 回傳固定內容的 HTTPClient
+type stubHTTPClient struct {
+	body []byte
+}
+
+func (c stubHTTPClient) GetWithRetry(ctx context.Context, url string, maxRetries int) ([]byte, error) {
+	return c.body, nil
+}
+
+func Test_defaultRateProvider_GetRates(t *testing.T) {
+	provider := NewRateProvider(stubHTTPClient{body: []byte(`{
+		"USDTWD": {"Exrate": 32.1, "UTC": "2026-10-19 08:00:00"},
+		"USDJPY": {"Exrate": 150.5, "UTC": "2026-10-19 08:00:00"},
+		"USDXXX": {"Exrate": 0},
+		"EURUSD": {"Exrate": 1.1}
+	}`)})
+
+	rates, err := provider.GetRates(context.Background())
+	if err != nil {
+		t.Fatalf("GetRates() unexpected error = %v", err)
+	}
+	want := Rates{"USD": 1, "TWD": 32.1, "JPY": 150.5}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("GetRates() = %v, want %v", rates, want)
+	}
+
+	rate, err := provider.GetRate(context.Background())
+	if err != nil || rate != 32.1 {
+		t.Errorf("GetRate() = %v, %v, want 32.1", rate, err)
+	}
+}
+
 // 輔助函數
 func contains(s, substr string) bool {
 	return len(substr) <= len(s) && (s == substr || len(s) > 0 && containsHelper(s, substr))
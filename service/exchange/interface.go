@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // ExchangeRateProvider 匯率提供者接口
 type ExchangeRateProvider interface {
+	// GetRate 美元兌台幣匯率
 	GetRate(ctx context.Context) (float64, error)
+	// GetRates 各幣別兌美元匯率（1 美元可兌換的數量）
+	GetRates(ctx context.Context) (Rates, error)
 }
 
 // HTTPClient HTTP 客戶端接口
@@ -28,20 +32,40 @@ func NewRateProvider(client HTTPClient) ExchangeRateProvider {
 
 // GetRate 獲取匯率
 func (p *defaultRateProvider) GetRate(ctx context.Context) (float64, error) {
-	body, err := p.client.GetWithRetry(ctx, exchangeAPIURL, maxRetries)
+	rates, err := p.GetRates(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
-	}
-
-	info := &apiInfo{}
-	if err := json.Unmarshal(body, info); err != nil {
-		return 0, fmt.Errorf("failed to unmarshal exchange rate: %w", err)
+		return 0, err
 	}
 
-	exrate := info.USDTWD.Exrate
+	exrate := rates["TWD"]
 	if exrate == 0 {
 		return 0, fmt.Errorf("invalid exchange rate: %f", exrate)
 	}
 
 	return exrate, nil
 }
+
+// GetRates 獲取各幣別兌美元匯率
+func (p *defaultRateProvider) GetRates(ctx context.Context) (Rates, error) {
+	body, err := p.client.GetWithRetry(ctx, exchangeAPIURL, maxRetries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+
+	info := apiInfo{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal exchange rate: %w", err)
+	}
+
+	rates := Rates{"USD": 1}
+	for pair, quote := range info {
+		// 只取美元對其他幣別的報價，例如 USDTWD
+		currency, ok := strings.CutPrefix(pair, "USD")
+		if !ok || len(currency) != 3 || quote.Exrate <= 0 {
+			continue
+		}
+		rates[currency] = quote.Exrate
+	}
+
+	return rates, nil
+}
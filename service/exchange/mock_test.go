@@ -4,8 +4,9 @@ import "context"
 
 // MockRateProvider ExchangeRateProvider 的 mock 實現
 type MockRateProvider struct {
-	Rate float64
-	Err  error
+	Rate  float64
+	Rates Rates
+	Err   error
 }
 
 // GetRate 實現 ExchangeRateProvider 接口
@@ -15,3 +16,11 @@ func (m *MockRateProvider) GetRate(ctx context.Context) (float64, error) {
 	}
 	return m.Rate, nil
 }
+
+// GetRates 實現 ExchangeRateProvider 接口
+func (m *MockRateProvider) GetRates(ctx context.Context) (Rates, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Rates, nil
+}
@@ -0,0 +1,246 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discordBot/pkg/logger"
+)
+
+const dateLayout = "2006-01-02"
+
+// Session 交易時段
+type Session string
+
+const (
+	SessionPreMarket  Session = "pre"
+	SessionRegular    Session = "regular"
+	SessionAfterHours Session = "after"
+	SessionClosed     Session = "closed"
+)
+
+// clock 一天中的時刻（自午夜起的分鐘數）
+type clock int
+
+func hm(hour, minute int) clock {
+	return clock(hour*60 + minute)
+}
+
+// Exchange 交易所行事曆，所有時刻皆以交易所當地時區計算
+type Exchange struct {
+	Code     string
+	Name     string
+	Location *time.Location
+
+	preOpen    clock
+	open       clock
+	close      clock
+	earlyClose clock
+	afterClose clock
+
+	// holidays 回傳指定年度的休市日（日期 -> 名稱）
+	holidays func(year int) map[string]string
+	// earlyCloses 回傳指定年度的提前收盤日
+	earlyCloses func(year int, holidays map[string]string) map[string]struct{}
+	// extraHolidays 額外設定的休市日（臨時休市、農曆假期等）
+	extraHolidays map[string]string
+}
+
+// Holiday 判斷指定日期是否為休市日
+func (e *Exchange) Holiday(t time.Time) (string, bool) {
+	local := t.In(e.Location)
+	date := local.Format(dateLayout)
+
+	if name, ok := e.extraHolidays[date]; ok {
+		return name, true
+	}
+
+	if e.holidays == nil {
+		return "", false
+	}
+
+	name, ok := e.holidays(local.Year())[date]
+	return name, ok
+}
+
+// IsTradingDay 判斷指定日期是否為交易日
+func (e *Exchange) IsTradingDay(t time.Time) bool {
+	local := t.In(e.Location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+
+	_, holiday := e.Holiday(local)
+	return !holiday
+}
+
+// IsEarlyClose 判斷指定日期是否提前收盤
+func (e *Exchange) IsEarlyClose(t time.Time) bool {
+	if e.earlyCloses == nil || !e.IsTradingDay(t) {
+		return false
+	}
+
+	local := t.In(e.Location)
+	var holidays map[string]string
+	if e.holidays != nil {
+		holidays = e.holidays(local.Year())
+	}
+
+	_, ok := e.earlyCloses(local.Year(), holidays)[local.Format(dateLayout)]
+	return ok
+}
+
+// SessionBounds 取得指定日期的正規交易開收盤時間，非交易日 ok 為 false
+func (e *Exchange) SessionBounds(t time.Time) (open, close time.Time, ok bool) {
+	if !e.IsTradingDay(t) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	closeAt := e.close
+	if e.IsEarlyClose(t) {
+		closeAt = e.earlyClose
+	}
+
+	return e.at(t, e.open), e.at(t, closeAt), true
+}
+
+// IsOpen 判斷指定時間是否為正規交易時段
+func (e *Exchange) IsOpen(t time.Time) bool {
+	return e.SessionAt(t) == SessionRegular
+}
+
+// SessionAt 判斷指定時間所屬的交易時段
+func (e *Exchange) SessionAt(t time.Time) Session {
+	open, close, ok := e.SessionBounds(t)
+	if !ok {
+		return SessionClosed
+	}
+
+	afterClose := e.at(t, e.afterClose)
+	if e.IsEarlyClose(t) {
+		// 提前收盤日盤後時段同樣縮短
+		afterClose = close.Add(time.Duration(e.afterClose-e.close) * time.Minute)
+	}
+
+	switch {
+	case !t.Before(e.at(t, e.preOpen)) && t.Before(open):
+		return SessionPreMarket
+	case !t.Before(open) && t.Before(close):
+		return SessionRegular
+	case !t.Before(close) && t.Before(afterClose):
+		return SessionAfterHours
+	default:
+		return SessionClosed
+	}
+}
+
+// NextOpen 取得 t 之後的下一次開盤時間
+func (e *Exchange) NextOpen(t time.Time) time.Time {
+	return e.nextBound(t, true)
+}
+
+// NextClose 取得 t 之後的下一次收盤時間
+func (e *Exchange) NextClose(t time.Time) time.Time {
+	return e.nextBound(t, false)
+}
+
+func (e *Exchange) nextBound(t time.Time, open bool) time.Time {
+	day := t.In(e.Location)
+	// 最長連續休市不會超過兩週
+	for i := 0; i < 15; i++ {
+		if o, c, ok := e.SessionBounds(day); ok {
+			bound := c
+			if open {
+				bound = o
+			}
+			if bound.After(t) {
+				return bound
+			}
+		}
+		day = e.at(day, 0).AddDate(0, 0, 1)
+	}
+
+	logger.Error("找不到下一個交易時段", "exchange", e.Code, "time", t)
+	return time.Time{}
+}
+
+// NextTradingDay 取得 t 當天（交易所時區）之後的下一個交易日（當地 00:00）
+func (e *Exchange) NextTradingDay(t time.Time) time.Time {
+	return e.adjacentTradingDay(t, 1)
+}
+
+// PrevTradingDay 取得 t 當天（交易所時區）之前的上一個交易日（當地 00:00）
+func (e *Exchange) PrevTradingDay(t time.Time) time.Time {
+	return e.adjacentTradingDay(t, -1)
+}
+
+func (e *Exchange) adjacentTradingDay(t time.Time, step int) time.Time {
+	day := e.at(t, 0)
+	for i := 0; i < 15; i++ {
+		day = time.Date(day.Year(), day.Month(), day.Day()+step, 0, 0, 0, 0, e.Location)
+		if e.IsTradingDay(day) {
+			return day
+		}
+	}
+
+	logger.Error("找不到相鄰交易日", "exchange", e.Code, "time", t)
+	return time.Time{}
+}
+
+// isFirstTradingDayOfWeek 判斷指定日期是否為該週（週一起算）第一個交易日
+func (e *Exchange) isFirstTradingDayOfWeek(t time.Time) bool {
+	if !e.IsTradingDay(t) {
+		return false
+	}
+
+	prev := e.PrevTradingDay(t)
+	local := e.at(t, 0)
+	daysSinceMonday := (int(local.Weekday()) + 6) % 7
+	monday := time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, e.Location)
+	return prev.Before(monday)
+}
+
+// at 取得 t 當天（交易所時區）指定時刻
+func (e *Exchange) at(t time.Time, c clock) time.Time {
+	local := t.In(e.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), int(c)/60, int(c)%60, 0, 0, e.Location)
+}
+
+// Get 依代碼取得交易所行事曆（US、TW）
+func Get(code string) (*Exchange, error) {
+	switch strings.ToUpper(code) {
+	case "US":
+		return US(), nil
+	case "TW":
+		return TW(), nil
+	default:
+		return nil, fmt.Errorf("不支援的交易所: %s", code)
+	}
+}
+
+// parseExtraHolidays 解析以逗號分隔的日期清單 (YYYY-MM-DD)
+func parseExtraHolidays(raw string) map[string]string {
+	holidays := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, item); err != nil {
+			logger.Warn("忽略無效的休市日設定", "value", item)
+			continue
+		}
+		holidays[item] = "休市"
+	}
+	return holidays
+}
+
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Error("無法載入時區", "location", name, "error", err)
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,188 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_usHolidays(t *testing.T) {
+	tests := []struct {
+		year int
+		want []string
+	}{
+		{
+			year: 2026,
+			want: []string{
+				"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25",
+				"2026-06-19", "2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25",
+			},
+		},
+		{
+			// 元旦適逢週六不補假、聖誕節適逢週日補週一
+			year: 2022,
+			want: []string{
+				"2022-01-17", "2022-02-21", "2022-04-15", "2022-05-30",
+				"2022-06-20", "2022-07-04", "2022-09-05", "2022-11-24", "2022-12-26",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := usHolidays(tt.year)
+		if len(got) != len(tt.want) {
+			t.Errorf("usHolidays(%d) = %v, want %v", tt.year, got, tt.want)
+			continue
+		}
+		for _, d := range tt.want {
+			if _, ok := got[d]; !ok {
+				t.Errorf("usHolidays(%d) missing %s", tt.year, d)
+			}
+		}
+	}
+}
+
+func Test_easter(t *testing.T) {
+	tests := map[int]string{
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2027: "2027-03-28",
+	}
+
+	for year, want := range tests {
+		if got := easter(year).Format(dateLayout); got != want {
+			t.Errorf("easter(%d) = %v, want %v", year, got, want)
+		}
+	}
+}
+
+func Test_Exchange_SessionBounds(t *testing.T) {
+	us := US()
+	ny := us.Location
+
+	tests := []struct {
+		name      string
+		day       time.Time
+		wantOK    bool
+		wantOpen  time.Time
+		wantClose time.Time
+	}{
+		{
+			name:      "regular day in EDT",
+			day:       time.Date(2026, 7, 14, 12, 0, 0, 0, ny),
+			wantOK:    true,
+			wantOpen:  time.Date(2026, 7, 14, 13, 30, 0, 0, time.UTC),
+			wantClose: time.Date(2026, 7, 14, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "regular day in EST",
+			day:       time.Date(2026, 12, 15, 12, 0, 0, 0, ny),
+			wantOK:    true,
+			wantOpen:  time.Date(2026, 12, 15, 14, 30, 0, 0, time.UTC),
+			wantClose: time.Date(2026, 12, 15, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day after thanksgiving closes early",
+			day:       time.Date(2026, 11, 27, 12, 0, 0, 0, ny),
+			wantOK:    true,
+			wantOpen:  time.Date(2026, 11, 27, 14, 30, 0, 0, time.UTC),
+			wantClose: time.Date(2026, 11, 27, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "good friday",
+			day:    time.Date(2026, 4, 3, 12, 0, 0, 0, ny),
+			wantOK: false,
+		},
+		{
+			name:   "weekend",
+			day:    time.Date(2026, 7, 18, 12, 0, 0, 0, ny),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, close, ok := us.SessionBounds(tt.day)
+			if ok != tt.wantOK {
+				t.Fatalf("SessionBounds() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !open.Equal(tt.wantOpen) || !close.Equal(tt.wantClose) {
+				t.Errorf("SessionBounds() = %v - %v, want %v - %v", open.UTC(), close.UTC(), tt.wantOpen, tt.wantClose)
+			}
+		})
+	}
+}
+
+func Test_Exchange_SessionAt(t *testing.T) {
+	us := US()
+
+	tests := []struct {
+		name string
+		time time.Time
+		want Session
+	}{
+		{name: "pre-market", time: time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC), want: SessionPreMarket},
+		{name: "regular", time: time.Date(2026, 7, 14, 15, 0, 0, 0, time.UTC), want: SessionRegular},
+		{name: "after-hours", time: time.Date(2026, 7, 14, 21, 0, 0, 0, time.UTC), want: SessionAfterHours},
+		{name: "holiday", time: time.Date(2026, 11, 26, 15, 0, 0, 0, time.UTC), want: SessionClosed},
+		{name: "early close after-hours", time: time.Date(2026, 11, 27, 19, 0, 0, 0, time.UTC), want: SessionAfterHours},
+		{name: "early close after-hours ended", time: time.Date(2026, 11, 27, 22, 30, 0, 0, time.UTC), want: SessionClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := us.SessionAt(tt.time); got != tt.want {
+				t.Errorf("SessionAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Exchange_NextOpenClose(t *testing.T) {
+	us := US()
+
+	// 2026-03-08 美國開始夏令時間：週五收盤後的下一次開盤為週一 13:30 UTC
+	friday := time.Date(2026, 3, 6, 21, 30, 0, 0, time.UTC)
+	if got, want := us.NextOpen(friday), time.Date(2026, 3, 9, 13, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextOpen() = %v, want %v", got.UTC(), want)
+	}
+
+	// 耶穌受難日前一天收盤後，下一次開盤跳過週五與週末
+	thursday := time.Date(2026, 4, 2, 21, 0, 0, 0, time.UTC)
+	if got, want := us.NextOpen(thursday), time.Date(2026, 4, 6, 13, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextOpen() = %v, want %v", got.UTC(), want)
+	}
+
+	// 聖誕夜提前收盤
+	christmasEve := time.Date(2026, 12, 24, 15, 0, 0, 0, time.UTC)
+	if got, want := us.NextClose(christmasEve), time.Date(2026, 12, 24, 18, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextClose() = %v, want %v", got.UTC(), want)
+	}
+}
+
+func Test_ExtraHolidays(t *testing.T) {
+	t.Setenv("MARKET_TW_HOLIDAYS", "2026-02-16, invalid ,2026-02-17")
+
+	tw := TW()
+	if tw.IsTradingDay(time.Date(2026, 2, 16, 10, 0, 0, 0, tw.Location)) {
+		t.Errorf("IsTradingDay() configured holiday = true, want false")
+	}
+	if !tw.IsTradingDay(time.Date(2026, 2, 18, 10, 0, 0, 0, tw.Location)) {
+		t.Errorf("IsTradingDay() regular day = false, want true")
+	}
+	if got := tw.SessionAt(time.Date(2026, 2, 18, 13, 0, 0, 0, tw.Location)); got != SessionRegular {
+		t.Errorf("SessionAt() = %v, want %v", got, SessionRegular)
+	}
+}
+
+func Test_Get(t *testing.T) {
+	if _, err := Get("us"); err != nil {
+		t.Errorf("Get(us) unexpected error = %v", err)
+	}
+	if _, err := Get("JP"); err == nil {
+		t.Errorf("Get(JP) error = nil, want error")
+	}
+}
@@ -0,0 +1,91 @@
+package market
+
+import "time"
+
+// maxScheduleDays 向後搜尋交易日的最長天數
+const maxScheduleDays = 15
+
+// IntervalSchedule 在正規交易時段內每隔固定時間觸發（實作 cron.Schedule）
+// 以開盤時間為起點對齊，休市日與收盤後不觸發
+type IntervalSchedule struct {
+	exchange *Exchange
+	interval time.Duration
+}
+
+// NewIntervalSchedule 創建交易時段內的固定間隔排程
+func NewIntervalSchedule(exchange *Exchange, interval time.Duration) *IntervalSchedule {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	return &IntervalSchedule{exchange: exchange, interval: interval}
+}
+
+// Next 取得 t 之後的下一次觸發時間
+func (s *IntervalSchedule) Next(t time.Time) time.Time {
+	day := t.In(s.exchange.Location)
+	for i := 0; i < maxScheduleDays; i++ {
+		if open, close, ok := s.exchange.SessionBounds(day); ok {
+			if t.Before(open) {
+				return open
+			}
+
+			next := open.Add((t.Sub(open)/s.interval + 1) * s.interval)
+			if next.Before(close) {
+				return next
+			}
+		}
+		day = s.exchange.at(day, 0).AddDate(0, 0, 1)
+	}
+
+	return time.Time{}
+}
+
+// Anchor 排程基準點
+type Anchor int
+
+const (
+	// AnchorOpen 以開盤時間為基準
+	AnchorOpen Anchor = iota
+	// AnchorClose 以收盤時間為基準（提前收盤日使用實際收盤時間）
+	AnchorClose
+)
+
+// OffsetSchedule 每個交易日在開盤或收盤前後固定時間觸發（實作 cron.Schedule）
+type OffsetSchedule struct {
+	exchange *Exchange
+	anchor   Anchor
+	offset   time.Duration
+	// 只在每週第一個交易日觸發
+	weekly bool
+}
+
+// NewOffsetSchedule 創建相對開收盤時間的排程，offset 可為負數
+func NewOffsetSchedule(exchange *Exchange, anchor Anchor, offset time.Duration) *OffsetSchedule {
+	return &OffsetSchedule{exchange: exchange, anchor: anchor, offset: offset}
+}
+
+// NewWeeklyOffsetSchedule 創建每週第一個交易日相對開收盤時間的排程（週一休市時順延）
+func NewWeeklyOffsetSchedule(exchange *Exchange, anchor Anchor, offset time.Duration) *OffsetSchedule {
+	return &OffsetSchedule{exchange: exchange, anchor: anchor, offset: offset, weekly: true}
+}
+
+// Next 取得 t 之後的下一次觸發時間
+func (s *OffsetSchedule) Next(t time.Time) time.Time {
+	// offset 可能跨日，從前一天開始找
+	day := s.exchange.at(t, 0).AddDate(0, 0, -1)
+	for i := 0; i < maxScheduleDays; i++ {
+		if open, close, ok := s.exchange.SessionBounds(day); ok && (!s.weekly || s.exchange.isFirstTradingDayOfWeek(day)) {
+			base := open
+			if s.anchor == AnchorClose {
+				base = close
+			}
+
+			if next := base.Add(s.offset); next.After(t) {
+				return next
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}
+}
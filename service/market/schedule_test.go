@@ -0,0 +1,122 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_IntervalSchedule_Next(t *testing.T) {
+	schedule := NewIntervalSchedule(US(), 10*time.Minute)
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "before open fires at open",
+			from: time.Date(2026, 7, 14, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 7, 14, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "aligned to open",
+			from: time.Date(2026, 7, 14, 13, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 7, 14, 13, 40, 0, 0, time.UTC),
+		},
+		{
+			name: "mid interval",
+			from: time.Date(2026, 7, 14, 15, 43, 0, 0, time.UTC),
+			want: time.Date(2026, 7, 14, 15, 50, 0, 0, time.UTC),
+		},
+		{
+			name: "last run before close moves to next open",
+			from: time.Date(2026, 7, 14, 19, 50, 0, 0, time.UTC),
+			want: time.Date(2026, 7, 15, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "skips holiday and weekend across DST change",
+			from: time.Date(2026, 10, 30, 20, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 2, 14, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_OffsetSchedule_Next(t *testing.T) {
+	us := US()
+
+	tests := []struct {
+		name     string
+		schedule *OffsetSchedule
+		from     time.Time
+		want     time.Time
+	}{
+		{
+			name:     "one hour after close",
+			schedule: NewOffsetSchedule(us, AnchorClose, time.Hour),
+			from:     time.Date(2026, 7, 14, 15, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 7, 14, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "after close on early close day",
+			schedule: NewOffsetSchedule(us, AnchorClose, time.Hour),
+			from:     time.Date(2026, 11, 27, 15, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 11, 27, 19, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "at open skips holiday",
+			schedule: NewOffsetSchedule(us, AnchorOpen, 0),
+			from:     time.Date(2026, 11, 25, 15, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 11, 27, 14, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "before open",
+			schedule: NewOffsetSchedule(us, AnchorOpen, -30*time.Minute),
+			from:     time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 7, 14, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly on monday",
+			schedule: NewWeeklyOffsetSchedule(us, AnchorOpen, -90*time.Minute),
+			from:     time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly moves to tuesday after monday holiday",
+			schedule: NewWeeklyOffsetSchedule(us, AnchorOpen, -90*time.Minute),
+			from:     time.Date(2026, 9, 4, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 9, 8, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_Exchange_AdjacentTradingDay(t *testing.T) {
+	us := US()
+
+	// 週五收盤後的下一個交易日為週一；感恩節隔日的上一個交易日為週三
+	if got := us.NextTradingDay(time.Date(2026, 7, 17, 21, 0, 0, 0, time.UTC)); got.Format(dateLayout) != "2026-07-20" {
+		t.Errorf("NextTradingDay() = %v, want 2026-07-20", got)
+	}
+	if got := us.PrevTradingDay(time.Date(2026, 11, 27, 15, 0, 0, 0, time.UTC)); got.Format(dateLayout) != "2026-11-25" {
+		t.Errorf("PrevTradingDay() = %v, want 2026-11-25", got)
+	}
+	// 美東 20:00 後 UTC 已是隔日，仍以美東日期計算
+	if got := us.NextTradingDay(time.Date(2026, 7, 15, 1, 0, 0, 0, time.UTC)); got.Format(dateLayout) != "2026-07-15" {
+		t.Errorf("NextTradingDay() = %v, want 2026-07-15", got)
+	}
+}
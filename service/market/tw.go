@@ -0,0 +1,19 @@
+package market
+
+import "discordBot/pkg/config"
+
+// TW 台股（TWSE）行事曆，開盤 09:00、收盤 13:30（台北時間），無延長交易時段
+// 農曆假期與颱風假等每年不同，由 MARKET_TW_HOLIDAYS 設定
+func TW() *Exchange {
+	return &Exchange{
+		Code:          "TW",
+		Name:          "台股",
+		Location:      loadLocation("Asia/Taipei"),
+		preOpen:       hm(9, 0),
+		open:          hm(9, 0),
+		close:         hm(13, 30),
+		earlyClose:    hm(13, 30),
+		afterClose:    hm(13, 30),
+		extraHolidays: parseExtraHolidays(config.GetMarketConfig().TWHolidays),
+	}
+}
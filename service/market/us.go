@@ -0,0 +1,122 @@
+package market
+
+import (
+	"time"
+
+	"discordBot/pkg/config"
+)
+
+// US 美股（NYSE/Nasdaq）行事曆
+// 盤前 04:00、開盤 09:30、收盤 16:00（提前收盤 13:00）、盤後至 20:00，皆為美東時間
+func US() *Exchange {
+	return &Exchange{
+		Code:          "US",
+		Name:          "美股",
+		Location:      loadLocation("America/New_York"),
+		preOpen:       hm(4, 0),
+		open:          hm(9, 30),
+		close:         hm(16, 0),
+		earlyClose:    hm(13, 0),
+		afterClose:    hm(20, 0),
+		holidays:      usHolidays,
+		earlyCloses:   usEarlyCloses,
+		extraHolidays: parseExtraHolidays(config.GetMarketConfig().USExtraHolidays),
+	}
+}
+
+// usHolidays NYSE 固定休市日
+func usHolidays(year int) map[string]string {
+	holidays := make(map[string]string)
+	add := func(t time.Time, name string) {
+		holidays[t.Format(dateLayout)] = name
+	}
+
+	// 元旦適逢週六時不補假
+	newYear := date(year, time.January, 1)
+	if newYear.Weekday() != time.Saturday {
+		add(observed(newYear), "元旦")
+	}
+
+	add(nthWeekday(year, time.January, time.Monday, 3), "馬丁路德金恩紀念日")
+	add(nthWeekday(year, time.February, time.Monday, 3), "總統日")
+	add(easter(year).AddDate(0, 0, -2), "耶穌受難日")
+	add(lastWeekday(year, time.May, time.Monday), "陣亡將士紀念日")
+	if year >= 2022 {
+		add(observed(date(year, time.June, 19)), "六月節")
+	}
+	add(observed(date(year, time.July, 4)), "獨立紀念日")
+	add(nthWeekday(year, time.September, time.Monday, 1), "勞動節")
+	add(nthWeekday(year, time.November, time.Thursday, 4), "感恩節")
+	add(observed(date(year, time.December, 25)), "聖誕節")
+
+	return holidays
+}
+
+// usEarlyCloses NYSE 提前收盤日：獨立紀念日前一天、感恩節隔天、聖誕夜
+func usEarlyCloses(year int, holidays map[string]string) map[string]struct{} {
+	earlyCloses := make(map[string]struct{})
+	add := func(t time.Time) {
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			return
+		}
+		if _, ok := holidays[t.Format(dateLayout)]; ok {
+			return
+		}
+		earlyCloses[t.Format(dateLayout)] = struct{}{}
+	}
+
+	add(date(year, time.July, 3))
+	add(nthWeekday(year, time.November, time.Thursday, 4).AddDate(0, 0, 1))
+	add(date(year, time.December, 24))
+
+	return earlyCloses
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// observed 假日適逢週六提前至週五、週日延後至週一
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	default:
+		return t
+	}
+}
+
+// nthWeekday 指定月份的第 n 個星期幾
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	t := date(year, month, 1)
+	offset := (int(weekday) - int(t.Weekday()) + 7) % 7
+	return t.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday 指定月份的最後一個星期幾
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	t := date(year, month+1, 1).AddDate(0, 0, -1)
+	offset := (int(t.Weekday()) - int(weekday) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// easter 復活節日期（Anonymous Gregorian algorithm）
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
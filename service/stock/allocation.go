@@ -0,0 +1,440 @@
+package stock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/pkg/chart"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/exchange"
+)
+
+// 資產配置分組方式
+const (
+	allocationSector   = "sector"
+	allocationIndustry = "industry"
+	allocationCountry  = "country"
+	allocationCurrency = "currency"
+)
+
+// allocationDimensions 報告依序列出的分組方式
+var allocationDimensions = []string{allocationSector, allocationIndustry, allocationCountry, allocationCurrency}
+
+// allocationLabels 分組方式顯示名稱
+var allocationLabels = map[string]string{
+	allocationSector:   "類股",
+	allocationIndustry: "產業",
+	allocationCountry:  "國家",
+	allocationCurrency: "幣別",
+}
+
+const (
+	// allocationUnclassified 無公司基本資料（例如 ETF）的分組
+	allocationUnclassified = "未分類"
+	// allocationOther 未列出的產業或合併顯示的小分組
+	allocationOther = "其他"
+)
+
+// industrySectors Finnhub 產業分類對應的 GICS 類股
+var industrySectors = map[string]string{
+	"Technology":                       "Information Technology",
+	"Semiconductors":                   "Information Technology",
+	"Communications":                   "Communication Services",
+	"Telecommunication":                "Communication Services",
+	"Media":                            "Communication Services",
+	"Banking":                          "Financials",
+	"Financial Services":               "Financials",
+	"Insurance":                        "Financials",
+	"Health Care":                      "Health Care",
+	"Biotechnology":                    "Health Care",
+	"Pharmaceuticals":                  "Health Care",
+	"Life Sciences Tools & Services":   "Health Care",
+	"Retail":                           "Consumer Discretionary",
+	"Automobiles":                      "Consumer Discretionary",
+	"Auto Components":                  "Consumer Discretionary",
+	"Hotels, Restaurants & Leisure":    "Consumer Discretionary",
+	"Textiles, Apparel & Luxury Goods": "Consumer Discretionary",
+	"Leisure Products":                 "Consumer Discretionary",
+	"Diversified Consumer Services":    "Consumer Discretionary",
+	"Distributors":                     "Consumer Discretionary",
+	"Consumer products":                "Consumer Staples",
+	"Beverages":                        "Consumer Staples",
+	"Food Products":                    "Consumer Staples",
+	"Tobacco":                          "Consumer Staples",
+	"Energy":                           "Energy",
+	"Utilities":                        "Utilities",
+	"Real Estate":                      "Real Estate",
+	"Chemicals":                        "Materials",
+	"Metals & Mining":                  "Materials",
+	"Packaging":                        "Materials",
+	"Paper & Forest":                   "Materials",
+	"Aerospace & Defense":              "Industrials",
+	"Airlines":                         "Industrials",
+	"Building":                         "Industrials",
+	"Construction":                     "Industrials",
+	"Electrical Equipment":             "Industrials",
+	"Commercial Services & Supplies":   "Industrials",
+	"Industrial Conglomerates":         "Industrials",
+	"Logistics & Transportation":       "Industrials",
+	"Machinery":                        "Industrials",
+	"Marine":                           "Industrials",
+	"Professional Services":            "Industrials",
+	"Road & Rail":                      "Industrials",
+	"Trading Companies & Distributors": "Industrials",
+	"Transportation Infrastructure":    "Industrials",
+	"N/A":                              "",
+}
+
+// allocationHolding 單一持倉的配置資料
+type allocationHolding struct {
+	Symbol   string
+	Currency string
+	// 換算基準幣別後的市值
+	Value   float64
+	Profile *CompanyProfile
+}
+
+// allocationGroup 一個分組的配置
+type allocationGroup struct {
+	Name    string
+	Value   float64
+	Percent float64
+	Symbols []string
+}
+
+// allocationReport 資產配置報告
+type allocationReport struct {
+	BaseCurrency string
+	TotalValue   float64
+	Groups       map[string][]*allocationGroup
+	// 佔比超過門檻的持倉
+	Concentrated []*allocationGroup
+	Threshold    int
+	// 報價失敗而未計入的標的
+	Skipped []string
+}
+
+// Allocation : 依類股、產業、國家與幣別列出呼叫者的資產配置，可附加圓餅圖
+func Allocation(ctx context.Context, m *discordgo.MessageCreate) (*discordgo.MessageSend, error) {
+	// example : $allocation sector chart
+	dimension, withChart, err := parseAllocationArgs(m.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio, err := loadPortfolio(ctx, stockDaoDeps{}, userSettingDaoDeps{}, dividendDaoDeps{}, m.Author.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(portfolio.Views) == 0 {
+		return nil, fmt.Errorf("目前沒有持倉")
+	}
+
+	setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+	if err != nil {
+		return nil, err
+	}
+	base := baseCurrency(setting, config.GetTaskConfig().DefaultBaseCurrency)
+
+	rates, err := getRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("取得匯率錯誤: %w", err)
+	}
+
+	allocationConfig := config.GetAllocationConfig()
+	holdings, skipped, err := allocationHoldings(ctx, redisDeps{}, portfolio.Views, rates, base, time.Duration(allocationConfig.ProfileCacheHours)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildAllocation(holdings, allocationConfig.ConcentrationPercent)
+	report.BaseCurrency = base
+	report.Skipped = skipped
+	if report.TotalValue <= 0 {
+		return nil, fmt.Errorf("持倉市值為 0，無法計算配置")
+	}
+
+	dimensions := allocationDimensions
+	if dimension != "" {
+		dimensions = []string{dimension}
+	}
+	msg := &discordgo.MessageSend{Content: formatAllocation(report, dimensions)}
+
+	if withChart {
+		if dimension == "" {
+			dimension = allocationSector
+		}
+		var buf bytes.Buffer
+		if err := allocationChart(report.Groups[dimension]).Render(&buf); err != nil {
+			return nil, err
+		}
+		msg.Files = []*discordgo.File{
+			{
+				Name:        "allocation.png",
+				ContentType: "image/png",
+				Reader:      &buf,
+			},
+		}
+		msg.Content += fmt.Sprintf("\n圓餅圖: 依%s（編號對應上方列表）", allocationLabels[dimension])
+	}
+
+	return msg, nil
+}
+
+// parseAllocationArgs 解析 $allocation [sector|industry|country|currency] [chart]
+func parseAllocationArgs(content string) (dimension string, withChart bool, err error) {
+	usage := fmt.Errorf("參數錯誤，格式: $allocation [%s] [chart]", strings.Join(allocationDimensions, "|"))
+
+	strSlice := strings.Fields(content)
+	if len(strSlice) > 3 {
+		return "", false, usage
+	}
+
+	for _, arg := range strSlice[1:] {
+		arg = strings.ToLower(arg)
+		if _, ok := allocationLabels[arg]; ok && dimension == "" {
+			dimension = arg
+			continue
+		}
+		if arg == "chart" && !withChart {
+			withChart = true
+			continue
+		}
+		return "", false, usage
+	}
+
+	return dimension, withChart, nil
+}
+
+// allocationHoldings 將持倉市值換算為基準幣別並取得公司基本資料，報價失敗的標的略過
+// 基本資料取得失敗時視為未分類，不影響整份報告
+func allocationHoldings(ctx context.Context, redisClient RedisClient, views []*PositionView, rates exchange.Rates, base string, cacheTTL time.Duration) ([]*allocationHolding, []string, error) {
+	var holdings []*allocationHolding
+	var skipped []string
+	for _, view := range views {
+		if view.Err != nil {
+			skipped = append(skipped, view.Symbol)
+			continue
+		}
+
+		currency := holdingCurrency(view.Currency)
+		value, err := rates.Convert(view.Value, currency, base)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s 換算 %s 錯誤: %w", view.Symbol, base, err)
+		}
+
+		profile, err := getCompanyProfile(ctx, redisClient, view.Symbol, cacheTTL)
+		if err != nil {
+			logger.Warn("取得公司基本資料失敗", "symbol", view.Symbol, "error", err)
+			profile = &CompanyProfile{Symbol: view.Symbol}
+		}
+
+		holdings = append(holdings, &allocationHolding{
+			Symbol:   view.Symbol,
+			Currency: currency,
+			Value:    value,
+			Profile:  profile,
+		})
+	}
+
+	return holdings, skipped, nil
+}
+
+// companyProfileKey 公司基本資料的 Redis 快取 key
+func companyProfileKey(symbol string) string {
+	return "company_profile:" + symbol
+}
+
+// getCompanyProfile 取得公司基本資料，優先使用 Redis 快取（查無資料的標的同樣快取，避免重複查詢）
+func getCompanyProfile(ctx context.Context, redisClient RedisClient, symbol string, cacheTTL time.Duration) (*CompanyProfile, error) {
+	key := companyProfileKey(symbol)
+
+	cached, err := redisClient.Get(ctx, key)
+	if err != nil {
+		logger.Warn("讀取公司基本資料快取失敗", "symbol", symbol, "error", err)
+	}
+	if cached != "" {
+		profile := &CompanyProfile{}
+		if err := json.Unmarshal([]byte(cached), profile); err == nil {
+			return profile, nil
+		}
+	}
+
+	externalTimeout := durationFromSeconds(config.GetTaskConfig().ExternalCallTimeoutSeconds, 15*time.Second)
+	profileCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+	defer cancel()
+
+	profile, err := GetClient("finnhub").GetCompanyProfile(profileCtx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	profile.Symbol = symbol
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := redisClient.Set(ctx, key, string(data), cacheTTL); err != nil {
+		logger.Warn("寫入公司基本資料快取失敗", "symbol", symbol, "error", err)
+	}
+
+	return profile, nil
+}
+
+// allocationKey 持倉在指定分組方式下所屬的分組
+func allocationKey(holding *allocationHolding, dimension string) string {
+	if dimension == allocationCurrency {
+		return holding.Currency
+	}
+
+	profile := holding.Profile
+	if profile == nil || profile.Industry == "" {
+		return allocationUnclassified
+	}
+
+	switch dimension {
+	case allocationSector:
+		sector, ok := industrySectors[profile.Industry]
+		if !ok {
+			return allocationOther
+		}
+		if sector == "" {
+			return allocationUnclassified
+		}
+		return sector
+	case allocationIndustry:
+		return profile.Industry
+	case allocationCountry:
+		if profile.Country == "" {
+			return allocationUnclassified
+		}
+		return profile.Country
+	}
+	return allocationUnclassified
+}
+
+// buildAllocation 計算各分組方式的配置比例與超過集中度門檻的持倉
+func buildAllocation(holdings []*allocationHolding, threshold int) *allocationReport {
+	report := &allocationReport{
+		Groups:    make(map[string][]*allocationGroup, len(allocationDimensions)),
+		Threshold: threshold,
+	}
+	for _, holding := range holdings {
+		report.TotalValue += holding.Value
+	}
+
+	for _, dimension := range allocationDimensions {
+		groups := make(map[string]*allocationGroup)
+		for _, holding := range holdings {
+			key := allocationKey(holding, dimension)
+			group, ok := groups[key]
+			if !ok {
+				group = &allocationGroup{Name: key}
+				groups[key] = group
+			}
+			group.Value += holding.Value
+			group.Symbols = append(group.Symbols, holding.Symbol)
+		}
+		report.Groups[dimension] = sortAllocationGroups(groups, report.TotalValue)
+	}
+
+	for _, holding := range holdings {
+		if report.TotalValue <= 0 {
+			break
+		}
+		percent := holding.Value / report.TotalValue * 100
+		if threshold > 0 && percent > float64(threshold) {
+			report.Concentrated = append(report.Concentrated, &allocationGroup{
+				Name:    holding.Symbol,
+				Value:   holding.Value,
+				Percent: percent,
+			})
+		}
+	}
+	sort.Slice(report.Concentrated, func(i, j int) bool {
+		return report.Concentrated[i].Value > report.Concentrated[j].Value
+	})
+
+	return report
+}
+
+// sortAllocationGroups 依市值由大到小排序並計算佔比，超過圓餅圖顏色數量的小分組合併為「其他」
+func sortAllocationGroups(groups map[string]*allocationGroup, total float64) []*allocationGroup {
+	ret := make([]*allocationGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group.Symbols)
+		ret = append(ret, group)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Value != ret[j].Value {
+			return ret[i].Value > ret[j].Value
+		}
+		return ret[i].Name < ret[j].Name
+	})
+
+	if limit := len(chart.Palette); len(ret) > limit {
+		other := &allocationGroup{Name: allocationOther}
+		for _, group := range ret[limit-1:] {
+			other.Value += group.Value
+			other.Symbols = append(other.Symbols, group.Symbols...)
+		}
+		sort.Strings(other.Symbols)
+		ret = append(ret[:limit-1], other)
+	}
+
+	for _, group := range ret {
+		if total > 0 {
+			group.Percent = group.Value / total * 100
+		}
+	}
+	return ret
+}
+
+// formatAllocation 資產配置顯示格式
+func formatAllocation(report *allocationReport, dimensions []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "資產配置（總市值 %.2f %s）\n", report.TotalValue, report.BaseCurrency)
+
+	for _, dimension := range dimensions {
+		fmt.Fprintf(&b, "依%s:\n```\n", allocationLabels[dimension])
+		for i, group := range report.Groups[dimension] {
+			fmt.Fprintf(&b, "%d. %-24s %6.2f%% %12.2f  %s\n",
+				i+1, group.Name, group.Percent, group.Value, strings.Join(group.Symbols, ", "))
+		}
+		b.WriteString("```\n")
+	}
+
+	if len(report.Concentrated) > 0 {
+		parts := make([]string, 0, len(report.Concentrated))
+		for _, holding := range report.Concentrated {
+			parts = append(parts, fmt.Sprintf("%s %.2f%%", holding.Name, holding.Percent))
+		}
+		fmt.Fprintf(&b, "集中度提醒: 單一持倉超過 %d%%: %s\n", report.Threshold, strings.Join(parts, ", "))
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Fprintf(&b, "報價失敗未計入: %s\n", strings.Join(report.Skipped, ", "))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// allocationChart 以分組配置產生圓餅圖
+func allocationChart(groups []*allocationGroup) *chart.PieChart {
+	pie := &chart.PieChart{}
+	for i, group := range groups {
+		pie.Slices = append(pie.Slices, chart.Slice{
+			Name:  group.Name,
+			Color: chart.Palette[i%len(chart.Palette)],
+			Value: group.Value,
+		})
+	}
+	return pie
+}
@@ -0,0 +1,171 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/pkg/chart"
+	"discordBot/service/exchange"
+)
+
+func Test_parseAllocationArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantDimension string
+		wantChart     bool
+		wantErr       bool
+	}{
+		{name: "all dimensions", content: "$allocation"},
+		{name: "dimension only", content: "$allocation Country", wantDimension: allocationCountry},
+		{name: "chart only", content: "$allocation chart", wantChart: true},
+		{name: "chart before dimension", content: "$allocation chart currency", wantDimension: allocationCurrency, wantChart: true},
+		{name: "unknown dimension", content: "$allocation region", wantErr: true},
+		{name: "duplicate dimension", content: "$allocation sector industry", wantErr: true},
+		{name: "too many args", content: "$allocation sector chart extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dimension, withChart, err := parseAllocationArgs(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseAllocationArgs() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAllocationArgs() unexpected error = %v", err)
+			}
+			if dimension != tt.wantDimension || withChart != tt.wantChart {
+				t.Errorf("parseAllocationArgs() = %q, %v, want %q, %v", dimension, withChart, tt.wantDimension, tt.wantChart)
+			}
+		})
+	}
+}
+
+func Test_buildAllocation(t *testing.T) {
+	holdings := []*allocationHolding{
+		{Symbol: "AAPL", Currency: "USD", Value: 500, Profile: &CompanyProfile{Country: "US", Industry: "Technology"}},
+		{Symbol: "NVDA", Currency: "USD", Value: 200, Profile: &CompanyProfile{Country: "US", Industry: "Semiconductors"}},
+		{Symbol: "2330", Currency: "TWD", Value: 200, Profile: &CompanyProfile{Country: "TW", Industry: "Semiconductors"}},
+		{Symbol: "VOO", Currency: "USD", Value: 100, Profile: &CompanyProfile{}},
+	}
+
+	report := buildAllocation(holdings, 30)
+
+	if !floatEqual(report.TotalValue, 1000) {
+		t.Fatalf("TotalValue = %v, want 1000", report.TotalValue)
+	}
+
+	tests := []struct {
+		dimension string
+		want      []string
+	}{
+		{dimension: allocationSector, want: []string{"Information Technology 90 2330,AAPL,NVDA", "未分類 10 VOO"}},
+		{dimension: allocationIndustry, want: []string{"Technology 50 AAPL", "Semiconductors 40 2330,NVDA", "未分類 10 VOO"}},
+		{dimension: allocationCountry, want: []string{"US 70 AAPL,NVDA", "TW 20 2330", "未分類 10 VOO"}},
+		{dimension: allocationCurrency, want: []string{"USD 80 AAPL,NVDA,VOO", "TWD 20 2330"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dimension, func(t *testing.T) {
+			var got []string
+			for _, group := range report.Groups[tt.dimension] {
+				got = append(got, fmt.Sprintf("%s %.0f %s", group.Name, group.Percent, strings.Join(group.Symbols, ",")))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if len(report.Concentrated) != 1 || report.Concentrated[0].Name != "AAPL" || !floatEqual(report.Concentrated[0].Percent, 50) {
+		t.Errorf("Concentrated = %+v, want AAPL 50%%", report.Concentrated)
+	}
+	if got := buildAllocation(holdings, 0); len(got.Concentrated) != 0 {
+		t.Errorf("threshold 0 Concentrated = %+v, want none", got.Concentrated)
+	}
+}
+
+func Test_sortAllocationGroups_mergeOther(t *testing.T) {
+	groups := make(map[string]*allocationGroup)
+	total := 0.0
+	for i := 0; i < len(chart.Palette)+2; i++ {
+		name := fmt.Sprintf("G%02d", i)
+		value := float64(100 - i)
+		groups[name] = &allocationGroup{Name: name, Value: value, Symbols: []string{name}}
+		total += value
+	}
+
+	got := sortAllocationGroups(groups, total)
+	if len(got) != len(chart.Palette) {
+		t.Fatalf("len = %d, want %d", len(got), len(chart.Palette))
+	}
+	other := got[len(got)-1]
+	if other.Name != allocationOther || len(other.Symbols) != 3 {
+		t.Errorf("last group = %+v, want 其他 with 3 symbols", other)
+	}
+
+	var percent float64
+	for _, group := range got {
+		percent += group.Percent
+	}
+	if !floatEqual(percent, 100) {
+		t.Errorf("total percent = %v, want 100", percent)
+	}
+}
+
+func Test_allocationHoldings(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	mockClient.Profiles = map[string]*CompanyProfile{
+		"AAPL": {Name: "Apple Inc", Country: "US", Industry: "Technology"},
+	}
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	redisClient := NewMockRedisClient()
+	rates := exchange.Rates{"USD": 1, "TWD": 32}
+	views := []*PositionView{
+		{Position: &Position{Symbol: "AAPL", Currency: "USD"}, Value: 100},
+		{Position: &Position{Symbol: "2330", Currency: "TWD"}, Value: 3200},
+		{Position: &Position{Symbol: "BAD"}, Err: errors.New("搜尋失敗")},
+	}
+
+	holdings, skipped, err := allocationHoldings(context.Background(), redisClient, views, rates, "USD", time.Hour)
+	if err != nil {
+		t.Fatalf("allocationHoldings() unexpected error = %v", err)
+	}
+	if len(holdings) != 2 || !floatEqual(holdings[1].Value, 100) || holdings[1].Currency != "TWD" {
+		t.Errorf("holdings = %+v, want AAPL and 2330 converted to 100 USD", holdings)
+	}
+	if len(skipped) != 1 || skipped[0] != "BAD" {
+		t.Errorf("skipped = %v, want [BAD]", skipped)
+	}
+	if holdings[0].Profile.Industry != "Technology" || redisClient.Data[companyProfileKey("AAPL")] == "" {
+		t.Errorf("AAPL profile = %+v, want fetched and cached", holdings[0].Profile)
+	}
+
+	// 快取命中時不呼叫 API；API 錯誤時視為未分類
+	mockClient.Err = errors.New("rate limited")
+	holdings, _, err = allocationHoldings(context.Background(), redisClient, views, rates, "USD", time.Hour)
+	if err != nil {
+		t.Fatalf("allocationHoldings() unexpected error = %v", err)
+	}
+	if holdings[0].Profile.Industry != "Technology" {
+		t.Errorf("cached AAPL profile = %+v, want Technology", holdings[0].Profile)
+	}
+
+	delete(redisClient.Data, companyProfileKey("2330"))
+	holdings, _, _ = allocationHoldings(context.Background(), redisClient, views, rates, "USD", time.Hour)
+	if key := allocationKey(holdings[1], allocationSector); key != allocationUnclassified {
+		t.Errorf("2330 sector = %s, want %s", key, allocationUnclassified)
+	}
+
+	if _, _, err := allocationHoldings(context.Background(), redisClient, views, rates, "EUR", time.Hour); err == nil {
+		t.Errorf("allocationHoldings() unknown base currency error = nil, want error")
+	}
+}
@@ -3,10 +3,13 @@ package stock
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	dividenddao "discordBot/model/dao/dividend"
+	snapshotdao "discordBot/model/dao/snapshot"
 	stockdao "discordBot/model/dao/stock"
 	"discordBot/model/dto"
 	"discordBot/pkg/config"
@@ -20,11 +23,12 @@ import (
 // StockRepository 股票數據倉庫接口類型
 type StockRepository interface {
 	Get(ctx context.Context, input *stockdao.GetInput) ([]*dto.Stock, error)
+	GetUserIDs(ctx context.Context) ([]string, error)
 }
 
 // CalculateProfit : 計算損益
 func CalculateProfit(s *discordgo.Session) {
-	CalculateProfitWithDeps(s, stockDaoDeps{}, redisDeps{})
+	CalculateProfitWithDeps(s, stockDaoDeps{}, userSettingDaoDeps{}, snapshotDaoDeps{}, dividendDaoDeps{})
 }
 
 // stockDaoDeps 封裝 Stock DAO 依賴
@@ -34,8 +38,49 @@ func (d stockDaoDeps) Get(ctx context.Context, input *stockdao.GetInput) ([]*dto
 	return stockdao.Get(ctx, input)
 }
 
+func (d stockDaoDeps) GetUserIDs(ctx context.Context) ([]string, error) {
+	return stockdao.GetUserIDs(ctx)
+}
+
+// calculateProfitInput 收益計算參數
+type calculateProfitInput struct {
+	UserID string
+	Method CostMethod
+	// 使用者券商費率，不為 nil 時另估算賣出成本
+	Schedule *FeeSchedule
+	// 基準幣別與各幣別匯率，總計金額皆換算為基準幣別
+	BaseCurrency string
+	Rates        exchange.Rates
+}
+
+// profitReport 單一使用者的收益計算結果，金額皆為基準幣別
+type profitReport struct {
+	UserID         string
+	Method         CostMethod
+	BaseCurrency   string
+	TotalCost      float64
+	TotalValue     float64
+	TotalProfit    float64
+	RealizedProfit float64
+	// 累計股息收入（扣除預扣稅）
+	DividendIncome float64
+	TodayProfit    float64
+	// 已計入成本與損益的手續費、交易稅與股息預扣稅
+	FeesPaid    float64
+	TaxesPaid   float64
+	DividendTax float64
+	// 依使用者券商費率估算目前持倉全數賣出的手續費與稅（未設定券商時為空）
+	Broker   string
+	ExitCost float64
+	// 各幣別曝險（依換算後市值由大到小）
+	Exposures []*currencyExposure
+	// 當日快照（各標的與總計）
+	Snapshots []*dto.PortfolioSnapshot
+}
+
 // CalculateProfitWithDeps 使用指定依賴計算損益（用於測試）
-func CalculateProfitWithDeps(s *discordgo.Session, repo StockRepository, redisClient RedisClient) {
+// 逐一計算每位有交易紀錄的使用者並寫入每日快照，再發送到其設定的頻道或私訊
+func CalculateProfitWithDeps(s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, snapshotRepo SnapshotRepository, dividendRepo DividendRepository) {
 	taskConfig := config.GetTaskConfig()
 	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
 
@@ -44,46 +89,222 @@ func CalculateProfitWithDeps(s *discordgo.Session, repo StockRepository, redisCl
 	defer cancel()
 
 	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
-	maxConcurrency := normalizeConcurrency(taskConfig.CalculateProfitMaxConcurrency, 5)
 
-	logger.Info("開始計算收益", "maxConcurrency", maxConcurrency, "timeout", runTimeout.String())
+	logger.Info("開始計算收益", "timeout", runTimeout.String())
+
+	usersCtx, usersCancel := context.WithTimeout(ctx, externalTimeout)
+	userIDs, err := repo.GetUserIDs(usersCtx)
+	usersCancel()
+	if err != nil {
+		logger.Error("取得使用者清單失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"calculate_profit:get_users",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.ProfitReportChannelID,
+				Content:   fmt.Sprintf("取使用者清單時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	logger.Info("取得使用者清單", "count", len(userIDs))
+
+	// 所有使用者共用同一份匯率；取得失敗時仍計算不需換算幣別的使用者，需要換算者各自回報錯誤
+	ratesCtx, ratesCancel := context.WithTimeout(ctx, externalTimeout)
+	rates, err := getRates(ratesCtx)
+	ratesCancel()
+	if err != nil {
+		logger.Error("取得匯率失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"calculate_profit:rates",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.ProfitReportChannelID,
+				Content:   fmt.Sprintf("取匯率時錯誤，需換算幣別的使用者將無法計算: %v", err),
+			},
+		)
+		rates = exchange.Rates{}
+	}
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			logger.Warn("收益計算任務逾時，停止處理剩餘使用者", "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"calculate_profit:timeout",
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.ProfitReportChannelID,
+					Content:   fmt.Sprintf("收益計算任務逾時: %v", err),
+				},
+			)
+			return
+		}
+
+		if err := reportUserProfit(ctx, s, repo, settingRepo, snapshotRepo, dividendRepo, taskConfig, rates, userID); err != nil {
+			logger.Error("計算使用者收益失敗", "userID", userID, "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"calculate_profit:"+userID,
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.ProfitReportChannelID,
+					Content:   fmt.Sprintf("計算 <@%s> 收益時錯誤: %v", userID, err),
+				},
+			)
+		}
+	}
+
+	logger.Info("完成收益計算")
+}
+
+// reportUserProfit 計算單一使用者收益、寫入快照並發送報告
+func reportUserProfit(ctx context.Context, s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, snapshotRepo SnapshotRepository, dividendRepo DividendRepository, taskConfig *config.TaskConfig, rates exchange.Rates, userID string) error {
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+
+	settingCtx, settingCancel := context.WithTimeout(ctx, externalTimeout)
+	setting, err := settingRepo.GetSetting(settingCtx, userID)
+	settingCancel()
+	if err != nil {
+		return fmt.Errorf("取使用者設定錯誤: %w", err)
+	}
+
+	method := CostMethodAverage
+	if setting != nil {
+		if method, err = ParseCostMethod(setting.CostMethod); err != nil {
+			logger.Error("成本計算方式設定錯誤，改用平均成本", "userID", userID, "error", err)
+			method = CostMethodAverage
+		}
+	}
+
+	report, err := calculateUserProfit(ctx, s, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{
+		UserID:       userID,
+		Method:       method,
+		Schedule:     loadFeeSchedules().ForUser(setting),
+		BaseCurrency: baseCurrency(setting, taskConfig.DefaultBaseCurrency),
+		Rates:        rates,
+	})
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		logger.Info("持倉為空，略過收益計算", "userID", userID)
+		return nil
+	}
+
+	// 先寫入快照，即使使用者關閉報告仍保留歷史
+	saveCtx, saveCancel := context.WithTimeout(ctx, externalTimeout)
+	err = snapshotRepo.SaveSnapshots(saveCtx, report.Snapshots)
+	saveCancel()
+	if err != nil {
+		return fmt.Errorf("寫入每日快照錯誤: %w", err)
+	}
+
+	if setting != nil && !setting.ReportEnabled {
+		logger.Info("使用者已關閉收益報告", "userID", userID)
+		return nil
+	}
+
+	if err := sendToReportTarget(s, setting, taskConfig.ProfitReportChannelID, userID, &discordgo.MessageSend{
+		Content: formatProfitReport(report),
+	}); err != nil {
+		return err
+	}
+
+	logger.Info("收益報告發送成功", "userID", userID, "baseCurrency", report.BaseCurrency, "totalCost", report.TotalCost, "totalValue", report.TotalValue, "totalProfit", report.TotalProfit, "realizedProfit", report.RealizedProfit, "todayProfit", report.TodayProfit)
+
+	return nil
+}
+
+// calculateUserProfit 依交易紀錄與現價計算使用者收益與當日快照，無交易紀錄時回傳 nil
+// 成本與已實現損益已扣除手續費與交易稅；各標的快照為持倉幣別，總計以目前匯率換算為基準幣別
+func calculateUserProfit(ctx context.Context, s *discordgo.Session, repo StockRepository, snapshotRepo SnapshotRepository, dividendRepo DividendRepository, taskConfig *config.TaskConfig, input *calculateProfitInput) (*profitReport, error) {
+	userID := input.UserID
+	schedule := input.Schedule
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+	maxConcurrency := normalizeConcurrency(taskConfig.CalculateProfitMaxConcurrency, 5)
 
 	// 先取資料
 	getStockCtx, getStockCancel := context.WithTimeout(ctx, externalTimeout)
 	dbRes, err := repo.Get(
 		getStockCtx,
 		&stockdao.GetInput{
-			UserID: taskConfig.DefaultUserID,
+			UserID: userID,
 		},
 	)
 	getStockCancel()
 	if err != nil {
-		logger.Error("取得股票資料失敗", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:get_stock",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("取資料時錯誤: %v", err),
-			},
-		)
-		return
+		return nil, fmt.Errorf("取資料時錯誤: %w", err)
 	}
 
-	logger.Info("取得股票資料", "count", len(dbRes))
-	if len(dbRes) == 0 {
-		logger.Info("持倉為空，略過收益計算")
-		return
+	logger.Info("取得股票資料", "userID", userID, "count", len(dbRes))
+
+	// 依使用者設定的成本計算方式配對買賣批次
+	matched, err := MatchLots(dbRes, input.Method)
+	if err != nil {
+		return nil, fmt.Errorf("推導持倉時錯誤: %w", err)
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
 	}
 
-	var totalProfit, totalCost, totalValue float64
+	date := snapshotDate(nowFunc())
+
+	// 截至今日已發放的股息
+	dividendCtx, dividendCancel := context.WithTimeout(ctx, externalTimeout)
+	dividends, err := dividendRepo.GetDividends(dividendCtx, &dividenddao.GetInput{
+		UserID: userID,
+		To:     date,
+	})
+	dividendCancel()
+	if err != nil {
+		return nil, fmt.Errorf("取股息紀錄錯誤: %w", err)
+	}
+
+	// 先確認所有幣別都有匯率，之後換算不需再處理錯誤
+	for _, trade := range dbRes {
+		if _, err := input.Rates.Convert(1, holdingCurrency(trade.Currency), input.BaseCurrency); err != nil {
+			return nil, fmt.Errorf("換算 %s 至 %s 錯誤: %w", holdingCurrency(trade.Currency), input.BaseCurrency, err)
+		}
+	}
+	for _, dividend := range dividends {
+		if _, err := input.Rates.Convert(1, holdingCurrency(dividend.Currency), input.BaseCurrency); err != nil {
+			return nil, fmt.Errorf("換算 %s 至 %s 錯誤: %w", holdingCurrency(dividend.Currency), input.BaseCurrency, err)
+		}
+	}
+	toBase := func(amount float64, currency string) float64 {
+		converted, _ := input.Rates.Convert(amount, holdingCurrency(currency), input.BaseCurrency)
+		return converted
+	}
+
+	report := &profitReport{UserID: userID, Method: input.Method, BaseCurrency: input.BaseCurrency}
+	for _, position := range matched {
+		report.RealizedProfit += toBase(position.RealizedProfit, position.Currency)
+	}
+	for _, trade := range dbRes {
+		report.FeesPaid += toBase(trade.Fee, trade.Currency)
+		report.TaxesPaid += toBase(trade.Tax, trade.Currency)
+	}
+	// 各標的快照沿用原幣別的股息
+	_, dividendBySymbol := dividendIncome(dividends)
+	for _, dividend := range dividends {
+		report.DividendIncome += toBase(netDividend(dividend), dividend.Currency)
+		report.DividendTax += toBase(dividend.Tax, dividend.Currency)
+	}
+	if schedule != nil {
+		report.Broker = schedule.Broker
+	}
+	positions := OpenPositions(matched)
+	exposures := make(map[string]*currencyExposure)
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	// 無法計算損益的標的，任一失敗時不產生報告，避免缺漏持倉的總計寫入快照
+	var failed []string
 	sem := make(chan struct{}, maxConcurrency)
 
 loop:
-	for _, v := range dbRes {
+	for _, v := range positions {
 		if err := ctx.Err(); err != nil {
 			logger.Warn("收益計算任務超時，停止派發剩餘標的", "error", err)
 			break
@@ -97,7 +318,7 @@ loop:
 		}
 
 		wg.Add(1)
-		go func(holding *dto.Stock) {
+		go func(holding *Position) {
 			defer wg.Done()
 			defer func() {
 				<-sem
@@ -109,142 +330,150 @@ loop:
 				&CalculateInput{
 					Symbol: holding.Symbol,
 					Units:  holding.Units,
-					Price:  holding.Price,
+					Price:  holding.AveragePrice(),
 				})
 			calcCancel()
 			if err != nil {
 				logger.Error("計算損益失敗", "symbol", holding.Symbol, "error", err)
-				taskErrorReporter.Notify(
-					s,
-					"calculate_profit:calculate",
-					&discord.SendMessageInput{
-						ChannelID: taskConfig.ProfitReportChannelID,
-						Content:   fmt.Sprintf("計算損益時錯誤: %v", err),
-					},
-				)
+				mu.Lock()
+				failed = append(failed, holding.Symbol)
+				mu.Unlock()
 				return
 			}
 
+			currency := holdingCurrency(holding.Currency)
+
 			mu.Lock()
-			cost := holding.Units * holding.Price
+			report.TotalProfit += toBase(profit, currency)
+			report.TotalCost += toBase(holding.Cost, currency)
+			report.TotalValue += toBase(value, currency)
+			if schedule.AppliesTo(currency) {
+				report.ExitCost += toBase(schedule.TradeFee(value)+schedule.TradeTax(dto.StockSideSell, value), currency)
+			}
 
-			totalProfit += profit
-			totalCost += cost
-			totalValue += value
+			exposure := exposures[currency]
+			if exposure == nil {
+				exposure = &currencyExposure{Currency: currency}
+				exposures[currency] = exposure
+			}
+			exposure.Cost += holding.Cost
+			exposure.Value += value
+			exposure.Profit += profit
+			exposure.BaseValue += toBase(value, currency)
+
+			report.Snapshots = append(report.Snapshots, &dto.PortfolioSnapshot{
+				UserID:         userID,
+				Date:           date,
+				Symbol:         holding.Symbol,
+				Units:          holding.Units,
+				Cost:           holding.Cost,
+				Value:          value,
+				Profit:         profit,
+				RealizedProfit: holding.RealizedProfit,
+				DividendIncome: dividendBySymbol[holding.Symbol],
+				Currency:       currency,
+			})
 			mu.Unlock()
 		}(v)
 	}
 
 	wg.Wait()
 	if err := ctx.Err(); err != nil && err != context.Canceled {
-		logger.Warn("收益計算任務逾時", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:timeout",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("收益計算任務逾時: %v", err),
-			},
-		)
-		return
+		return nil, fmt.Errorf("收益計算任務逾時: %w", err)
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return nil, fmt.Errorf("無法計算 %s 損益，今日不寫入快照", strings.Join(failed, ", "))
 	}
 
-	// 取昨日市場總值
-	redisKey := taskConfig.ProfitReportChannelID + "_" + "totalValue"
-	redisGetCtx, redisGetCancel := context.WithTimeout(ctx, externalTimeout)
-	yesterdayTotalValue, err := redisClient.Get(redisGetCtx, redisKey)
-	redisGetCancel()
+	// 以前一筆總計快照計算今日損益（未實現、已實現損益與股息的變化，不受買賣金流影響）
+	prevCtx, prevCancel := context.WithTimeout(ctx, externalTimeout)
+	prev, err := snapshotRepo.GetSnapshots(prevCtx, &snapshotdao.GetInput{
+		UserID: userID,
+		Symbol: dto.PortfolioSnapshotTotal,
+		To:     date.AddDate(0, 0, -1),
+		Desc:   true,
+		Limit:  1,
+	})
+	prevCancel()
 	if err != nil {
-		logger.Error("取得昨日市場總值失敗", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:redis_get",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("取昨日市場總值錯誤: %v", err),
-			},
-		)
-		return
+		return nil, fmt.Errorf("取前一日快照錯誤: %w", err)
 	}
 
-	var yesterdayTotalValueFloat float64
-	if yesterdayTotalValue == "" {
-		yesterdayTotalValueFloat = totalValue
-		logger.Info("昨日市場總值不存在，使用今日總值作為基準", "redisKey", redisKey)
+	report.Exposures = sortExposures(exposures)
+
+	if len(prev) == 0 {
+		logger.Info("前一日快照不存在，今日損益以 0 計算", "userID", userID)
+	} else if prev[0].Currency != input.BaseCurrency {
+		logger.Info("前一日快照幣別不同，今日損益以 0 計算", "userID", userID, "prev", prev[0].Currency, "base", input.BaseCurrency)
 	} else {
-		yesterdayTotalValueFloat, err = strconv.ParseFloat(yesterdayTotalValue, 64)
-		if err != nil {
-			logger.Error("轉換昨日市場總值失敗", "value", yesterdayTotalValue, "error", err)
-			taskErrorReporter.Notify(
-				s,
-				"calculate_profit:redis_parse",
-				&discord.SendMessageInput{
-					ChannelID: taskConfig.ProfitReportChannelID,
-					Content:   fmt.Sprintf("string to float64 錯誤: %v", err),
-				},
-			)
-			return
-		}
+		report.TodayProfit = (report.TotalProfit + report.RealizedProfit + report.DividendIncome) - (prev[0].Profit + prev[0].RealizedProfit + prev[0].DividendIncome)
 	}
 
-	todayProfit := totalValue - yesterdayTotalValueFloat
+	sort.Slice(report.Snapshots, func(i, j int) bool {
+		return report.Snapshots[i].Symbol < report.Snapshots[j].Symbol
+	})
+	report.Snapshots = append(report.Snapshots, &dto.PortfolioSnapshot{
+		UserID:         userID,
+		Date:           date,
+		Symbol:         dto.PortfolioSnapshotTotal,
+		Cost:           report.TotalCost,
+		Value:          report.TotalValue,
+		Profit:         report.TotalProfit,
+		RealizedProfit: report.RealizedProfit,
+		DividendIncome: report.DividendIncome,
+		Currency:       input.BaseCurrency,
+	})
 
-	oldMoney := []float64{totalCost, totalValue, totalProfit, todayProfit}
-	// 換算幣值
-	convertCtx, convertCancel := context.WithTimeout(ctx, externalTimeout)
-	newMoney, err := exchange.ConvertExchangeWithContext(convertCtx, oldMoney)
-	convertCancel()
-	if err != nil {
-		logger.Error("換算匯率失敗", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:convert_exchange",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("換算匯率錯誤: %v", err),
-			},
-		)
-		return
-	}
+	return report, nil
+}
 
-	_, err = s.ChannelMessageSendComplex(taskConfig.ProfitReportChannelID, &discordgo.MessageSend{
-		Content: fmt.Sprintf("<@%s> 總成本: %.2f, 目前市場總值: %.2f, 目前損益: %.2f, 今日損益: %.2f  \n 換算台幣總成本: %.2f, 換算台幣目前市場總值: %.2f, 換算台幣目前損益: %.2f, 換算台幣今日損益: %.2f",
-			taskConfig.DefaultUserID, totalCost, totalValue, totalProfit, todayProfit, newMoney[0], newMoney[1], newMoney[2], newMoney[3]),
-		AllowedMentions: &discordgo.MessageAllowedMentions{
-			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
-		},
-	})
-	if err != nil {
-		logger.Error("發送收益報告失敗", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:send_report",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("發送訊息錯誤: %v", err),
-			},
-		)
-		return
+// resolveReportTarget 依使用者設定決定報告發送頻道，dm 為 true 時需改發私訊
+func resolveReportTarget(setting *dto.UserSetting, defaultChannelID string) (channelID string, dm bool) {
+	if setting == nil || setting.ReportChannelID == "" {
+		return defaultChannelID, false
+	}
+	if setting.ReportChannelID == dto.ReportChannelDM {
+		return "", true
 	}
+	return setting.ReportChannelID, false
+}
 
-	logger.Info("收益報告發送成功", "totalCost", totalCost, "totalValue", totalValue, "totalProfit", totalProfit, "todayProfit", todayProfit)
+// sendToReportTarget 依使用者的報告設定發送訊息到頻道或私訊，只允許提及使用者
+func sendToReportTarget(s *discordgo.Session, setting *dto.UserSetting, defaultChannelID string, userID string, msg *discordgo.MessageSend) error {
+	channelID, dm := resolveReportTarget(setting, defaultChannelID)
+	if dm {
+		channel, err := s.UserChannelCreate(userID)
+		if err != nil {
+			return fmt.Errorf("建立私訊頻道錯誤: %w", err)
+		}
+		channelID = channel.ID
+	}
 
-	// 將今日市場總值存入 Redis
-	redisSetCtx, redisSetCancel := context.WithTimeout(ctx, externalTimeout)
-	err = redisClient.Set(redisSetCtx, redisKey, totalValue, 0)
-	redisSetCancel()
-	if err != nil {
-		logger.Error("儲存今日市場總值失敗", "error", err)
-		taskErrorReporter.Notify(
-			s,
-			"calculate_profit:redis_set",
-			&discord.SendMessageInput{
-				ChannelID: taskConfig.ProfitReportChannelID,
-				Content:   fmt.Sprintf("存今日總值錯誤: %v", err),
-			},
-		)
-		return
+	msg.AllowedMentions = &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+	}
+	if _, err := s.ChannelMessageSendComplex(channelID, msg); err != nil {
+		return fmt.Errorf("發送訊息錯誤: %w", err)
 	}
 
-	logger.Info("完成收益計算")
+	return nil
+}
+
+// formatProfitReport 組合收益報告訊息
+func formatProfitReport(report *profitReport) string {
+	totalReturn := report.TotalProfit + report.RealizedProfit + report.DividendIncome
+	return fmt.Sprintf("<@%s> 收益報告（基準幣別: %s）  \n 總成本: %.2f, 目前市場總值: %.2f, 未實現損益: %.2f, 已實現損益: %.2f, 股息收入: %.2f, 總報酬: %.2f, 今日損益: %.2f  \n 幣別曝險: %s  \n 成本計算方式: %s",
+		report.UserID, report.BaseCurrency, report.TotalCost, report.TotalValue, report.TotalProfit, report.RealizedProfit, report.DividendIncome, totalReturn, report.TodayProfit,
+		formatExposures(report.Exposures, report.TotalValue), report.Method.Label()) +
+		formatProfitCosts(report)
+}
+
+// formatProfitCosts 收益報告的交易成本說明（已計入的費用與預估賣出成本）
+func formatProfitCosts(report *profitReport) string {
+	text := fmt.Sprintf("  \n 已計入手續費: %.2f, 交易稅: %.2f, 股息預扣稅: %.2f", report.FeesPaid, report.TaxesPaid, report.DividendTax)
+	if report.Broker != "" {
+		text += fmt.Sprintf(", 預估賣出成本（%s）: %.2f, 扣除後未實現損益: %.2f", report.Broker, report.ExitCost, report.TotalProfit-report.ExitCost)
+	}
+	return text
 }
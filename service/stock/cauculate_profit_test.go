@@ -1,9 +1,14 @@
 package stock
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/service/exchange"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -45,3 +50,223 @@ func TestCalculateProfit(t *testing.T) {
 	t.Log("CalculateProfit logic can be tested with mocked dependencies")
 	t.Logf("Stocks: %v", mockRepo.Stocks)
 }
+
+func Test_calculateUserProfit(t *testing.T) {
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 300})
+	mockFinnhub.AddQuote("AAPL", &QuoteResponse{CurrentPrice: 150})
+	mockFinnhub.AddQuote("2330.TW", &QuoteResponse{CurrentPrice: 900})
+	SetDefaultClient(mockFinnhub)
+	defer ResetDefaultClient()
+
+	u1Buy := tradeAt(1, "TSLA", dto.StockSideBuy, 2, 100, 2)
+	u1Buy.UserID = "u1"
+	u1Sell := tradeAt(2, "TSLA", dto.StockSideSell, 1, 250, 1)
+	u1Sell.UserID = "u1"
+	u1Sell.Tax = 0.75
+	u2Buy := tradeAt(3, "AAPL", dto.StockSideBuy, 4, 100, 0)
+	u2Buy.UserID = "u2"
+	u4TW := tradeAt(4, "2330.TW", dto.StockSideBuy, 10, 600, 0)
+	u4TW.UserID = "u4"
+	u4TW.Currency = "TWD"
+	u4US := tradeAt(5, "AAPL", dto.StockSideBuy, 1, 100, 0)
+	u4US.UserID = "u4"
+	u5Buy := tradeAt(6, "7203.T", dto.StockSideBuy, 100, 3000, 0)
+	u5Buy.UserID = "u5"
+	u5Buy.Currency = "JPY"
+
+	repo := &MockStockRepository{Stocks: []*dto.Stock{u1Buy, u1Sell, u2Buy, u4TW, u4US, u5Buy}}
+	rates := exchange.Rates{"USD": 1, "TWD": 30}
+	taskConfig := &config.TaskConfig{DefaultUserID: "u1", ProfitReportChannelID: "c1"}
+
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC) }
+	snapshotRepo := &MockSnapshotRepository{
+		Snapshots: []*dto.PortfolioSnapshot{
+			{UserID: "u2", Date: day(9), Symbol: dto.PortfolioSnapshotTotal, Profit: 10, Currency: "USD"},
+			{UserID: "u2", Date: day(10), Symbol: dto.PortfolioSnapshotTotal, Profit: 150, Currency: "USD"},
+			{UserID: "u1", Date: day(14), Symbol: "TSLA", Profit: 999, Currency: "USD"},
+			{UserID: "u1", Date: day(14), Symbol: dto.PortfolioSnapshotTotal, Profit: 100, RealizedProfit: 150, DividendIncome: 5, Currency: "USD"},
+			// 同日重跑的快照不應作為前一日基準
+			{UserID: "u2", Date: day(15), Symbol: dto.PortfolioSnapshotTotal, Profit: 190, Currency: "USD"},
+			// 基準幣別變更前的快照不作為今日損益基準
+			{UserID: "u4", Date: day(14), Symbol: dto.PortfolioSnapshotTotal, Profit: 100, Currency: "USD"},
+		},
+	}
+
+	dividendRepo := &MockDividendRepository{
+		Dividends: []*dto.Dividend{
+			{UserID: "u1", Symbol: "TSLA", Amount: 5, PayDate: day(14)},
+			{UserID: "u1", Symbol: "TSLA", Amount: 10, Tax: 3, PayDate: day(15)},
+			// 尚未發放的股息不列入
+			{UserID: "u1", Symbol: "TSLA", Amount: 20, PayDate: day(16)},
+		},
+	}
+
+	sinopac := &FeeSchedule{Broker: "sinopac", Rate: 0.001, MinFee: 1, SellTaxRate: 0.0025}
+	fubon := &FeeSchedule{Broker: "fubon", Rate: 0.001, MinFee: 20, SellTaxRate: 0.0025, Currency: "TWD"}
+
+	tests := []struct {
+		userID   string
+		base     string
+		schedule *FeeSchedule
+		want     profitReport
+		// 各標的快照幣別
+		wantCurrencies []string
+	}{
+		{
+			// 成本與已實現損益含手續費與交易稅，股息扣除預扣稅
+			userID:   "u1",
+			base:     "USD",
+			schedule: sinopac,
+			want: profitReport{UserID: "u1", Method: CostMethodFIFO, BaseCurrency: "USD", TotalCost: 101, TotalValue: 300, TotalProfit: 199, RealizedProfit: 147.25, DividendIncome: 12, TodayProfit: 103.25,
+				FeesPaid: 3, TaxesPaid: 0.75, DividendTax: 3, Broker: "sinopac", ExitCost: 1.75,
+				Exposures: []*currencyExposure{{Currency: "USD", Cost: 101, Value: 300, Profit: 199, BaseValue: 300}}},
+			wantCurrencies: []string{"USD"},
+		},
+		{
+			userID: "u2",
+			base:   "USD",
+			want: profitReport{UserID: "u2", Method: CostMethodFIFO, BaseCurrency: "USD", TotalCost: 400, TotalValue: 600, TotalProfit: 200, RealizedProfit: 0, TodayProfit: 50,
+				Exposures: []*currencyExposure{{Currency: "USD", Cost: 400, Value: 600, Profit: 200, BaseValue: 600}}},
+			wantCurrencies: []string{"USD"},
+		},
+		{
+			// 各持倉依自身幣別換算為台幣
+			userID: "u4",
+			base:   "TWD",
+			want: profitReport{UserID: "u4", Method: CostMethodFIFO, BaseCurrency: "TWD", TotalCost: 9000, TotalValue: 13500, TotalProfit: 4500,
+				Exposures: []*currencyExposure{
+					{Currency: "TWD", Cost: 6000, Value: 9000, Profit: 3000, BaseValue: 9000},
+					{Currency: "USD", Cost: 100, Value: 150, Profit: 50, BaseValue: 4500},
+				}},
+			wantCurrencies: []string{"TWD", "USD"},
+		},
+		{
+			// 台幣券商費率只估算台幣持倉的賣出成本
+			userID:   "u4",
+			base:     "TWD",
+			schedule: fubon,
+			want: profitReport{UserID: "u4", Method: CostMethodFIFO, BaseCurrency: "TWD", TotalCost: 9000, TotalValue: 13500, TotalProfit: 4500,
+				Broker: "fubon", ExitCost: 42.5,
+				Exposures: []*currencyExposure{
+					{Currency: "TWD", Cost: 6000, Value: 9000, Profit: 3000, BaseValue: 9000},
+					{Currency: "USD", Cost: 100, Value: 150, Profit: 50, BaseValue: 4500},
+				}},
+			wantCurrencies: []string{"TWD", "USD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			got, err := calculateUserProfit(context.Background(), nil, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{
+				UserID:       tt.userID,
+				Method:       CostMethodFIFO,
+				Schedule:     tt.schedule,
+				BaseCurrency: tt.base,
+				Rates:        rates,
+			})
+			if err != nil {
+				t.Fatalf("calculateUserProfit() unexpected error = %v", err)
+			}
+			if got == nil {
+				t.Fatalf("calculateUserProfit() = nil, want report")
+			}
+
+			snapshots := got.Snapshots
+			got.Snapshots = nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("calculateUserProfit() = %+v, want %+v", *got, tt.want)
+			}
+
+			if len(snapshots) != len(tt.wantCurrencies)+1 {
+				t.Fatalf("calculateUserProfit() = %d snapshots, want %d", len(snapshots), len(tt.wantCurrencies)+1)
+			}
+			total := snapshots[len(snapshots)-1]
+			if total.Symbol != dto.PortfolioSnapshotTotal || !total.Date.Equal(day(15)) || total.Currency != tt.base ||
+				total.Value != tt.want.TotalValue || total.Profit != tt.want.TotalProfit || total.RealizedProfit != tt.want.RealizedProfit || total.DividendIncome != tt.want.DividendIncome {
+				t.Errorf("calculateUserProfit() total snapshot = %+v", *total)
+			}
+			// 各標的快照依 symbol 排序（2330.TW 在 AAPL 之前）
+			for i, currency := range tt.wantCurrencies {
+				if snapshots[i].Symbol == dto.PortfolioSnapshotTotal || snapshots[i].UserID != tt.userID || snapshots[i].Currency != currency {
+					t.Errorf("calculateUserProfit() symbol snapshot = %+v, want currency %s", *snapshots[i], currency)
+				}
+			}
+			if tt.userID == "u1" && snapshots[0].DividendIncome != tt.want.DividendIncome {
+				t.Errorf("calculateUserProfit() symbol snapshot dividend = %v, want %v", snapshots[0].DividendIncome, tt.want.DividendIncome)
+			}
+		})
+	}
+
+	got, err := calculateUserProfit(context.Background(), nil, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{UserID: "u3", Method: CostMethodFIFO, BaseCurrency: "USD", Rates: rates})
+	if err != nil || got != nil {
+		t.Errorf("calculateUserProfit(u3) = %+v, %v, want nil report", got, err)
+	}
+
+	// 匯率取得失敗時，不需換算幣別的使用者仍可計算
+	got, err = calculateUserProfit(context.Background(), nil, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{UserID: "u2", Method: CostMethodFIFO, BaseCurrency: "USD", Rates: exchange.Rates{}})
+	if err != nil || got == nil || got.TotalValue != 600 {
+		t.Errorf("calculateUserProfit(u2) without rates = %+v, %v, want report", got, err)
+	}
+	got, err = calculateUserProfit(context.Background(), nil, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{UserID: "u4", Method: CostMethodFIFO, BaseCurrency: "TWD", Rates: exchange.Rates{}})
+	if err == nil || got != nil {
+		t.Errorf("calculateUserProfit(u4) without rates = %+v, %v, want error", got, err)
+	}
+
+	// 缺少匯率時回傳錯誤，不產生錯誤的總計
+	got, err = calculateUserProfit(context.Background(), nil, repo, snapshotRepo, dividendRepo, taskConfig, &calculateProfitInput{UserID: "u5", Method: CostMethodFIFO, BaseCurrency: "USD", Rates: rates})
+	if err == nil || got != nil {
+		t.Errorf("calculateUserProfit(u5) = %+v, %v, want error", got, err)
+	}
+}
+
+func Test_resolveReportTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		setting     *dto.UserSetting
+		wantChannel string
+		wantDM      bool
+	}{
+		{name: "no setting uses default channel", wantChannel: "default"},
+		{name: "empty channel uses default channel", setting: &dto.UserSetting{ReportEnabled: true}, wantChannel: "default"},
+		{name: "dm", setting: &dto.UserSetting{ReportEnabled: true, ReportChannelID: dto.ReportChannelDM}, wantDM: true},
+		{name: "custom channel", setting: &dto.UserSetting{ReportEnabled: true, ReportChannelID: "c2"}, wantChannel: "c2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channelID, dm := resolveReportTarget(tt.setting, "default")
+			if channelID != tt.wantChannel || dm != tt.wantDM {
+				t.Errorf("resolveReportTarget() = %q, %v, want %q, %v", channelID, dm, tt.wantChannel, tt.wantDM)
+			}
+		})
+	}
+}
+
+func Test_reportUserProfit_QuoteFailure(t *testing.T) {
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 300})
+	SetDefaultClient(mockFinnhub)
+	defer ResetDefaultClient()
+
+	// NVDA 沒有報價，總計缺少該持倉
+	repo := &MockStockRepository{Stocks: []*dto.Stock{
+		userTrade("u1", tradeAt(1, "TSLA", dto.StockSideBuy, 2, 100, 0)),
+		userTrade("u1", tradeAt(2, "NVDA", dto.StockSideBuy, 1, 100, 0)),
+	}}
+	settingRepo := &MockUserSettingRepository{Settings: map[string]*dto.UserSetting{"u1": {UserID: "u1", BaseCurrency: "USD", ReportEnabled: true}}}
+	snapshotRepo := &MockSnapshotRepository{}
+	taskConfig := &config.TaskConfig{ProfitReportChannelID: "c1"}
+
+	err := reportUserProfit(context.Background(), nil, repo, settingRepo, snapshotRepo, &MockDividendRepository{}, taskConfig, exchange.Rates{"USD": 1}, "u1")
+	if err == nil {
+		t.Fatalf("reportUserProfit() error = nil, want quote error")
+	}
+	if len(snapshotRepo.Snapshots) != 0 {
+		t.Errorf("reportUserProfit() saved %d snapshots, want none", len(snapshotRepo.Snapshots))
+	}
+}
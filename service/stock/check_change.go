@@ -2,6 +2,7 @@ package stock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -19,6 +20,10 @@ type RedisClient interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+	Del(ctx context.Context, key string) error
+	SAdd(ctx context.Context, key string, members ...string) (int64, error)
+	SRem(ctx context.Context, key string, members ...string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
 }
 
 // CheckChange : 檢查漲跌幅
@@ -41,6 +46,22 @@ func (d redisDeps) LRange(ctx context.Context, key string, start, stop int64) ([
 	return redis.LRange(ctx, key, start, stop)
 }
 
+func (d redisDeps) Del(ctx context.Context, key string) error {
+	return redis.Del(ctx, key)
+}
+
+func (d redisDeps) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	return redis.SAdd(ctx, key, members...)
+}
+
+func (d redisDeps) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return redis.SRem(ctx, key, members...)
+}
+
+func (d redisDeps) SMembers(ctx context.Context, key string) ([]string, error) {
+	return redis.SMembers(ctx, key)
+}
+
 // CheckChangeWithDeps 使用指定依賴檢查漲跌幅（用於測試）
 func CheckChangeWithDeps(s *discordgo.Session, redisClient RedisClient) {
 	taskConfig := config.GetTaskConfig()
@@ -55,20 +76,15 @@ func CheckChangeWithDeps(s *discordgo.Session, redisClient RedisClient) {
 
 	logger.Info("開始檢查股票漲跌幅", "maxConcurrency", maxConcurrency, "timeout", runTimeout.String())
 
-	// Redis 取出資料
+	// Redis 取出所有觀察清單
 	fetchCtx, fetchCancel := context.WithTimeout(ctx, externalTimeout)
-	watchList, err := redisClient.LRange(
-		fetchCtx,
-		"watch_list",
-		0,
-		-1,
-	)
+	lists, err := loadWatchLists(fetchCtx, redisClient, taskConfig)
 	fetchCancel()
 	if err != nil {
 		logger.Error("取得觀察列表失敗", "error", err)
 		taskErrorReporter.Notify(
 			s,
-			"check_change:watch_list:lrange",
+			"check_change:watch_list:load",
 			&discord.SendMessageInput{
 				ChannelID: taskConfig.WatchListChannelID,
 				Content:   fmt.Sprintf("取得列表時錯誤: %v", err),
@@ -77,8 +93,9 @@ func CheckChangeWithDeps(s *discordgo.Session, redisClient RedisClient) {
 		return
 	}
 
-	logger.Info("取得觀察列表", "count", len(watchList))
-	if len(watchList) == 0 {
+	symbols, bySymbol := watchListsBySymbol(lists)
+	logger.Info("取得觀察列表", "lists", len(lists), "symbols", len(symbols))
+	if len(symbols) == 0 {
 		logger.Info("觀察列表為空，略過漲跌幅檢查")
 		return
 	}
@@ -87,7 +104,7 @@ func CheckChangeWithDeps(s *discordgo.Session, redisClient RedisClient) {
 	sem := make(chan struct{}, maxConcurrency)
 
 loop:
-	for _, v := range watchList {
+	for _, v := range symbols {
 		if err := ctx.Err(); err != nil {
 			logger.Warn("檢查任務超時，停止派發剩餘標的", "error", err)
 			break
@@ -101,35 +118,14 @@ loop:
 		}
 
 		wg.Add(1)
-		go func(symbol string) {
+		go func(symbol string, lists []*watchList) {
 			defer wg.Done()
 			defer func() {
 				<-sem
 			}()
 
-			// 先看是否已通知過
-			redisGetCtx, redisGetCancel := context.WithTimeout(ctx, externalTimeout)
-			redisRes, err := redisClient.Get(redisGetCtx, "watch_list:"+symbol)
-			redisGetCancel()
-			if err != nil {
-				logger.Error("取得通知紀錄失敗", "symbol", symbol, "error", err)
-				taskErrorReporter.Notify(
-					s,
-					"check_change:watch_list:get_record",
-					&discord.SendMessageInput{
-						ChannelID: taskConfig.WatchListChannelID,
-						Content:   fmt.Sprintf("取得紀錄時錯誤: %v", err),
-					},
-				)
-				return
-			}
-
-			if redisRes != "" {
-				return
-			}
-
 			changeCtx, changeCancel := context.WithTimeout(ctx, externalTimeout)
-			change, err := GetChange(changeCtx, symbol)
+			quote, err := GetSessionChange(changeCtx, symbol)
 			changeCancel()
 			if err != nil {
 				logger.Error("取得漲跌幅失敗", "symbol", symbol, "error", err)
@@ -144,45 +140,14 @@ loop:
 				return
 			}
 
-			if change > 3 || change < -3 {
-				logger.Warn("股票漲跌幅超過閾值", "symbol", symbol, "change", change)
-				_, err = s.ChannelMessageSendComplex(taskConfig.WatchListChannelID, &discordgo.MessageSend{
-					Content: fmt.Sprintf("<@%s> 警告: %s 今日漲跌幅為 %.2f %%", taskConfig.DefaultUserID, symbol, change),
-					AllowedMentions: &discordgo.MessageAllowedMentions{
-						Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
-					},
-				})
-				if err != nil {
-					logger.Error("發送警告訊息失敗", "symbol", symbol, "error", err)
-					taskErrorReporter.Notify(
-						s,
-						"check_change:watch_list:send_alert",
-						&discord.SendMessageInput{
-							ChannelID: taskConfig.WatchListChannelID,
-							Content:   fmt.Sprintf("發送訊息時錯誤: %v", err),
-						},
-					)
-					return
-				}
-
-				// 寫入紀錄已通知
-				setCtx, setCancel := context.WithTimeout(ctx, externalTimeout)
-				err = redisClient.Set(setCtx, "watch_list:"+symbol, "true", time.Hour*8)
-				setCancel()
-				if err != nil {
-					logger.Error("寫入通知紀錄失敗", "symbol", symbol, "error", err)
-					taskErrorReporter.Notify(
-						s,
-						"check_change:watch_list:set_record",
-						&discord.SendMessageInput{
-							ChannelID: taskConfig.WatchListChannelID,
-							Content:   fmt.Sprintf("寫入紀錄時錯誤: %v", err),
-						},
-					)
-					return
-				}
+			for _, list := range lists {
+				alertOnChange(ctx, s, redisClient, taskConfig, externalTimeout, list, symbol, quote)
+			}
+
+			if volumeSpikeEnabled(taskConfig, quote.Session) {
+				alertOnVolume(ctx, s, redisClient, GetClient("finnhub"), taskConfig, externalTimeout, lists, symbol, quote)
 			}
-		}(v)
+		}(v, bySymbol[v])
 	}
 
 	wg.Wait()
@@ -200,3 +165,148 @@ loop:
 
 	logger.Info("完成股票漲跌幅檢查")
 }
+
+// alertOnChange 依清單對該標的的門檻與分級判斷，尚未通知的警告發送到清單的頻道（排程檢查與即時串流共用）
+func alertOnChange(ctx context.Context, s *discordgo.Session, redisClient RedisClient, taskConfig *config.TaskConfig, externalTimeout time.Duration, list *watchList, symbol string, quote *SessionQuote) {
+	for _, alert := range list.Threshold(symbol, taskConfig).alerts(list.Owner, symbol, quote, taskConfig.WatchAlertTiers) {
+		sendWatchAlert(ctx, s, redisClient, taskConfig, externalTimeout, list, symbol, quote, alert)
+	}
+}
+
+// alertOnVolume 今日成交量超過日均量指定倍數時，發送警告到尚未通知的清單頻道
+// Finnhub 方案不支援日線時當日略過成交量檢查，不視為錯誤
+func alertOnVolume(ctx context.Context, s *discordgo.Session, redisClient RedisClient, client FinnhubClient, taskConfig *config.TaskConfig, externalTimeout time.Duration, lists []*watchList, symbol string, quote *SessionQuote) {
+	recordCtx, recordCancel := context.WithTimeout(ctx, externalTimeout)
+	restricted, err := redisClient.Get(recordCtx, volumePlanRestrictedKey)
+	var pending []*watchList
+	if err == nil && restricted == "" {
+		pending, err = pendingVolumeLists(recordCtx, redisClient, lists, symbol, quote.Session)
+	}
+	recordCancel()
+	if err != nil {
+		logger.Error("取得成交量通知紀錄失敗", "symbol", symbol, "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"check_change:watch_list:get_volume_record",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得成交量通知紀錄時錯誤: %v", err),
+			},
+		)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	now := nowFunc()
+	volumeCtx, volumeCancel := context.WithTimeout(ctx, externalTimeout)
+	stats, err := getVolumeStats(volumeCtx, redisClient, client, symbol, taskConfig.VolumeAverageDays, now)
+	volumeCancel()
+	if errors.Is(err, ErrPlanRestricted) {
+		logger.Warn("Finnhub 方案不支援日線，今日略過成交量檢查", "symbol", symbol)
+		setCtx, setCancel := context.WithTimeout(ctx, externalTimeout)
+		if err := redisClient.Set(setCtx, volumePlanRestrictedKey, "true", alertRecordTTL(now)); err != nil {
+			logger.Error("寫入成交量略過標記失敗", "error", err)
+		}
+		setCancel()
+		return
+	}
+	if err != nil {
+		logger.Error("取得成交量失敗", "symbol", symbol, "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"check_change:watch_list:get_volume",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得成交量時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	for _, list := range pending {
+		if alert := volumeAlert(list.Owner, symbol, quote.Session, stats, taskConfig.VolumeSpikeMultiplier); alert != nil {
+			sendWatchAlert(ctx, s, redisClient, taskConfig, externalTimeout, list, symbol, quote, alert)
+		}
+	}
+}
+
+// sendWatchAlert 確認尚未通知（或達到更高一級）後發送警告並寫入通知紀錄
+func sendWatchAlert(ctx context.Context, s *discordgo.Session, redisClient RedisClient, taskConfig *config.TaskConfig, externalTimeout time.Duration, list *watchList, symbol string, quote *SessionQuote, alert *watchAlert) {
+	// 確認是否已通知過
+	redisGetCtx, redisGetCancel := context.WithTimeout(ctx, externalTimeout)
+	redisRes, err := redisClient.Get(redisGetCtx, alert.RecordKey)
+	redisGetCancel()
+	if err != nil {
+		logger.Error("取得通知紀錄失敗", "symbol", symbol, "owner", list.Owner, "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"check_change:watch_list:get_record",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得通知紀錄時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	if alert.notified(redisRes) {
+		return
+	}
+
+	logger.Warn("股票觸發觀察門檻", "symbol", symbol, "owner", list.Owner, "session", quote.Session, "change", quote.Change, "price", quote.Price)
+	_, err = s.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("%s警告: %s [%s] %s", list.Mention(taskConfig.DefaultUserID), symbol, quote.Session.Label(), alert.Message),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+		},
+	})
+	if err != nil {
+		logger.Error("發送警告訊息失敗", "symbol", symbol, "owner", list.Owner, "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"check_change:watch_list:send_alert:"+list.Owner,
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("發送 %s 觀察清單訊息時錯誤: %v", list.Owner, err),
+			},
+		)
+		return
+	}
+
+	// 寫入各方向已通知的最高級距，當日有效
+	setCtx, setCancel := context.WithTimeout(ctx, externalTimeout)
+	err = redisClient.Set(setCtx, alert.RecordKey, alert.record(redisRes), alertRecordTTL(nowFunc()))
+	setCancel()
+	if err != nil {
+		logger.Error("寫入通知紀錄失敗", "symbol", symbol, "owner", list.Owner, "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"check_change:watch_list:set_record",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("寫入紀錄時錯誤: %v", err),
+			},
+		)
+	}
+}
+
+// alertRecordTTL 通知紀錄保留到美東時間隔日零時，同一級距每天只通知一次
+func alertRecordTTL(now time.Time) time.Duration {
+	local := now.In(usExchange().Location)
+	next := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+	if ttl := next.Sub(now); ttl > time.Minute {
+		return ttl
+	}
+	return time.Minute
+}
+
+// alertRecordKey 通知紀錄 key，每份觀察清單分開記錄，延長時段與正規時段分開記錄
+func alertRecordKey(owner string, symbol string, session MarketSession) string {
+	key := "watch_alert:" + owner + ":" + symbol
+	if session.IsExtended() {
+		key += ":" + string(session)
+	}
+	return key
+}
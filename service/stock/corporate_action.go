@@ -0,0 +1,461 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	corporateactiondao "discordBot/model/dao/corporateaction"
+	snapshotdao "discordBot/model/dao/snapshot"
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dao/stockhistory"
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+)
+
+const (
+	// splitLookbackDays 自動偵測分割時往回查詢的生效日天數
+	splitLookbackDays = 30
+	// splitPreviewLimit 預覽時最多列出的交易紀錄筆數
+	splitPreviewLimit = 10
+)
+
+// CorporateActionRepository 公司行動倉庫接口類型
+type CorporateActionRepository interface {
+	GetCorporateActions(ctx context.Context, input *corporateactiondao.GetInput) ([]*dto.CorporateAction, error)
+}
+
+// corporateActionDaoDeps 封裝 CorporateAction DAO 依賴
+type corporateActionDaoDeps struct{}
+
+func (d corporateActionDaoDeps) GetCorporateActions(ctx context.Context, input *corporateactiondao.GetInput) ([]*dto.CorporateAction, error) {
+	return corporateactiondao.Get(ctx, input)
+}
+
+// splitPromptKey 已提醒過的分割 Redis key，避免每天重複提醒
+func splitPromptKey(userID string, symbol string, date time.Time) string {
+	return fmt.Sprintf("split_prompt:%s:%s:%s", userID, symbol, date.Format("2006-01-02"))
+}
+
+// SplitCommand : 查詢已套用的分割，或預覽股票分割調整（需 $confirm 確認）
+func SplitCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $split AAPL 4:1 2020-08-31
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) == 2 {
+		return listSplits(ctx, corporateActionDaoDeps{}, m.Author.ID, strings.ToUpper(strSlice[1]))
+	}
+
+	action, err := parseSplit(m.Content)
+	if err != nil {
+		return "", err
+	}
+	action.UserID = m.Author.ID
+
+	before, after, err := previewSplit(ctx, stockDaoDeps{}, corporateActionDaoDeps{}, action)
+	if err != nil {
+		return "", err
+	}
+
+	change := &pendingStockChange{Action: dto.CorporateActionSplit, Split: action}
+	if err := storePendingStockChange(ctx, redisDeps{}, m.Author.ID, change); err != nil {
+		return "", err
+	}
+
+	return formatSplitPreview(action, before, after), nil
+}
+
+// parseSplit 解析分割指令參數，比例格式為 <分割後>:<分割前>（例如 4:1 分割、1:10 反分割）
+func parseSplit(content string) (*dto.CorporateAction, error) {
+	strSlice := strings.Fields(content)
+	if len(strSlice) != 4 {
+		return nil, fmt.Errorf("參數錯誤，格式: $split <symbol> [<分割後>:<分割前> <YYYY-MM-DD>]")
+	}
+
+	toStr, fromStr, ok := strings.Cut(strSlice[2], ":")
+	if !ok {
+		return nil, fmt.Errorf("無效的分割比例: %s，格式: 4:1", strSlice[2])
+	}
+	to, err := strconv.ParseFloat(toStr, 64)
+	if err != nil || to <= 0 {
+		return nil, fmt.Errorf("無效的分割比例: %s，格式: 4:1", strSlice[2])
+	}
+	from, err := strconv.ParseFloat(fromStr, 64)
+	if err != nil || from <= 0 {
+		return nil, fmt.Errorf("無效的分割比例: %s，格式: 4:1", strSlice[2])
+	}
+	if to == from {
+		return nil, fmt.Errorf("分割比例不可為 1:1")
+	}
+
+	date, err := time.Parse("2006-01-02", strSlice[3])
+	if err != nil {
+		return nil, fmt.Errorf("無效的日期: %s，格式: YYYY-MM-DD", strSlice[3])
+	}
+
+	return &dto.CorporateAction{
+		Symbol:        strings.ToUpper(strSlice[1]),
+		Type:          dto.CorporateActionSplit,
+		EffectiveDate: date,
+		FromFactor:    from,
+		ToFactor:      to,
+	}, nil
+}
+
+// previewSplit 計算分割需調整的交易紀錄，回傳調整前與調整後（一一對應）
+func previewSplit(ctx context.Context, repo StockRepository, actionRepo CorporateActionRepository, action *dto.CorporateAction) (before []*dto.Stock, after []*dto.Stock, err error) {
+	if action.EffectiveDate.After(snapshotDate(nowFunc())) {
+		return nil, nil, fmt.Errorf("生效日不可晚於今天")
+	}
+
+	actions, err := actionRepo.GetCorporateActions(ctx, &corporateactiondao.GetInput{
+		UserID: action.UserID,
+		Symbol: action.Symbol,
+		Type:   dto.CorporateActionSplit,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, existing := range actions {
+		if existing.EffectiveDate.Equal(action.EffectiveDate) {
+			return nil, nil, fmt.Errorf("%s %s 的分割已調整過", action.Symbol, action.EffectiveDate.Format("2006-01-02"))
+		}
+	}
+
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: action.UserID,
+			Symbol: action.Symbol,
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before, after = splitTrades(trades, action)
+	if len(before) == 0 {
+		return nil, nil, fmt.Errorf("%s 在 %s 前沒有交易紀錄，不需調整", action.Symbol, action.EffectiveDate.Format("2006-01-02"))
+	}
+
+	return before, after, nil
+}
+
+// splitTrades 生效日前（美東日期）的交易依比例調整數量與價格，總金額與手續費不變
+func splitTrades(trades []*dto.Stock, action *dto.CorporateAction) (before []*dto.Stock, after []*dto.Stock) {
+	ratio := action.Ratio()
+	for _, trade := range sortTrades(trades) {
+		if trade.Symbol != action.Symbol || !snapshotDate(trade.TradedAt).Before(action.EffectiveDate) {
+			continue
+		}
+		adjusted := *trade
+		adjusted.Units = trade.Units * ratio
+		adjusted.Price = trade.Price / ratio
+		before = append(before, trade)
+		after = append(after, &adjusted)
+	}
+	return before, after
+}
+
+// confirmSplit 以最新的交易紀錄重新計算並套用分割
+func confirmSplit(ctx context.Context, redisClient RedisClient, action *dto.CorporateAction) (string, error) {
+	before, after, err := previewSplit(ctx, stockDaoDeps{}, corporateActionDaoDeps{}, action)
+	if err != nil {
+		_ = redisClient.Del(ctx, pendingStockKey(action.UserID))
+		return "", err
+	}
+
+	snapshots, err := commitSplit(ctx, action, before, after)
+	if err != nil {
+		return "", err
+	}
+
+	if err := redisClient.Del(ctx, pendingStockKey(action.UserID)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("已套用 %s %s 分割（%s 生效），調整 %d 筆交易紀錄與 %d 筆快照",
+		action.Symbol, formatSplitRatio(action), action.EffectiveDate.Format("2006-01-02"), len(after), snapshots), nil
+}
+
+// commitSplit 於同一個 transaction 中寫入分割紀錄、調整交易紀錄（保留異動歷程）與快照數量
+func commitSplit(ctx context.Context, action *dto.CorporateAction, before []*dto.Stock, after []*dto.Stock) (snapshots int64, err error) {
+	dbM, err := postgresql.GetConn()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	tx, err := dbM.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("開始 transaction 錯誤: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// 分割紀錄有唯一限制，先寫入可避免重複調整
+	if err = corporateactiondao.Ins(ctx, tx, action); err != nil {
+		return 0, err
+	}
+
+	for i := range after {
+		var affected int64
+		affected, err = stockdao.Upd(ctx, tx, after[i])
+		if err != nil {
+			return 0, err
+		}
+		if affected == 0 {
+			err = fmt.Errorf("找不到交易紀錄 #%d", after[i].ID)
+			return 0, err
+		}
+
+		if err = stockhistory.Ins(ctx, tx, &dto.StockHistory{
+			StockID: before[i].ID,
+			UserID:  action.UserID,
+			Action:  dto.StockHistoryActionUpdate,
+			Before:  before[i],
+			After:   after[i],
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	snapshots, err = snapshotdao.AdjustUnits(ctx, tx, action.UserID, action.Symbol, action.EffectiveDate, action.Ratio())
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit 錯誤: %v", err)
+	}
+
+	return snapshots, nil
+}
+
+// listSplits 列出使用者已套用的分割
+func listSplits(ctx context.Context, actionRepo CorporateActionRepository, userID string, symbol string) (string, error) {
+	actions, err := actionRepo.GetCorporateActions(ctx, &corporateactiondao.GetInput{
+		UserID: userID,
+		Symbol: symbol,
+		Type:   dto.CorporateActionSplit,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(actions) == 0 {
+		return fmt.Sprintf("%s 沒有已套用的分割", symbol), nil
+	}
+
+	lines := make([]string, 0, len(actions)+1)
+	lines = append(lines, fmt.Sprintf("%s 已套用的分割:", symbol))
+	for _, action := range actions {
+		lines = append(lines, fmt.Sprintf("%s %s", action.EffectiveDate.Format("2006-01-02"), formatSplitRatio(action)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// formatSplitRatio 分割比例顯示格式，例如 4:1
+func formatSplitRatio(action *dto.CorporateAction) string {
+	return strconv.FormatFloat(action.ToFactor, 'f', -1, 64) + ":" + strconv.FormatFloat(action.FromFactor, 'f', -1, 64)
+}
+
+// formatSplitPreview 分割調整預覽顯示格式
+func formatSplitPreview(action *dto.CorporateAction, before []*dto.Stock, after []*dto.Stock) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "即將套用 %s %s 分割（%s 生效），調整 %d 筆交易紀錄:\n",
+		action.Symbol, formatSplitRatio(action), action.EffectiveDate.Format("2006-01-02"), len(after))
+	for i := range after {
+		if i == splitPreviewLimit {
+			fmt.Fprintf(&b, "...另有 %d 筆\n", len(after)-splitPreviewLimit)
+			break
+		}
+		fmt.Fprintf(&b, "%s → %v 股 @ %.4f\n", formatTrade(before[i]), after[i].Units, after[i].Price)
+	}
+	fmt.Fprintf(&b, "生效日前的每日快照數量會一併調整\n輸入 $confirm 確認或 $cancel 取消（%v 內有效）", pendingStockTTL)
+	return b.String()
+}
+
+// DetectSplits : 依資料來源的分割資料提醒持有者確認調整
+func DetectSplits(s *discordgo.Session) {
+	DetectSplitsWithDeps(s, stockDaoDeps{}, userSettingDaoDeps{}, corporateActionDaoDeps{}, redisDeps{})
+}
+
+// DetectSplitsWithDeps 使用指定依賴偵測股票分割（用於測試）
+func DetectSplitsWithDeps(s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, actionRepo CorporateActionRepository, redisClient RedisClient) {
+	taskConfig := config.GetTaskConfig()
+	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), durationFromSeconds(taskConfig.CalculateProfitTimeoutSeconds, 3*time.Minute))
+	defer cancel()
+
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+	today := snapshotDate(nowFunc())
+
+	userIDs, err := repo.GetUserIDs(ctx)
+	if err != nil {
+		logger.Error("取得使用者清單失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"split_detection:get_users",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.ProfitReportChannelID,
+				Content:   fmt.Sprintf("偵測股票分割取使用者清單時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	// 同一標的只向資料來源查詢一次
+	cache := make(map[string][]*SplitEvent)
+	fetch := func(symbol string) ([]*SplitEvent, error) {
+		if events, ok := cache[symbol]; ok {
+			return events, nil
+		}
+		fetchCtx, fetchCancel := context.WithTimeout(ctx, externalTimeout)
+		defer fetchCancel()
+		events, err := GetClient("finnhub").GetSplits(fetchCtx, symbol, today.AddDate(0, 0, -splitLookbackDays).Format("2006-01-02"), today.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		cache[symbol] = events
+		return events, nil
+	}
+
+	for _, userID := range userIDs {
+		if err := promptUserSplits(ctx, s, repo, settingRepo, actionRepo, redisClient, fetch, taskConfig, userID, today); err != nil {
+			logger.Error("偵測股票分割失敗", "userID", userID, "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"split_detection:"+userID,
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.ProfitReportChannelID,
+					Content:   fmt.Sprintf("偵測 <@%s> 股票分割時錯誤: %v", userID, err),
+				},
+			)
+		}
+	}
+
+	logger.Info("完成股票分割偵測")
+}
+
+// promptUserSplits 提醒使用者確認尚未套用的分割，每個分割只提醒一次
+func promptUserSplits(ctx context.Context, s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, actionRepo CorporateActionRepository, redisClient RedisClient, fetch func(symbol string) ([]*SplitEvent, error), taskConfig *config.TaskConfig, userID string, today time.Time) error {
+	candidates, err := detectUserSplits(ctx, repo, actionRepo, fetch, userID, today)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	setting, err := settingRepo.GetSetting(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("取使用者設定錯誤: %w", err)
+	}
+
+	for _, action := range candidates {
+		key := splitPromptKey(userID, action.Symbol, action.EffectiveDate)
+		prompted, err := redisClient.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if prompted != "" {
+			continue
+		}
+
+		if err := sendToReportTarget(s, setting, taskConfig.ProfitReportChannelID, userID, &discordgo.MessageSend{
+			Content: formatSplitPrompt(action),
+		}); err != nil {
+			return err
+		}
+
+		if err := redisClient.Set(ctx, key, "1", splitLookbackDays*24*time.Hour); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// detectUserSplits 找出使用者在生效日前有持股、但尚未套用的分割
+func detectUserSplits(ctx context.Context, repo StockRepository, actionRepo CorporateActionRepository, fetch func(symbol string) ([]*SplitEvent, error), userID string, today time.Time) ([]*dto.CorporateAction, error) {
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("取資料時錯誤: %w", err)
+	}
+
+	actions, err := actionRepo.GetCorporateActions(ctx, &corporateactiondao.GetInput{
+		UserID: userID,
+		Type:   dto.CorporateActionSplit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]bool)
+	for _, action := range actions {
+		applied[splitPromptKey(userID, action.Symbol, action.EffectiveDate)] = true
+	}
+
+	var symbols []string
+	bySymbol := make(map[string][]*dto.Stock)
+	for _, trade := range sortTrades(trades) {
+		if bySymbol[trade.Symbol] == nil {
+			symbols = append(symbols, trade.Symbol)
+		}
+		bySymbol[trade.Symbol] = append(bySymbol[trade.Symbol], trade)
+	}
+
+	var candidates []*dto.CorporateAction
+	for _, symbol := range symbols {
+		events, err := fetch(symbol)
+		if err != nil {
+			logger.Error("取得分割資料失敗", "symbol", symbol, "error", err)
+			continue
+		}
+
+		for _, event := range events {
+			date, err := time.Parse("2006-01-02", event.Date)
+			if err != nil || date.After(today) || event.FromFactor <= 0 || event.ToFactor <= 0 || event.FromFactor == event.ToFactor {
+				continue
+			}
+			if applied[splitPromptKey(userID, symbol, date)] {
+				continue
+			}
+			if unitsHeldBefore(bySymbol[symbol], date) <= unitsEpsilon {
+				continue
+			}
+
+			candidates = append(candidates, &dto.CorporateAction{
+				UserID:        userID,
+				Symbol:        symbol,
+				Type:          dto.CorporateActionSplit,
+				EffectiveDate: date,
+				FromFactor:    float64(event.FromFactor),
+				ToFactor:      float64(event.ToFactor),
+			})
+		}
+	}
+
+	return candidates, nil
+}
+
+// formatSplitPrompt 分割提醒訊息
+func formatSplitPrompt(action *dto.CorporateAction) string {
+	ratio := formatSplitRatio(action)
+	date := action.EffectiveDate.Format("2006-01-02")
+	return fmt.Sprintf("<@%s> 偵測到 %s 於 %s 進行 %s 分割，交易紀錄尚未調整，損益會因此失真。\n輸入 `$split %s %s %s` 預覽調整",
+		action.UserID, action.Symbol, date, ratio, action.Symbol, ratio, date)
+}
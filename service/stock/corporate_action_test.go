@@ -0,0 +1,191 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_parseSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantFrom float64
+		wantTo   float64
+		wantErr  bool
+	}{
+		{name: "forward split", content: "$split aapl 4:1 2020-08-31", wantFrom: 1, wantTo: 4},
+		{name: "reverse split", content: "$split GE 1:8 2021-08-02", wantFrom: 8, wantTo: 1},
+		{name: "fractional ratio", content: "$split BRK.B 3:2 2026-01-05", wantFrom: 2, wantTo: 3},
+		{name: "missing date", content: "$split AAPL 4:1", wantErr: true},
+		{name: "invalid ratio", content: "$split AAPL 4 2020-08-31", wantErr: true},
+		{name: "zero factor", content: "$split AAPL 0:1 2020-08-31", wantErr: true},
+		{name: "one to one", content: "$split AAPL 2:2 2020-08-31", wantErr: true},
+		{name: "invalid date", content: "$split AAPL 4:1 2020/08/31", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSplit(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSplit() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSplit() unexpected error = %v", err)
+			}
+			if got.FromFactor != tt.wantFrom || got.ToFactor != tt.wantTo || got.Type != dto.CorporateActionSplit || got.Symbol != strings.ToUpper(got.Symbol) {
+				t.Errorf("parseSplit() = %+v, want %v:%v", *got, tt.wantTo, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func Test_splitTrades(t *testing.T) {
+	at := func(m time.Month, d int, hour int) time.Time { return time.Date(2026, m, d, hour, 0, 0, 0, time.UTC) }
+
+	buy := &dto.Stock{ID: 1, Symbol: "NVDA", Side: dto.StockSideBuy, Units: 10, Price: 1000, Fee: 1, TradedAt: at(5, 1, 15)}
+	sell := &dto.Stock{ID: 2, Symbol: "NVDA", Side: dto.StockSideSell, Units: 4, Price: 1100, TradedAt: at(6, 7, 15)}
+	// UTC 6/10 01:00 為美東 6/9 晚間，仍屬生效日前
+	lateBuy := &dto.Stock{ID: 3, Symbol: "NVDA", Side: dto.StockSideBuy, Units: 1, Price: 1200, TradedAt: at(6, 10, 1)}
+	after := &dto.Stock{ID: 4, Symbol: "NVDA", Side: dto.StockSideBuy, Units: 5, Price: 120, TradedAt: at(6, 10, 15)}
+	other := &dto.Stock{ID: 5, Symbol: "AAPL", Side: dto.StockSideBuy, Units: 5, Price: 200, TradedAt: at(5, 1, 15)}
+
+	action := &dto.CorporateAction{Symbol: "NVDA", EffectiveDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), FromFactor: 1, ToFactor: 10}
+	before, adjusted := splitTrades([]*dto.Stock{after, sell, other, lateBuy, buy}, action)
+
+	wantIDs := []int64{1, 2, 3}
+	if len(before) != len(wantIDs) || len(adjusted) != len(wantIDs) {
+		t.Fatalf("splitTrades() = %d, %d trades, want %d", len(before), len(adjusted), len(wantIDs))
+	}
+	for i, id := range wantIDs {
+		if before[i].ID != id || adjusted[i].ID != id {
+			t.Errorf("splitTrades()[%d] = #%d/#%d, want #%d", i, before[i].ID, adjusted[i].ID, id)
+		}
+		if !floatEqual(adjusted[i].Units, before[i].Units*10) || !floatEqual(adjusted[i].Price, before[i].Price/10) || adjusted[i].Fee != before[i].Fee {
+			t.Errorf("splitTrades()[%d] = %+v, before %+v", i, *adjusted[i], *before[i])
+		}
+	}
+	if buy.Units != 10 || buy.Price != 1000 {
+		t.Errorf("splitTrades() modified original trade: %+v", *buy)
+	}
+
+	// 調整後持倉：數量乘以比例、總成本不變
+	positions, err := DerivePositions(append(adjusted, after))
+	if err != nil {
+		t.Fatalf("DerivePositions() unexpected error = %v", err)
+	}
+	if len(positions) != 1 || !floatEqual(positions[0].Units, 75) {
+		t.Errorf("DerivePositions() after split = %+v", positions)
+	}
+}
+
+func Test_previewSplit(t *testing.T) {
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	trade := &dto.Stock{ID: 1, UserID: "u1", Symbol: "NVDA", Side: dto.StockSideBuy, Units: 10, Price: 1000, TradedAt: time.Date(2026, 5, 1, 15, 0, 0, 0, time.UTC)}
+	repo := &MockStockRepository{Stocks: []*dto.Stock{trade}}
+	applied := &dto.CorporateAction{UserID: "u1", Symbol: "NVDA", Type: dto.CorporateActionSplit, EffectiveDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), FromFactor: 1, ToFactor: 10}
+	actionRepo := &MockCorporateActionRepository{Actions: []*dto.CorporateAction{applied}}
+
+	split := func(userID string, symbol string, m time.Month, d int) *dto.CorporateAction {
+		return &dto.CorporateAction{UserID: userID, Symbol: symbol, Type: dto.CorporateActionSplit, EffectiveDate: time.Date(2026, m, d, 0, 0, 0, 0, time.UTC), FromFactor: 1, ToFactor: 2}
+	}
+
+	tests := []struct {
+		name    string
+		action  *dto.CorporateAction
+		want    int
+		wantErr bool
+	}{
+		{name: "new split", action: split("u1", "NVDA", 7, 1), want: 1},
+		{name: "already applied", action: split("u1", "NVDA", 6, 10), wantErr: true},
+		{name: "no trades before split", action: split("u1", "NVDA", 4, 1), wantErr: true},
+		{name: "other user", action: split("u2", "NVDA", 7, 1), wantErr: true},
+		{name: "future split", action: split("u1", "NVDA", 7, 16), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after, err := previewSplit(context.Background(), repo, actionRepo, tt.action)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("previewSplit() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("previewSplit() unexpected error = %v", err)
+			}
+			if len(before) != tt.want || len(after) != tt.want {
+				t.Errorf("previewSplit() = %d, %d trades, want %d", len(before), len(after), tt.want)
+			}
+
+			text := formatSplitPreview(tt.action, before, after)
+			if !strings.Contains(text, "2:1") || !strings.Contains(text, "20 股 @ 500.0000") {
+				t.Errorf("formatSplitPreview() = %q", text)
+			}
+		})
+	}
+}
+
+func Test_detectUserSplits(t *testing.T) {
+	at := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 15, 0, 0, 0, time.UTC) }
+
+	repo := &MockStockRepository{Stocks: []*dto.Stock{
+		{UserID: "u1", Symbol: "NVDA", Side: dto.StockSideBuy, Units: 10, TradedAt: at(5, 1)},
+		{UserID: "u1", Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, TradedAt: at(5, 1)},
+		{UserID: "u1", Symbol: "AAPL", Side: dto.StockSideSell, Units: 10, TradedAt: at(6, 1)},
+		{UserID: "u1", Symbol: "TSLA", Side: dto.StockSideBuy, Units: 3, TradedAt: at(5, 1)},
+		{UserID: "u1", Symbol: "GE", Side: dto.StockSideBuy, Units: 80, TradedAt: at(5, 1)},
+	}}
+	actionRepo := &MockCorporateActionRepository{Actions: []*dto.CorporateAction{
+		{UserID: "u1", Symbol: "TSLA", Type: dto.CorporateActionSplit, EffectiveDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), FromFactor: 1, ToFactor: 3},
+	}}
+	events := map[string][]*SplitEvent{
+		"NVDA": {{Symbol: "NVDA", Date: "2026-06-10", FromFactor: 1, ToFactor: 10}},
+		// 分割前已全數賣出
+		"AAPL": {{Symbol: "AAPL", Date: "2026-07-01", FromFactor: 1, ToFactor: 4}},
+		// 已套用過
+		"TSLA": {{Symbol: "TSLA", Date: "2026-07-01", FromFactor: 1, ToFactor: 3}},
+		// 反分割；另一筆尚未生效
+		"GE": {
+			{Symbol: "GE", Date: "2026-07-02", FromFactor: 8, ToFactor: 1},
+			{Symbol: "GE", Date: "2026-07-20", FromFactor: 1, ToFactor: 2},
+		},
+	}
+	fetch := func(symbol string) ([]*SplitEvent, error) { return events[symbol], nil }
+
+	got, err := detectUserSplits(context.Background(), repo, actionRepo, fetch, "u1", time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("detectUserSplits() unexpected error = %v", err)
+	}
+
+	want := []struct {
+		symbol string
+		ratio  float64
+	}{
+		{symbol: "NVDA", ratio: 10},
+		{symbol: "GE", ratio: 0.125},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("detectUserSplits() = %d candidates, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Symbol != w.symbol || !floatEqual(got[i].Ratio(), w.ratio) || got[i].UserID != "u1" {
+			t.Errorf("detectUserSplits()[%d] = %+v, want %s ratio %v", i, *got[i], w.symbol, w.ratio)
+		}
+	}
+
+	prompt := formatSplitPrompt(got[1])
+	if !strings.Contains(prompt, "$split GE 1:8 2026-07-02") {
+		t.Errorf("formatSplitPrompt() = %q", prompt)
+	}
+}
@@ -2,6 +2,8 @@ package stock
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"sync"
 
@@ -51,6 +53,114 @@ func (w *finnhubClientWrapper) GetQuote(ctx context.Context, symbol string) (*Qu
 	}, nil
 }
 
+// GetEarningsCalendar 實現 FinnhubClient 接口
+func (w *finnhubClientWrapper) GetEarningsCalendar(ctx context.Context, from, to string) ([]*EarningsEvent, error) {
+	res, _, err := w.client.EarningsCalendar(ctx).From(from).To(to).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	releases := res.GetEarningsCalendar()
+	events := make([]*EarningsEvent, 0, len(releases))
+	for _, r := range releases {
+		events = append(events, &EarningsEvent{
+			Symbol:      r.GetSymbol(),
+			Date:        r.GetDate(),
+			Hour:        r.GetHour(),
+			Year:        r.GetYear(),
+			Quarter:     r.GetQuarter(),
+			EpsEstimate: r.EpsEstimate,
+			EpsActual:   r.EpsActual,
+		})
+	}
+
+	return events, nil
+}
+
+// GetCandles 實現 FinnhubClient 接口
+// resolution 可為 1, 5, 15, 30, 60, D, W, M；查無資料時回傳空的 Candles，方案無權限（403）時回傳 ErrPlanRestricted
+func (w *finnhubClientWrapper) GetCandles(ctx context.Context, symbol string, resolution string, from, to int64) (*Candles, error) {
+	res, httpRes, err := w.client.StockCandles(ctx).Symbol(symbol).Resolution(resolution).From(from).To(to).Execute()
+	if err != nil {
+		if httpRes != nil && httpRes.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("%w: %v", ErrPlanRestricted, err)
+		}
+		return nil, err
+	}
+
+	if res.GetS() != "ok" {
+		return &Candles{}, nil
+	}
+
+	return &Candles{
+		Open:      res.GetO(),
+		High:      res.GetH(),
+		Low:       res.GetL(),
+		Close:     res.GetC(),
+		Volume:    res.GetV(),
+		Timestamp: res.GetT(),
+	}, nil
+}
+
+// GetDividends 實現 FinnhubClient 接口
+// from、to 為除息日區間 (YYYY-MM-DD)
+func (w *finnhubClientWrapper) GetDividends(ctx context.Context, symbol string, from, to string) ([]*DividendEvent, error) {
+	res, _, err := w.client.StockDividends(ctx).Symbol(symbol).From(from).To(to).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*DividendEvent, 0, len(res))
+	for _, d := range res {
+		events = append(events, &DividendEvent{
+			Symbol:   d.GetSymbol(),
+			ExDate:   d.GetDate(),
+			PayDate:  d.GetPayDate(),
+			Amount:   d.GetAmount(),
+			Currency: d.GetCurrency(),
+		})
+	}
+
+	return events, nil
+}
+
+// GetSplits 實現 FinnhubClient 接口
+// from、to 為生效日區間 (YYYY-MM-DD)
+func (w *finnhubClientWrapper) GetSplits(ctx context.Context, symbol string, from, to string) ([]*SplitEvent, error) {
+	res, _, err := w.client.StockSplits(ctx).Symbol(symbol).From(from).To(to).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*SplitEvent, 0, len(res))
+	for _, split := range res {
+		events = append(events, &SplitEvent{
+			Symbol:     split.GetSymbol(),
+			Date:       split.GetDate(),
+			FromFactor: split.GetFromFactor(),
+			ToFactor:   split.GetToFactor(),
+		})
+	}
+
+	return events, nil
+}
+
+// GetCompanyProfile 實現 FinnhubClient 接口
+func (w *finnhubClientWrapper) GetCompanyProfile(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	res, _, err := w.client.CompanyProfile2(ctx).Symbol(symbol).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompanyProfile{
+		Symbol:   symbol,
+		Name:     res.GetName(),
+		Country:  res.GetCountry(),
+		Currency: res.GetCurrency(),
+		Industry: res.GetFinnhubIndustry(),
+	}, nil
+}
+
 // GetConn : 取得 Finnhub 連線（向後兼容）
 // 若超時無法取得連線，會回傳error
 func GetConn(name string) (ret *finnhub.DefaultApiService) {
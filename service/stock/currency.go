@@ -0,0 +1,133 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/model/dao/usersetting"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/service/exchange"
+)
+
+// getRates 取得各幣別匯率（測試時可替換）
+var getRates = exchange.GetRatesWithContext
+
+// baseCurrency 使用者的基準幣別，未設定時使用系統預設
+func baseCurrency(setting *dto.UserSetting, defaultCurrency string) string {
+	if setting != nil && setting.BaseCurrency != "" {
+		return setting.BaseCurrency
+	}
+	if defaultCurrency == "" {
+		return "USD"
+	}
+	return defaultCurrency
+}
+
+// holdingCurrency 持倉或交易的幣別，舊資料未填時視為美元
+func holdingCurrency(currency string) string {
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// CurrencyCommand : 查詢或設定呼叫者的基準幣別
+func CurrencyCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $currency [USD|TWD|default]
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $currency [幣別|default]")
+	}
+
+	defaultCurrency := config.GetTaskConfig().DefaultBaseCurrency
+
+	if len(strSlice) == 1 {
+		setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("目前基準幣別: %s", baseCurrency(setting, defaultCurrency)), nil
+	}
+
+	currency := strings.ToUpper(strSlice[1])
+	if currency == "DEFAULT" {
+		if err := usersetting.UpsertBaseCurrency(ctx, nil, m.Author.ID, ""); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("已改用系統預設基準幣別: %s", baseCurrency(nil, defaultCurrency)), nil
+	}
+
+	rates, err := getRates(ctx)
+	if err != nil {
+		return "", fmt.Errorf("取得匯率錯誤: %w", err)
+	}
+	if _, ok := rates[currency]; !ok {
+		return "", fmt.Errorf("不支援的幣別: %s", currency)
+	}
+
+	if err := usersetting.UpsertBaseCurrency(ctx, nil, m.Author.ID, currency); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("基準幣別已設定為: %s（已保存的快照維持原幣別，區間表現自下次結算起以新幣別計算）", currency), nil
+}
+
+// currencyExposure 單一幣別的持倉曝險
+type currencyExposure struct {
+	Currency string
+	// 以原幣別計算的成本、市值與未實現損益
+	Cost   float64
+	Value  float64
+	Profit float64
+	// 換算基準幣別後的市值
+	BaseValue float64
+}
+
+// sortExposures 依換算後市值由大到小排序
+func sortExposures(exposures map[string]*currencyExposure) []*currencyExposure {
+	ret := make([]*currencyExposure, 0, len(exposures))
+	for _, exposure := range exposures {
+		ret = append(ret, exposure)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].BaseValue != ret[j].BaseValue {
+			return ret[i].BaseValue > ret[j].BaseValue
+		}
+		return ret[i].Currency < ret[j].Currency
+	})
+	return ret
+}
+
+// formatExposures 幣別曝險顯示格式，例如 "USD 75.00% (市值 1000.00 USD, 損益 +100.00 USD)"
+func formatExposures(exposures []*currencyExposure, totalValue float64) string {
+	parts := make([]string, 0, len(exposures))
+	for _, exposure := range exposures {
+		var weight float64
+		if totalValue > 0 {
+			weight = exposure.BaseValue / totalValue * 100
+		}
+		parts = append(parts, fmt.Sprintf("%s %.2f%% (市值 %.2f %s, 損益 %+.2f %s)",
+			exposure.Currency, weight, exposure.Value, exposure.Currency, exposure.Profit, exposure.Currency))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// latestCurrencySnapshots 只保留與最後一筆相同幣別的連續快照，避免基準幣別變更前後的金額混算
+// snapshots 需依日期由舊到新排序
+func latestCurrencySnapshots(snapshots []*dto.PortfolioSnapshot) []*dto.PortfolioSnapshot {
+	if len(snapshots) == 0 {
+		return snapshots
+	}
+
+	currency := snapshots[len(snapshots)-1].Currency
+	start := len(snapshots) - 1
+	for start > 0 && snapshots[start-1].Currency == currency {
+		start--
+	}
+	return snapshots[start:]
+}
@@ -0,0 +1,71 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_baseCurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting *dto.UserSetting
+		def     string
+		want    string
+	}{
+		{name: "no setting", def: "TWD", want: "TWD"},
+		{name: "user setting", setting: &dto.UserSetting{BaseCurrency: "JPY"}, def: "TWD", want: "JPY"},
+		{name: "empty user setting", setting: &dto.UserSetting{}, def: "TWD", want: "TWD"},
+		{name: "no default", want: "USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := baseCurrency(tt.setting, tt.def); got != tt.want {
+				t.Errorf("baseCurrency() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_latestCurrencySnapshots(t *testing.T) {
+	day := func(d int, currency string) *dto.PortfolioSnapshot {
+		return &dto.PortfolioSnapshot{Date: time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC), Currency: currency}
+	}
+
+	tests := []struct {
+		name      string
+		snapshots []*dto.PortfolioSnapshot
+		want      int
+	}{
+		{name: "empty", want: 0},
+		{name: "same currency", snapshots: []*dto.PortfolioSnapshot{day(1, "USD"), day(2, "USD")}, want: 2},
+		{name: "legacy snapshots", snapshots: []*dto.PortfolioSnapshot{day(1, ""), day(2, "")}, want: 2},
+		{name: "base currency changed", snapshots: []*dto.PortfolioSnapshot{day(1, ""), day(2, "USD"), day(3, "TWD"), day(4, "TWD")}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := latestCurrencySnapshots(tt.snapshots)
+			if len(got) != tt.want {
+				t.Fatalf("latestCurrencySnapshots() = %d snapshots, want %d", len(got), tt.want)
+			}
+			if tt.want > 0 && got[len(got)-1] != tt.snapshots[len(tt.snapshots)-1] {
+				t.Errorf("latestCurrencySnapshots() dropped the latest snapshot")
+			}
+		})
+	}
+}
+
+func Test_formatExposures(t *testing.T) {
+	exposures := sortExposures(map[string]*currencyExposure{
+		"USD": {Currency: "USD", Value: 150, Profit: 50, BaseValue: 4500},
+		"TWD": {Currency: "TWD", Value: 13500, Profit: -500, BaseValue: 13500},
+	})
+
+	want := "TWD 75.00% (市值 13500.00 TWD, 損益 -500.00 TWD), USD 25.00% (市值 150.00 USD, 損益 +50.00 USD)"
+	if got := formatExposures(exposures, 18000); got != want {
+		t.Errorf("formatExposures() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,460 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	dividenddao "discordBot/model/dao/dividend"
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+)
+
+// dividendLookbackDays 自動偵測配息時往回查詢的除息日天數（需涵蓋除息日到發放日的間隔）
+const dividendLookbackDays = 60
+
+// DividendRepository 股息倉庫接口類型
+type DividendRepository interface {
+	SaveDividend(ctx context.Context, dividend *dto.Dividend) error
+	AddDividends(ctx context.Context, dividends []*dto.Dividend) (int64, error)
+	GetDividends(ctx context.Context, input *dividenddao.GetInput) ([]*dto.Dividend, error)
+}
+
+// dividendDaoDeps 封裝 Dividend DAO 依賴
+type dividendDaoDeps struct{}
+
+func (d dividendDaoDeps) SaveDividend(ctx context.Context, dividend *dto.Dividend) error {
+	return dividenddao.Upsert(ctx, nil, dividend)
+}
+
+func (d dividendDaoDeps) AddDividends(ctx context.Context, dividends []*dto.Dividend) (int64, error) {
+	return dividenddao.InsIgnore(ctx, nil, dividends)
+}
+
+func (d dividendDaoDeps) GetDividends(ctx context.Context, input *dividenddao.GetInput) ([]*dto.Dividend, error) {
+	return dividenddao.Get(ctx, input)
+}
+
+// DividendCommand : 登記呼叫者收到的股息
+func DividendCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $dividend AAPL 12.5 2026-05-15 [currency] [tax=<tax>]
+	setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+
+	dividend, withholding, err := parseDividend(m.Content, loadFeeSchedules().ForUser(setting))
+	if err != nil {
+		return "", err
+	}
+	dividend.UserID = m.Author.ID
+
+	if err := recordDividend(ctx, stockDaoDeps{}, dividendDaoDeps{}, dividend, withholding); err != nil {
+		return "", err
+	}
+
+	text := fmt.Sprintf("已登記 %s 股息 %.2f %s（%s）", dividend.Symbol, dividend.Amount, dividend.Currency, dividend.PayDate.Format("2006-01-02"))
+	if dividend.Tax > 0 {
+		text += fmt.Sprintf("，預扣稅 %.2f，實收 %.2f", dividend.Tax, dividend.Amount-dividend.Tax)
+	}
+	return text, nil
+}
+
+// parseDividend 解析股息指令參數，amount 為稅前金額
+// 未輸入預扣稅時回傳使用者券商 schedule，待確定幣別後由 recordDividend 計算預扣稅
+func parseDividend(content string, schedule *FeeSchedule) (*dto.Dividend, *FeeSchedule, error) {
+	strSlice := strings.Fields(content)
+	usage := fmt.Errorf("參數錯誤，格式: $dividend <symbol> <amount> <YYYY-MM-DD> [currency] [tax=<tax>]")
+	if len(strSlice) < 4 || len(strSlice) > 6 {
+		return nil, nil, usage
+	}
+
+	amount, err := strconv.ParseFloat(strSlice[2], 64)
+	if err != nil || amount <= 0 {
+		return nil, nil, fmt.Errorf("無效的金額: %s", strSlice[2])
+	}
+
+	payDate, err := time.Parse("2006-01-02", strSlice[3])
+	if err != nil {
+		return nil, nil, fmt.Errorf("無效的日期: %s", strSlice[3])
+	}
+
+	dividend := &dto.Dividend{
+		Symbol:  strings.ToUpper(strSlice[1]),
+		Amount:  amount,
+		PayDate: payDate,
+		Source:  dto.DividendSourceManual,
+	}
+
+	taxSet := false
+	for _, arg := range strSlice[4:] {
+		if value, ok := strings.CutPrefix(strings.ToLower(arg), "tax="); ok {
+			tax, err := strconv.ParseFloat(value, 64)
+			if err != nil || tax < 0 || tax > amount {
+				return nil, nil, fmt.Errorf("無效的預扣稅: %s", value)
+			}
+			dividend.Tax, taxSet = tax, true
+			continue
+		}
+
+		currency := strings.ToUpper(arg)
+		if dividend.Currency != "" || len(currency) != 3 {
+			return nil, nil, fmt.Errorf("無效的幣別: %s", arg)
+		}
+		dividend.Currency = currency
+	}
+
+	if taxSet {
+		return dividend, nil, nil
+	}
+	return dividend, schedule, nil
+}
+
+// recordDividend 確認使用者曾持有該標的後寫入股息，未指定幣別時沿用交易紀錄的幣別
+// withholding 不為 nil 時，依券商股息預扣稅率計算預扣稅（只適用券商幣別的股息）
+func recordDividend(ctx context.Context, repo StockRepository, dividendRepo DividendRepository, dividend *dto.Dividend, withholding *FeeSchedule) error {
+	if dividend.PayDate.After(snapshotDate(nowFunc())) {
+		return fmt.Errorf("發放日期不可晚於今天")
+	}
+
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: dividend.UserID,
+			Symbol: dividend.Symbol,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if len(trades) == 0 {
+		return fmt.Errorf("沒有 %s 的交易紀錄", dividend.Symbol)
+	}
+
+	if dividend.Currency == "" {
+		dividend.Currency = trades[0].Currency
+	}
+	if dividend.Currency == "" {
+		dividend.Currency = "USD"
+	}
+
+	if withholding.AppliesTo(dividend.Currency) {
+		dividend.Tax = withholding.DividendTax(dividend.Amount)
+	}
+
+	return dividendRepo.SaveDividend(ctx, dividend)
+}
+
+// netDividend 扣除預扣稅後的實際股息收入
+func netDividend(dividend *dto.Dividend) float64 {
+	return dividend.Amount - dividend.Tax
+}
+
+// dividendIncome 加總扣除預扣稅後的股息收入（總計與各標的）
+func dividendIncome(dividends []*dto.Dividend) (total float64, bySymbol map[string]float64) {
+	bySymbol = make(map[string]float64)
+	for _, dividend := range dividends {
+		total += netDividend(dividend)
+		bySymbol[dividend.Symbol] += netDividend(dividend)
+	}
+	return total, bySymbol
+}
+
+// IncomeCommand : 依月份或年度彙總呼叫者的股息收入
+func IncomeCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $income [month|year] [YYYY]
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 3 {
+		return "", fmt.Errorf("參數錯誤，格式: $income [month|year] [YYYY]")
+	}
+
+	return income(ctx, dividendDaoDeps{}, m.Author.ID, strSlice[1:])
+}
+
+func income(ctx context.Context, dividendRepo DividendRepository, userID string, args []string) (string, error) {
+	period := "month"
+	if len(args) > 0 {
+		period = strings.ToLower(args[0])
+	}
+
+	input := &dividenddao.GetInput{UserID: userID}
+	var layout, title string
+	switch period {
+	case "month":
+		year := snapshotDate(nowFunc()).Year()
+		if len(args) > 1 {
+			y, err := strconv.Atoi(args[1])
+			if err != nil || y < 1900 {
+				return "", fmt.Errorf("無效的年份: %s", args[1])
+			}
+			year = y
+		}
+		input.From = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		input.To = time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+		layout = "2006-01"
+		title = fmt.Sprintf("%d 年股息收入（依月份）", year)
+	case "year":
+		if len(args) > 1 {
+			return "", fmt.Errorf("參數錯誤，格式: $income year")
+		}
+		layout = "2006"
+		title = "股息收入（依年度）"
+	default:
+		return "", fmt.Errorf("不支援的彙總方式: %s (可用: month, year)", period)
+	}
+
+	dividends, err := dividendRepo.GetDividends(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if len(dividends) == 0 {
+		return "", fmt.Errorf("沒有股息紀錄")
+	}
+
+	return formatIncome(title, layout, dividends), nil
+}
+
+// formatIncome 股息收入（扣除預扣稅）彙總顯示格式，dividends 需依發放日期由舊到新排序
+func formatIncome(title string, layout string, dividends []*dto.Dividend) string {
+	var periods []string
+	byPeriod := make(map[string]map[string]float64)
+	bySymbol := make(map[string]map[string]float64)
+	total := make(map[string]float64)
+	withheld := make(map[string]float64)
+
+	for _, dividend := range dividends {
+		period := dividend.PayDate.Format(layout)
+		if byPeriod[period] == nil {
+			byPeriod[period] = make(map[string]float64)
+			periods = append(periods, period)
+		}
+		if bySymbol[dividend.Symbol] == nil {
+			bySymbol[dividend.Symbol] = make(map[string]float64)
+		}
+		byPeriod[period][dividend.Currency] += netDividend(dividend)
+		bySymbol[dividend.Symbol][dividend.Currency] += netDividend(dividend)
+		total[dividend.Currency] += netDividend(dividend)
+		if dividend.Tax > 0 {
+			withheld[dividend.Currency] += dividend.Tax
+		}
+	}
+
+	symbols := make([]string, 0, len(bySymbol))
+	for symbol := range bySymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var b strings.Builder
+	b.WriteString(title + "\n```\n")
+	for _, period := range periods {
+		fmt.Fprintf(&b, "%-8s %s\n", period, formatCurrencyAmounts(byPeriod[period]))
+	}
+	b.WriteString("```\n")
+
+	parts := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		parts = append(parts, fmt.Sprintf("%s %s", symbol, formatCurrencyAmounts(bySymbol[symbol])))
+	}
+	fmt.Fprintf(&b, "合計: %s\n", formatCurrencyAmounts(total))
+	if len(withheld) > 0 {
+		fmt.Fprintf(&b, "已扣預扣稅: %s\n", formatCurrencyAmounts(withheld))
+	}
+	fmt.Fprintf(&b, "依標的: %s", strings.Join(parts, ", "))
+	return b.String()
+}
+
+// formatCurrencyAmounts 依幣別代碼排序顯示金額，例如 "300.00 TWD + 12.00 USD"
+func formatCurrencyAmounts(amounts map[string]float64) string {
+	currencies := make([]string, 0, len(amounts))
+	for currency := range amounts {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, fmt.Sprintf("%.2f %s", amounts[currency], currency))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// DiscoverDividends : 依資料來源的配息資料自動登記已發放的股息
+func DiscoverDividends(s *discordgo.Session) {
+	DiscoverDividendsWithDeps(s, stockDaoDeps{}, userSettingDaoDeps{}, dividendDaoDeps{})
+}
+
+// DiscoverDividendsWithDeps 使用指定依賴自動登記股息（用於測試）
+func DiscoverDividendsWithDeps(s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, dividendRepo DividendRepository) {
+	taskConfig := config.GetTaskConfig()
+	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), durationFromSeconds(taskConfig.CalculateProfitTimeoutSeconds, 3*time.Minute))
+	defer cancel()
+
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+	today := snapshotDate(nowFunc())
+
+	userIDs, err := repo.GetUserIDs(ctx)
+	if err != nil {
+		logger.Error("取得使用者清單失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"dividend_discovery:get_users",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.ProfitReportChannelID,
+				Content:   fmt.Sprintf("自動登記股息取使用者清單時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	// 同一標的只向資料來源查詢一次
+	cache := make(map[string][]*DividendEvent)
+	fetch := func(symbol string) ([]*DividendEvent, error) {
+		if events, ok := cache[symbol]; ok {
+			return events, nil
+		}
+		fetchCtx, fetchCancel := context.WithTimeout(ctx, externalTimeout)
+		defer fetchCancel()
+		events, err := GetClient("finnhub").GetDividends(fetchCtx, symbol, today.AddDate(0, 0, -dividendLookbackDays).Format("2006-01-02"), today.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		cache[symbol] = events
+		return events, nil
+	}
+
+	schedules := loadFeeSchedules()
+
+	for _, userID := range userIDs {
+		setting, err := settingRepo.GetSetting(ctx, userID)
+		if err != nil {
+			logger.Error("取使用者設定錯誤，改用預設券商計算預扣稅", "userID", userID, "error", err)
+		}
+
+		inserted, err := discoverUserDividends(ctx, repo, dividendRepo, fetch, userID, today, schedules.ForUser(setting))
+		if err != nil {
+			logger.Error("自動登記股息失敗", "userID", userID, "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"dividend_discovery:"+userID,
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.ProfitReportChannelID,
+					Content:   fmt.Sprintf("自動登記 <@%s> 股息時錯誤: %v", userID, err),
+				},
+			)
+			continue
+		}
+		if inserted > 0 {
+			logger.Info("自動登記股息", "userID", userID, "count", inserted)
+		}
+	}
+
+	logger.Info("完成自動登記股息")
+}
+
+// discoverUserDividends 依除息日前的持有數量計算已發放的股息並登記，已有紀錄（含手動登記）者略過
+// 預扣稅依使用者券商 schedule 的股息預扣稅率計算
+func discoverUserDividends(ctx context.Context, repo StockRepository, dividendRepo DividendRepository, fetch func(symbol string) ([]*DividendEvent, error), userID string, today time.Time, schedule *FeeSchedule) (int64, error) {
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("取資料時錯誤: %w", err)
+	}
+
+	var symbols []string
+	bySymbol := make(map[string][]*dto.Stock)
+	for _, trade := range sortTrades(trades) {
+		if bySymbol[trade.Symbol] == nil {
+			symbols = append(symbols, trade.Symbol)
+		}
+		bySymbol[trade.Symbol] = append(bySymbol[trade.Symbol], trade)
+	}
+
+	var dividends []*dto.Dividend
+	for _, symbol := range symbols {
+		events, err := fetch(symbol)
+		if err != nil {
+			logger.Error("取得配息資料失敗", "symbol", symbol, "error", err)
+			continue
+		}
+
+		for _, event := range events {
+			exDate, err := time.Parse("2006-01-02", event.ExDate)
+			if err != nil || event.Amount <= 0 {
+				continue
+			}
+
+			payDate := exDate
+			if event.PayDate != "" {
+				if payDate, err = time.Parse("2006-01-02", event.PayDate); err != nil {
+					continue
+				}
+			}
+			if payDate.After(today) {
+				continue
+			}
+
+			units := unitsHeldBefore(bySymbol[symbol], exDate)
+			if units <= unitsEpsilon {
+				continue
+			}
+
+			currency := event.Currency
+			if currency == "" {
+				currency = bySymbol[symbol][0].Currency
+			}
+
+			amount := units * float64(event.Amount)
+			// 券商費率只適用相同幣別的股息
+			var tax float64
+			if schedule.AppliesTo(currency) {
+				tax = schedule.DividendTax(amount)
+			}
+			dividends = append(dividends, &dto.Dividend{
+				UserID:   userID,
+				Symbol:   symbol,
+				Amount:   amount,
+				Tax:      tax,
+				Currency: currency,
+				PayDate:  payDate,
+				Source:   dto.DividendSourceAuto,
+			})
+		}
+	}
+
+	if len(dividends) == 0 {
+		return 0, nil
+	}
+
+	return dividendRepo.AddDividends(ctx, dividends)
+}
+
+// unitsHeldBefore 除息日前（美東日期）持有的數量
+func unitsHeldBefore(trades []*dto.Stock, exDate time.Time) float64 {
+	var units float64
+	for _, trade := range trades {
+		if !snapshotDate(trade.TradedAt).Before(exDate) {
+			continue
+		}
+		if trade.Side == dto.StockSideSell {
+			units -= trade.Units
+		} else {
+			units += trade.Units
+		}
+	}
+	return units
+}
@@ -0,0 +1,291 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_parseDividend(t *testing.T) {
+	withholding := &FeeSchedule{Broker: "firstrade", DividendTaxRate: 0.3}
+
+	tests := []struct {
+		name     string
+		content  string
+		schedule *FeeSchedule
+		want     *dto.Dividend
+		// 待確定幣別後計算預扣稅的券商費率
+		wantWithholding *FeeSchedule
+		wantErr         bool
+	}{
+		{
+			name:    "default currency",
+			content: "$dividend aapl 12.5 2026-05-15",
+			want:    &dto.Dividend{Symbol: "AAPL", Amount: 12.5, PayDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), Source: dto.DividendSourceManual},
+		},
+		{
+			name:    "with currency",
+			content: "$dividend 0050 300 2026-07-20 twd",
+			want:    &dto.Dividend{Symbol: "0050", Amount: 300, Currency: "TWD", PayDate: time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC), Source: dto.DividendSourceManual},
+		},
+		{
+			name:            "withholding from broker",
+			content:         "$dividend AAPL 12.5 2026-05-15",
+			schedule:        withholding,
+			want:            &dto.Dividend{Symbol: "AAPL", Amount: 12.5, PayDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), Source: dto.DividendSourceManual},
+			wantWithholding: withholding,
+		},
+		{
+			name:     "explicit withholding",
+			content:  "$dividend AAPL 12.5 2026-05-15 tax=1.25 usd",
+			schedule: withholding,
+			want:     &dto.Dividend{Symbol: "AAPL", Amount: 12.5, Tax: 1.25, Currency: "USD", PayDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), Source: dto.DividendSourceManual},
+		},
+		{name: "withholding exceeds amount", content: "$dividend AAPL 1 2026-05-15 tax=2", wantErr: true},
+		{name: "duplicate currency", content: "$dividend AAPL 1 2026-05-15 USD TWD", wantErr: true},
+		{name: "missing date", content: "$dividend AAPL 12.5", wantErr: true},
+		{name: "invalid amount", content: "$dividend AAPL -1 2026-05-15", wantErr: true},
+		{name: "invalid date", content: "$dividend AAPL 1 2026/05/15", wantErr: true},
+		{name: "invalid currency", content: "$dividend AAPL 1 2026-05-15 US", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotWithholding, err := parseDividend(tt.content, tt.schedule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDividend() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDividend() unexpected error = %v", err)
+			}
+			if *got != *tt.want || gotWithholding != tt.wantWithholding {
+				t.Errorf("parseDividend() = %+v, %v, want %+v, %v", *got, gotWithholding, *tt.want, tt.wantWithholding)
+			}
+		})
+	}
+}
+
+func Test_recordDividend(t *testing.T) {
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	trade := tradeAt(1, "0050", dto.StockSideBuy, 1000, 150, 0)
+	trade.UserID = "u1"
+	trade.Currency = "TWD"
+	usTrade := tradeAt(2, "VOO", dto.StockSideBuy, 10, 150, 0)
+	usTrade.UserID = "u1"
+	repo := &MockStockRepository{Stocks: []*dto.Stock{trade, usTrade}}
+
+	twdWithholding := &FeeSchedule{Broker: "fubon", DividendTaxRate: 0.1, Currency: "TWD"}
+
+	tests := []struct {
+		name         string
+		dividend     *dto.Dividend
+		withholding  *FeeSchedule
+		wantCurrency string
+		wantTax      float64
+		wantErr      bool
+	}{
+		{
+			name:         "currency from trades",
+			dividend:     &dto.Dividend{UserID: "u1", Symbol: "0050", Amount: 300, PayDate: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)},
+			wantCurrency: "TWD",
+		},
+		{
+			// 幣別由交易紀錄決定後才計算預扣稅
+			name:         "withholding in broker currency",
+			dividend:     &dto.Dividend{UserID: "u1", Symbol: "0050", Amount: 300, PayDate: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)},
+			withholding:  twdWithholding,
+			wantCurrency: "TWD",
+			wantTax:      30,
+		},
+		{
+			name:         "no withholding for other currency",
+			dividend:     &dto.Dividend{UserID: "u1", Symbol: "VOO", Amount: 12.5, PayDate: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)},
+			withholding:  twdWithholding,
+			wantCurrency: "USD",
+		},
+		{
+			name:     "never traded",
+			dividend: &dto.Dividend{UserID: "u1", Symbol: "AAPL", Amount: 1, PayDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+			wantErr:  true,
+		},
+		{
+			name:     "other user's symbol",
+			dividend: &dto.Dividend{UserID: "u2", Symbol: "0050", Amount: 1, PayDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+			wantErr:  true,
+		},
+		{
+			name:     "future pay date",
+			dividend: &dto.Dividend{UserID: "u1", Symbol: "0050", Amount: 1, PayDate: time.Date(2026, 7, 16, 0, 0, 0, 0, time.UTC)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dividendRepo := &MockDividendRepository{}
+			err := recordDividend(context.Background(), repo, dividendRepo, tt.dividend, tt.withholding)
+			if tt.wantErr {
+				if err == nil || len(dividendRepo.Dividends) != 0 {
+					t.Errorf("recordDividend() error = %v, saved = %d, want error and nothing saved", err, len(dividendRepo.Dividends))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("recordDividend() unexpected error = %v", err)
+			}
+			if len(dividendRepo.Dividends) != 1 || dividendRepo.Dividends[0].Currency != tt.wantCurrency || !floatEqual(dividendRepo.Dividends[0].Tax, tt.wantTax) {
+				t.Errorf("recordDividend() saved = %+v, want currency %s tax %v", dividendRepo.Dividends, tt.wantCurrency, tt.wantTax)
+			}
+		})
+	}
+}
+
+func Test_income(t *testing.T) {
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	dividendRepo := &MockDividendRepository{
+		Dividends: []*dto.Dividend{
+			{UserID: "u1", Symbol: "AAPL", Amount: 2, Currency: "USD", PayDate: date(2025, 11, 13)},
+			{UserID: "u1", Symbol: "AAPL", Amount: 2.5, Currency: "USD", PayDate: date(2026, 2, 12)},
+			{UserID: "u1", Symbol: "MSFT", Amount: 5, Tax: 1, Currency: "USD", PayDate: date(2026, 3, 12)},
+			{UserID: "u1", Symbol: "0050", Amount: 300, Currency: "TWD", PayDate: date(2026, 3, 20)},
+			{UserID: "u2", Symbol: "AAPL", Amount: 99, Currency: "USD", PayDate: date(2026, 2, 12)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+		wantErr bool
+	}{
+		{
+			name:    "current year by month",
+			want:    []string{"2026 年股息收入", "2026-02  2.50 USD", "2026-03  300.00 TWD + 4.00 USD", "合計: 300.00 TWD + 6.50 USD", "已扣預扣稅: 1.00 USD", "AAPL 2.50 USD"},
+			notWant: []string{"2025-11", "99.00"},
+		},
+		{
+			name:    "given year by month",
+			args:    []string{"month", "2025"},
+			want:    []string{"2025-11  2.00 USD", "合計: 2.00 USD"},
+			notWant: []string{"預扣稅"},
+		},
+		{
+			name: "by year",
+			args: []string{"year"},
+			want: []string{"2025     2.00 USD", "2026     300.00 TWD + 6.50 USD", "合計: 300.00 TWD + 8.50 USD"},
+		},
+		{name: "no dividends", args: []string{"month", "2020"}, wantErr: true},
+		{name: "invalid period", args: []string{"week"}, wantErr: true},
+		{name: "invalid year", args: []string{"month", "abc"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := income(context.Background(), dividendRepo, "u1", tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("income() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("income() unexpected error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("income() = %q, want contains %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("income() = %q, want not contains %q", got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func Test_discoverUserDividends(t *testing.T) {
+	at := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 15, 0, 0, 0, time.UTC) }
+	date := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+
+	trades := []*dto.Stock{
+		{UserID: "u1", Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Currency: "USD", TradedAt: at(1, 5)},
+		{UserID: "u1", Symbol: "AAPL", Side: dto.StockSideSell, Units: 4, Currency: "USD", TradedAt: at(5, 8)},
+		// 除息日當天買進不能領息
+		{UserID: "u1", Symbol: "AAPL", Side: dto.StockSideBuy, Units: 100, Currency: "USD", TradedAt: at(5, 11)},
+		{UserID: "u1", Symbol: "MSFT", Side: dto.StockSideBuy, Units: 5, Currency: "USD", TradedAt: at(6, 1)},
+	}
+	events := map[string][]*DividendEvent{
+		"AAPL": {
+			{Symbol: "AAPL", ExDate: "2026-05-11", PayDate: "2026-05-14", Amount: 0.25, Currency: "USD"},
+			// 已有手動紀錄，不覆蓋
+			{Symbol: "AAPL", ExDate: "2026-02-09", PayDate: "2026-02-12", Amount: 0.25},
+		},
+		"MSFT": {
+			// 尚未發放
+			{Symbol: "MSFT", ExDate: "2026-07-10", PayDate: "2026-08-13", Amount: 0.8},
+			// 買進前除息
+			{Symbol: "MSFT", ExDate: "2026-05-20", PayDate: "2026-06-11", Amount: 0.8},
+		},
+	}
+
+	repo := &MockStockRepository{Stocks: trades}
+	manual := &dto.Dividend{UserID: "u1", Symbol: "AAPL", Amount: 2.4, Currency: "USD", PayDate: date(2, 12), Source: dto.DividendSourceManual}
+	dividendRepo := &MockDividendRepository{Dividends: []*dto.Dividend{manual}}
+
+	fetched := 0
+	fetch := func(symbol string) ([]*DividendEvent, error) {
+		fetched++
+		return events[symbol], nil
+	}
+
+	withholding := &FeeSchedule{Broker: "firstrade", DividendTaxRate: 0.3}
+	inserted, err := discoverUserDividends(context.Background(), repo, dividendRepo, fetch, "u1", date(7, 15), withholding)
+	if err != nil {
+		t.Fatalf("discoverUserDividends() unexpected error = %v", err)
+	}
+	if inserted != 1 || fetched != 2 {
+		t.Fatalf("discoverUserDividends() inserted = %d, fetched = %d, want 1, 2", inserted, fetched)
+	}
+
+	got := dividendRepo.Dividends[1]
+	if got.Symbol != "AAPL" || !floatEqual(got.Amount, 1.5) || !floatEqual(got.Tax, 0.45) || !got.PayDate.Equal(date(5, 14)) || got.Source != dto.DividendSourceAuto || got.Currency != "USD" {
+		t.Errorf("discoverUserDividends() dividend = %+v", *got)
+	}
+	if dividendRepo.Dividends[0] != manual || manual.Amount != 2.4 {
+		t.Errorf("discoverUserDividends() overwrote manual dividend: %+v", *dividendRepo.Dividends[0])
+	}
+
+	// 再次執行不重複登記
+	inserted, err = discoverUserDividends(context.Background(), repo, dividendRepo, fetch, "u1", date(7, 15), withholding)
+	if err != nil || inserted != 0 {
+		t.Errorf("discoverUserDividends() second run = %d, %v, want 0, nil", inserted, err)
+	}
+
+	// 台幣券商費率不預扣美元股息
+	twdRepo := &MockDividendRepository{}
+	twdWithholding := &FeeSchedule{Broker: "fubon", DividendTaxRate: 0.1, Currency: "TWD"}
+	if inserted, err := discoverUserDividends(context.Background(), repo, twdRepo, fetch, "u1", date(7, 15), twdWithholding); err != nil || inserted != 2 {
+		t.Fatalf("discoverUserDividends() TWD schedule = %d, %v, want 2, nil", inserted, err)
+	}
+	for _, dividend := range twdRepo.Dividends {
+		if dividend.Tax != 0 {
+			t.Errorf("discoverUserDividends() TWD schedule withheld %+v", *dividend)
+		}
+	}
+}
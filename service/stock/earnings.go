@@ -0,0 +1,357 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const earningsDateLayout = "2006-01-02"
+
+// EarningsPreview : 發送未來一週財報預告
+func EarningsPreview(s *discordgo.Session) {
+	EarningsPreviewWithDeps(s, stockDaoDeps{}, redisDeps{})
+}
+
+// EarningsPreviewWithDeps 使用指定依賴發送財報預告（用於測試）
+func EarningsPreviewWithDeps(s *discordgo.Session, repo StockRepository, redisClient RedisClient) {
+	taskConfig := config.GetTaskConfig()
+	ctx, cancel, externalTimeout := newEarningsTaskContext(taskConfig)
+	defer cancel()
+
+	today := time.Now().In(earningsLocation())
+	from := today.Format(earningsDateLayout)
+	to := today.AddDate(0, 0, 6).Format(earningsDateLayout)
+
+	events, err := loadEarnings(ctx, externalTimeout, repo, redisClient, from, to)
+	if err != nil {
+		logger.Error("取得財報行事曆失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"earnings:preview:load",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得財報行事曆時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	if len(events) == 0 {
+		logger.Info("本週無持倉或觀察標的財報", "from", from, "to", to)
+		return
+	}
+
+	if err := discord.SendMessage(s, &discord.SendMessageInput{
+		ChannelID: taskConfig.WatchListChannelID,
+		Content:   formatEarningsPreview(from, to, events),
+	}); err != nil {
+		logger.Error("發送財報預告失敗", "error", err)
+		return
+	}
+
+	logger.Info("財報預告發送成功", "count", len(events))
+}
+
+// EarningsReminder : 發送明日財報提醒
+func EarningsReminder(s *discordgo.Session) {
+	EarningsReminderWithDeps(s, stockDaoDeps{}, redisDeps{})
+}
+
+// EarningsReminderWithDeps 使用指定依賴發送明日財報提醒（用於測試）
+func EarningsReminderWithDeps(s *discordgo.Session, repo StockRepository, redisClient RedisClient) {
+	taskConfig := config.GetTaskConfig()
+	ctx, cancel, externalTimeout := newEarningsTaskContext(taskConfig)
+	defer cancel()
+
+	// 收盤後提醒下一個交易日（週五提醒下週一，遇假日順延）
+	tomorrow := usExchange().NextTradingDay(time.Now()).Format(earningsDateLayout)
+
+	events, err := loadEarnings(ctx, externalTimeout, repo, redisClient, tomorrow, tomorrow)
+	if err != nil {
+		logger.Error("取得財報行事曆失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"earnings:reminder:load",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得財報行事曆時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	if len(events) == 0 {
+		logger.Info("明日無持倉或觀察標的財報", "date", tomorrow)
+		return
+	}
+
+	_, err = s.ChannelMessageSendComplex(taskConfig.WatchListChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s> %s", taskConfig.DefaultUserID, formatEarningsReminder(tomorrow, events)),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+		},
+	})
+	if err != nil {
+		logger.Error("發送財報提醒失敗", "error", err)
+		return
+	}
+
+	logger.Info("財報提醒發送成功", "count", len(events))
+}
+
+// EarningsResults : 發送已公布財報的 EPS 實際值與預估值比較
+func EarningsResults(s *discordgo.Session) {
+	EarningsResultsWithDeps(s, stockDaoDeps{}, redisDeps{})
+}
+
+// EarningsResultsWithDeps 使用指定依賴發送財報結果（用於測試）
+func EarningsResultsWithDeps(s *discordgo.Session, repo StockRepository, redisClient RedisClient) {
+	taskConfig := config.GetTaskConfig()
+	ctx, cancel, externalTimeout := newEarningsTaskContext(taskConfig)
+	defer cancel()
+
+	// 從上一個交易日起算，涵蓋前一交易日盤後（含週五盤後）公布的財報
+	now := time.Now()
+	from := usExchange().PrevTradingDay(now).Format(earningsDateLayout)
+	to := now.In(earningsLocation()).Format(earningsDateLayout)
+
+	events, err := loadEarnings(ctx, externalTimeout, repo, redisClient, from, to)
+	if err != nil {
+		logger.Error("取得財報行事曆失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"earnings:results:load",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得財報行事曆時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	for _, event := range events {
+		if event.EpsActual == nil {
+			continue
+		}
+
+		// 先看是否已通知過
+		recordKey := "earnings:" + event.Symbol + ":" + event.Date
+		getCtx, getCancel := context.WithTimeout(ctx, externalTimeout)
+		record, err := redisClient.Get(getCtx, recordKey)
+		getCancel()
+		if err != nil {
+			logger.Error("取得財報通知紀錄失敗", "symbol", event.Symbol, "error", err)
+			continue
+		}
+		if record != "" {
+			continue
+		}
+
+		_, err = s.ChannelMessageSendComplex(taskConfig.WatchListChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("<@%s> %s", taskConfig.DefaultUserID, formatEarningsResult(event)),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+			},
+		})
+		if err != nil {
+			logger.Error("發送財報結果失敗", "symbol", event.Symbol, "error", err)
+			continue
+		}
+
+		// 寫入紀錄已通知
+		setCtx, setCancel := context.WithTimeout(ctx, externalTimeout)
+		err = redisClient.Set(setCtx, recordKey, "true", 72*time.Hour)
+		setCancel()
+		if err != nil {
+			logger.Error("寫入財報通知紀錄失敗", "symbol", event.Symbol, "error", err)
+		}
+	}
+
+	logger.Info("完成財報結果檢查", "count", len(events))
+}
+
+func newEarningsTaskContext(taskConfig *config.TaskConfig) (context.Context, context.CancelFunc, time.Duration) {
+	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
+
+	runTimeout := durationFromSeconds(taskConfig.CheckChangeTimeoutSeconds, 2*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+
+	return ctx, cancel, durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+}
+
+// loadEarnings 取得持倉與觀察清單標的在指定日期區間內的財報
+func loadEarnings(ctx context.Context, externalTimeout time.Duration, repo StockRepository, redisClient RedisClient, from, to string) ([]*EarningsEvent, error) {
+	usersCtx, usersCancel := context.WithTimeout(ctx, externalTimeout)
+	userIDs, err := repo.GetUserIDs(usersCtx)
+	usersCancel()
+	if err != nil {
+		return nil, fmt.Errorf("取得使用者清單錯誤: %w", err)
+	}
+
+	// 交易紀錄只能依使用者查詢，逐一取得後再推導持倉
+	var trades []*dto.Stock
+	for _, userID := range userIDs {
+		tradesCtx, tradesCancel := context.WithTimeout(ctx, externalTimeout)
+		userTrades, err := repo.Get(tradesCtx, &stockdao.GetInput{UserID: userID})
+		tradesCancel()
+		if err != nil {
+			return nil, fmt.Errorf("取得 <@%s> 持倉標的錯誤: %w", userID, err)
+		}
+		trades = append(trades, userTrades...)
+	}
+	held := heldSymbols(trades)
+
+	watchCtx, watchCancel := context.WithTimeout(ctx, externalTimeout)
+	watched, err := allWatchSymbols(watchCtx, redisClient)
+	watchCancel()
+	if err != nil {
+		return nil, fmt.Errorf("取得觀察列表錯誤: %w", err)
+	}
+
+	symbols := collectEarningsSymbols(held, watched)
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	calendarCtx, calendarCancel := context.WithTimeout(ctx, externalTimeout)
+	events, err := GetClient("finnhub").GetEarningsCalendar(calendarCtx, from, to)
+	calendarCancel()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterEarnings(events, symbols), nil
+}
+
+// heldSymbols 各使用者仍有持股的標的（與 $position 相同的批次配對，已全數賣出的標的不列入）
+func heldSymbols(trades []*dto.Stock) []string {
+	byUser := make(map[string][]*dto.Stock)
+	for _, trade := range trades {
+		if trade != nil {
+			byUser[trade.UserID] = append(byUser[trade.UserID], trade)
+		}
+	}
+
+	var symbols []string
+	for userID, userTrades := range byUser {
+		positions, err := MatchLots(userTrades, CostMethodAverage)
+		if err != nil {
+			logger.Warn("推導持倉失敗，略過該使用者的財報標的", "userID", userID, "error", err)
+			continue
+		}
+		for _, position := range OpenPositions(positions) {
+			symbols = append(symbols, position.Symbol)
+		}
+	}
+	return symbols
+}
+
+// collectEarningsSymbols 合併持倉與觀察清單標的（轉大寫並去重）
+func collectEarningsSymbols(lists ...[]string) map[string]struct{} {
+	symbols := make(map[string]struct{})
+	for _, list := range lists {
+		for _, symbol := range list {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			if symbol == "" {
+				continue
+			}
+			symbols[symbol] = struct{}{}
+		}
+	}
+	return symbols
+}
+
+// filterEarnings 只保留指定標的的財報，並依日期與標的排序
+func filterEarnings(events []*EarningsEvent, symbols map[string]struct{}) []*EarningsEvent {
+	var ret []*EarningsEvent
+	for _, e := range events {
+		if e == nil {
+			continue
+		}
+		if _, ok := symbols[strings.ToUpper(e.Symbol)]; ok {
+			ret = append(ret, e)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Date != ret[j].Date {
+			return ret[i].Date < ret[j].Date
+		}
+		return ret[i].Symbol < ret[j].Symbol
+	})
+
+	return ret
+}
+
+func formatEarningsPreview(from, to string, events []*EarningsEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "本週財報預告 (%s ~ %s)", from, to)
+	for _, e := range events {
+		fmt.Fprintf(&b, "\n%s %s %s EPS 預估: %s", e.Date, e.Symbol, earningsHourLabel(e.Hour), formatEps(e.EpsEstimate))
+	}
+	return b.String()
+}
+
+func formatEarningsReminder(date string, events []*EarningsEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "明日 (%s) 財報提醒:", date)
+	for _, e := range events {
+		fmt.Fprintf(&b, "\n%s %s EPS 預估: %s", e.Symbol, earningsHourLabel(e.Hour), formatEps(e.EpsEstimate))
+	}
+	return b.String()
+}
+
+func formatEarningsResult(e *EarningsEvent) string {
+	res := fmt.Sprintf("%s %dQ%d 財報公布: EPS 實際 %s / 預估 %s", e.Symbol, e.Year, e.Quarter, formatEps(e.EpsActual), formatEps(e.EpsEstimate))
+
+	if e.EpsActual == nil || e.EpsEstimate == nil || *e.EpsEstimate == 0 {
+		return res
+	}
+
+	surprise := float64(*e.EpsActual-*e.EpsEstimate) / math.Abs(float64(*e.EpsEstimate)) * 100
+	switch {
+	case surprise > 0:
+		return res + fmt.Sprintf(" (優於預期 %.2f %%)", surprise)
+	case surprise < 0:
+		return res + fmt.Sprintf(" (低於預期 %.2f %%)", -surprise)
+	default:
+		return res + " (符合預期)"
+	}
+}
+
+func earningsHourLabel(hour string) string {
+	switch hour {
+	case "bmo":
+		return "盤前"
+	case "amc":
+		return "盤後"
+	case "dmh":
+		return "盤中"
+	default:
+		return "時間未定"
+	}
+}
+
+func formatEps(eps *float32) string {
+	if eps == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.2f", *eps)
+}
+
+// earningsLocation 財報日期以美東時間為準
+func earningsLocation() *time.Location {
+	return usExchange().Location
+}
@@ -0,0 +1,185 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func float32Ptr(v float32) *float32 {
+	return &v
+}
+
+func Test_loadEarnings(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		repo        *MockStockRepository
+		watchList   []string
+		mockSetup   func(*MockFinnhubClient)
+		wantSymbols []string
+		wantErr     bool
+	}{
+		{
+			name: "held and watched symbols are merged",
+			// 交易紀錄依使用者逐一查詢
+			repo: &MockStockRepository{Stocks: []*dto.Stock{
+				userTrade("u1", tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 0)),
+				// 已全數賣出的標的不提醒
+				userTrade("u2", tradeAt(2, "MSFT", dto.StockSideBuy, 5, 300, 0)),
+				userTrade("u2", tradeAt(3, "MSFT", dto.StockSideSell, 5, 320, 0)),
+			}},
+			watchList: []string{"aapl", "TSLA"},
+			mockSetup: func(m *MockFinnhubClient) {
+				m.Earnings = []*EarningsEvent{
+					{Symbol: "TSLA", Date: "2026-10-21"},
+					{Symbol: "MSFT", Date: "2026-10-21"},
+					{Symbol: "AAPL", Date: "2026-10-20"},
+				}
+			},
+			wantSymbols: []string{"AAPL", "TSLA"},
+		},
+		{
+			name:        "no symbols skips calendar",
+			repo:        &MockStockRepository{},
+			mockSetup:   func(m *MockFinnhubClient) { m.Err = errors.New("should not be called") },
+			wantSymbols: nil,
+		},
+		{
+			name:    "repository error",
+			repo:    &MockStockRepository{Err: errors.New("db down")},
+			wantErr: true,
+		},
+		{
+			name:      "calendar error",
+			repo:      &MockStockRepository{Stocks: []*dto.Stock{userTrade("u1", tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 0))}},
+			mockSetup: func(m *MockFinnhubClient) { m.Err = errors.New("rate limit") },
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockFinnhubClient()
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+			SetDefaultClient(mock)
+			defer ResetDefaultClient()
+
+			mockRedis := NewMockRedisClient()
+			mockRedis.Lists["watch_list"] = tt.watchList
+
+			got, err := loadEarnings(ctx, time.Second, tt.repo, mockRedis, "2026-10-19", "2026-10-25")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("loadEarnings() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("loadEarnings() unexpected error = %v", err)
+				return
+			}
+
+			if len(got) != len(tt.wantSymbols) {
+				t.Errorf("loadEarnings() got %d events, want %d", len(got), len(tt.wantSymbols))
+				return
+			}
+			for i, e := range got {
+				if e.Symbol != tt.wantSymbols[i] {
+					t.Errorf("loadEarnings()[%d] = %v, want %v", i, e.Symbol, tt.wantSymbols[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_formatEarningsResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *EarningsEvent
+		want  string
+	}{
+		{
+			name:  "beat estimate",
+			event: &EarningsEvent{Symbol: "TSLA", Year: 2026, Quarter: 3, EpsEstimate: float32Ptr(0.5), EpsActual: float32Ptr(0.6)},
+			want:  "TSLA 2026Q3 財報公布: EPS 實際 0.60 / 預估 0.50 (優於預期 20.00 %)",
+		},
+		{
+			name:  "miss estimate",
+			event: &EarningsEvent{Symbol: "AAPL", Year: 2026, Quarter: 4, EpsEstimate: float32Ptr(2), EpsActual: float32Ptr(1.5)},
+			want:  "AAPL 2026Q4 財報公布: EPS 實際 1.50 / 預估 2.00 (低於預期 25.00 %)",
+		},
+		{
+			name:  "negative estimate beaten",
+			event: &EarningsEvent{Symbol: "RIVN", Year: 2026, Quarter: 3, EpsEstimate: float32Ptr(-1), EpsActual: float32Ptr(-0.5)},
+			want:  "RIVN 2026Q3 財報公布: EPS 實際 -0.50 / 預估 -1.00 (優於預期 50.00 %)",
+		},
+		{
+			name:  "no estimate",
+			event: &EarningsEvent{Symbol: "TSLA", Year: 2026, Quarter: 3, EpsActual: float32Ptr(0.6)},
+			want:  "TSLA 2026Q3 財報公布: EPS 實際 0.60 / 預估 N/A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatEarningsResult(tt.event); got != tt.want {
+				t.Errorf("formatEarningsResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatEarningsPreview(t *testing.T) {
+	events := []*EarningsEvent{
+		{Symbol: "AAPL", Date: "2026-10-20", Hour: "amc", EpsEstimate: float32Ptr(1.25)},
+		{Symbol: "TSLA", Date: "2026-10-21", Hour: "bmo"},
+	}
+
+	want := "本週財報預告 (2026-10-19 ~ 2026-10-25)\n" +
+		"2026-10-20 AAPL 盤後 EPS 預估: 1.25\n" +
+		"2026-10-21 TSLA 盤前 EPS 預估: N/A"
+
+	if got := formatEarningsPreview("2026-10-19", "2026-10-25", events); got != want {
+		t.Errorf("formatEarningsPreview() = %v, want %v", got, want)
+	}
+}
+
+// userTrade 設定交易紀錄的使用者
+func userTrade(userID string, trade *dto.Stock) *dto.Stock {
+	trade.UserID = userID
+	return trade
+}
+
+func Test_heldSymbols(t *testing.T) {
+	trades := []*dto.Stock{
+		tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 0),
+		tradeAt(2, "TSLA", dto.StockSideSell, 10, 120, 0),
+		tradeAt(3, "NVDA", dto.StockSideBuy, 2, 100, 0),
+		// 其他使用者仍持有 TSLA
+		{ID: 4, UserID: "u2", Symbol: "TSLA", Side: dto.StockSideBuy, Units: 1, Price: 100, Currency: "USD", TradedAt: time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		// 賣超的使用者資料有誤，略過
+		{ID: 5, UserID: "u3", Symbol: "AMD", Side: dto.StockSideSell, Units: 1, Price: 100, Currency: "USD", TradedAt: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got := collectEarningsSymbols(heldSymbols(trades))
+	if len(got) != 2 {
+		t.Errorf("heldSymbols() = %v, want NVDA and TSLA", got)
+	}
+	for _, symbol := range []string{"NVDA", "TSLA"} {
+		if _, ok := got[symbol]; !ok {
+			t.Errorf("heldSymbols() missing %s", symbol)
+		}
+	}
+
+	trades = trades[:3]
+	if got := heldSymbols(trades); len(got) != 1 || got[0] != "NVDA" {
+		t.Errorf("heldSymbols() = %v, want [NVDA]", got)
+	}
+}
@@ -0,0 +1,415 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/model/dao/stock"
+	"discordBot/model/dao/stockhistory"
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+)
+
+// pendingStockTTL 待確認異動的有效時間
+const pendingStockTTL = 5 * time.Minute
+
+// pendingStockChange 待確認的交易紀錄異動（修改/刪除單筆、套用股票分割，或匯入 CSV）
+type pendingStockChange struct {
+	Action string     `json:"action"`
+	Before *dto.Stock `json:"before,omitempty"`
+	After  *dto.Stock `json:"after,omitempty"`
+	// 股票分割，確認時重新計算受影響的交易紀錄
+	Split *dto.CorporateAction `json:"split,omitempty"`
+	// 匯入的交易紀錄，確認時於同一個 transaction 新增
+	Import []*dto.Stock `json:"import,omitempty"`
+}
+
+// pendingStockKey 使用者待確認異動的 Redis key
+func pendingStockKey(userID string) string {
+	return "pending_stock:" + userID
+}
+
+// EditStock : 預覽修改交易紀錄，需 $confirm 確認後才會寫入
+func EditStock(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $edit_stock 12 units=10 price=100.5 fee=1 tax=0 broker=sinopac currency=USD side=buy date=2026-01-02
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) < 3 {
+		return "", fmt.Errorf("參數錯誤，格式: $edit_stock <id> <欄位>=<值> ...（欄位: units, price, fee, tax, broker, currency, side, date）")
+	}
+
+	before, err := getOwnTrade(ctx, m.Author.ID, strSlice[1])
+	if err != nil {
+		return "", err
+	}
+
+	after, err := applyStockEdits(before, strSlice[2:])
+	if err != nil {
+		return "", err
+	}
+
+	change := &pendingStockChange{Action: dto.StockHistoryActionUpdate, Before: before, After: after}
+	if err := savePendingStockChange(ctx, m.Author.ID, change); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("即將修改交易紀錄:\n修改前: %s\n修改後: %s\n輸入 $confirm 確認或 $cancel 取消（%v 內有效）",
+		formatTrade(before), formatTrade(after), pendingStockTTL), nil
+}
+
+// DelStock : 預覽刪除交易紀錄，需 $confirm 確認後才會刪除
+func DelStock(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $del_stock 12
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) != 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $del_stock <id>")
+	}
+
+	before, err := getOwnTrade(ctx, m.Author.ID, strSlice[1])
+	if err != nil {
+		return "", err
+	}
+
+	change := &pendingStockChange{Action: dto.StockHistoryActionDelete, Before: before}
+	if err := savePendingStockChange(ctx, m.Author.ID, change); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("即將刪除交易紀錄:\n%s\n輸入 $confirm 確認或 $cancel 取消（%v 內有效）",
+		formatTrade(before), pendingStockTTL), nil
+}
+
+// ConfirmStockChange : 確認並寫入待確認的交易紀錄異動，同時保留異動歷程
+func ConfirmStockChange(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $confirm
+	userID := m.Author.ID
+	redisClient := redisDeps{}
+
+	raw, err := redisClient.Get(ctx, pendingStockKey(userID))
+	if err != nil {
+		return "", err
+	}
+	if raw == "" {
+		return "", fmt.Errorf("沒有待確認的交易紀錄異動（或已逾時）")
+	}
+
+	change := &pendingStockChange{}
+	if err := json.Unmarshal([]byte(raw), change); err != nil {
+		return "", fmt.Errorf("待確認資料格式錯誤: %v", err)
+	}
+
+	if change.Split != nil {
+		return confirmSplit(ctx, redisClient, change.Split)
+	}
+	if len(change.Import) > 0 {
+		return confirmImport(ctx, redisClient, userID, change.Import)
+	}
+	if change.Before == nil {
+		return "", fmt.Errorf("待確認資料格式錯誤: 缺少交易紀錄")
+	}
+
+	// 確認預覽後資料未被其他操作變更
+	current, err := stock.GetByID(ctx, userID, change.Before.ID)
+	if err != nil {
+		return "", err
+	}
+	if current == nil || !sameTrade(current, change.Before) {
+		_ = redisClient.Del(ctx, pendingStockKey(userID))
+		return "", fmt.Errorf("交易紀錄 #%d 已變更或不存在，請重新操作", change.Before.ID)
+	}
+
+	if err := validateStockChange(ctx, userID, change); err != nil {
+		return "", err
+	}
+
+	if err := commitStockChange(ctx, userID, change); err != nil {
+		return "", err
+	}
+
+	if err := redisClient.Del(ctx, pendingStockKey(userID)); err != nil {
+		return "", err
+	}
+
+	if change.Action == dto.StockHistoryActionDelete {
+		return fmt.Sprintf("已刪除交易紀錄: %s", formatTrade(change.Before)), nil
+	}
+	return fmt.Sprintf("已修改交易紀錄: %s", formatTrade(change.After)), nil
+}
+
+// CancelStockChange : 取消待確認的交易紀錄異動
+func CancelStockChange(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $cancel
+	redisClient := redisDeps{}
+
+	raw, err := redisClient.Get(ctx, pendingStockKey(m.Author.ID))
+	if err != nil {
+		return "", err
+	}
+	if raw == "" {
+		return "", fmt.Errorf("沒有待確認的交易紀錄異動")
+	}
+
+	if err := redisClient.Del(ctx, pendingStockKey(m.Author.ID)); err != nil {
+		return "", err
+	}
+
+	return "已取消", nil
+}
+
+// getOwnTrade 取得屬於使用者的交易紀錄
+func getOwnTrade(ctx context.Context, userID string, idStr string) (*dto.Stock, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(idStr, "#"), 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("無效的交易紀錄編號: %s", idStr)
+	}
+
+	trade, err := stock.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if trade == nil {
+		return nil, fmt.Errorf("找不到交易紀錄 #%d", id)
+	}
+
+	return trade, nil
+}
+
+// savePendingStockChange 檢查異動後交易紀錄仍然合理，並暫存待確認
+func savePendingStockChange(ctx context.Context, userID string, change *pendingStockChange) error {
+	if err := validateStockChange(ctx, userID, change); err != nil {
+		return err
+	}
+
+	return storePendingStockChange(ctx, redisDeps{}, userID, change)
+}
+
+// label 異動類型的顯示名稱
+func (c *pendingStockChange) label() string {
+	switch c.Action {
+	case dto.StockHistoryActionUpdate:
+		return "修改交易紀錄"
+	case dto.StockHistoryActionDelete:
+		return "刪除交易紀錄"
+	case dto.CorporateActionSplit:
+		return "股票分割"
+	case pendingActionImport:
+		return "匯入交易紀錄"
+	default:
+		return c.Action
+	}
+}
+
+// storePendingStockChange 暫存待確認異動；使用者已有未確認的異動時拒絕，避免 $confirm 套用到不是最後預覽的異動
+func storePendingStockChange(ctx context.Context, redisClient RedisClient, userID string, change *pendingStockChange) error {
+	raw, err := redisClient.Get(ctx, pendingStockKey(userID))
+	if err != nil {
+		return err
+	}
+	if raw != "" {
+		pending := &pendingStockChange{}
+		if err := json.Unmarshal([]byte(raw), pending); err != nil {
+			return fmt.Errorf("已有待確認的異動，請先 $confirm 確認或 $cancel 取消")
+		}
+		return fmt.Errorf("已有待確認的%s，請先 $confirm 確認或 $cancel 取消", pending.label())
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	return redisClient.Set(ctx, pendingStockKey(userID), string(data), pendingStockTTL)
+}
+
+// validateStockChange 確認異動後的交易紀錄不會出現賣超
+func validateStockChange(ctx context.Context, userID string, change *pendingStockChange) error {
+	trades, err := stock.Get(
+		ctx,
+		&stock.GetInput{
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := MatchLots(applyStockChange(trades, change), CostMethodAverage); err != nil {
+		return fmt.Errorf("異動後交易紀錄不一致: %w", err)
+	}
+
+	return nil
+}
+
+// commitStockChange 於同一個 transaction 中寫入異動與異動歷程
+func commitStockChange(ctx context.Context, userID string, change *pendingStockChange) (err error) {
+	dbM, err := postgresql.GetConn()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	tx, err := dbM.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("開始 transaction 錯誤: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var affected int64
+	if change.Action == dto.StockHistoryActionDelete {
+		affected, err = stock.Del(ctx, tx, userID, change.Before.ID)
+	} else {
+		affected, err = stock.Upd(ctx, tx, change.After)
+	}
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("找不到交易紀錄 #%d", change.Before.ID)
+	}
+
+	if err = stockhistory.Ins(ctx, tx, &dto.StockHistory{
+		StockID: change.Before.ID,
+		UserID:  userID,
+		Action:  change.Action,
+		Before:  change.Before,
+		After:   change.After,
+	}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit 錯誤: %v", err)
+	}
+
+	return nil
+}
+
+// applyStockEdits 依 <欄位>=<值> 參數產生修改後的交易紀錄，不修改原始資料
+func applyStockEdits(before *dto.Stock, args []string) (*dto.Stock, error) {
+	after := *before
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("無效的參數: %s，格式: <欄位>=<值>", arg)
+		}
+
+		switch strings.ToLower(key) {
+		case "units":
+			units, err := strconv.ParseFloat(value, 64)
+			if err != nil || units <= 0 {
+				return nil, fmt.Errorf("無效的數量: %s", value)
+			}
+			after.Units = units
+		case "price":
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil || price < 0 {
+				return nil, fmt.Errorf("無效的價格: %s", value)
+			}
+			after.Price = price
+		case "fee":
+			fee, err := strconv.ParseFloat(value, 64)
+			if err != nil || fee < 0 {
+				return nil, fmt.Errorf("無效的手續費: %s", value)
+			}
+			after.Fee = fee
+		case "tax":
+			tax, err := strconv.ParseFloat(value, 64)
+			if err != nil || tax < 0 {
+				return nil, fmt.Errorf("無效的交易稅: %s", value)
+			}
+			after.Tax = tax
+		case "broker":
+			after.Broker = strings.ToLower(value)
+		case "currency":
+			currency := strings.ToUpper(value)
+			if len(currency) != 3 {
+				return nil, fmt.Errorf("無效的幣別: %s", value)
+			}
+			after.Currency = currency
+		case "side":
+			side := strings.ToLower(value)
+			if side != dto.StockSideBuy && side != dto.StockSideSell {
+				return nil, fmt.Errorf("無效的交易方向: %s", value)
+			}
+			after.Side = side
+		case "date":
+			date, err := time.ParseInLocation("2006-01-02", value, before.TradedAt.Location())
+			if err != nil {
+				return nil, fmt.Errorf("無效的日期: %s，格式: YYYY-MM-DD", value)
+			}
+			// 保留原本的時間，只調整日期
+			h, mi, sec := before.TradedAt.Clock()
+			after.TradedAt = time.Date(date.Year(), date.Month(), date.Day(), h, mi, sec, before.TradedAt.Nanosecond(), date.Location())
+		default:
+			return nil, fmt.Errorf("不支援的欄位: %s", key)
+		}
+	}
+
+	if sameTrade(before, &after) {
+		return nil, fmt.Errorf("沒有任何變更")
+	}
+
+	return &after, nil
+}
+
+// applyStockChange 回傳套用異動後的交易紀錄，不修改原始 slice
+func applyStockChange(trades []*dto.Stock, change *pendingStockChange) []*dto.Stock {
+	ret := make([]*dto.Stock, 0, len(trades))
+	for _, trade := range trades {
+		if trade.ID != change.Before.ID {
+			ret = append(ret, trade)
+			continue
+		}
+		if change.Action == dto.StockHistoryActionUpdate && change.After != nil {
+			ret = append(ret, change.After)
+		}
+	}
+	return ret
+}
+
+// sameTrade 比較兩筆交易紀錄內容是否相同
+func sameTrade(a, b *dto.Stock) bool {
+	return a.ID == b.ID &&
+		a.UserID == b.UserID &&
+		a.Symbol == b.Symbol &&
+		a.Side == b.Side &&
+		a.Units == b.Units &&
+		a.Price == b.Price &&
+		a.Fee == b.Fee &&
+		a.Tax == b.Tax &&
+		a.Broker == b.Broker &&
+		a.Currency == b.Currency &&
+		a.TradedAt.Equal(b.TradedAt)
+}
+
+// formatTrade 交易紀錄顯示格式
+func formatTrade(trade *dto.Stock) string {
+	side := "買進"
+	if trade.Side == dto.StockSideSell {
+		side = "賣出"
+	}
+
+	return fmt.Sprintf("#%d %s %s %s %v 股 @ %.2f %s (%s)",
+		trade.ID, trade.TradedAt.Format("2006-01-02"), side, trade.Symbol, trade.Units, trade.Price, trade.Currency, FormatTradeCosts(trade))
+}
+
+// FormatTradeCosts 交易成本顯示格式，例如 "手續費 20.00, 稅 1800.00, sinopac"
+func FormatTradeCosts(trade *dto.Stock) string {
+	text := fmt.Sprintf("手續費 %.2f", trade.Fee)
+	if trade.Tax > 0 {
+		text += fmt.Sprintf(", 稅 %.2f", trade.Tax)
+	}
+	if trade.Broker != "" {
+		text += ", " + trade.Broker
+	}
+	return text
+}
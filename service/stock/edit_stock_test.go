@@ -0,0 +1,147 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_applyStockEdits(t *testing.T) {
+	before := tradeAt(5, "TSLA", dto.StockSideBuy, 10, 100, 1)
+	before.TradedAt = time.Date(2026, 1, 5, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(*dto.Stock) bool
+		wantErr bool
+	}{
+		{
+			name:  "units and price",
+			args:  []string{"units=12", "price=95.5"},
+			check: func(s *dto.Stock) bool { return s.Units == 12 && s.Price == 95.5 && s.Fee == 1 },
+		},
+		{
+			name:  "side currency and fee",
+			args:  []string{"side=SELL", "currency=twd", "fee=0"},
+			check: func(s *dto.Stock) bool { return s.Side == dto.StockSideSell && s.Currency == "TWD" && s.Fee == 0 },
+		},
+		{
+			name: "date keeps time of day",
+			args: []string{"date=2026-01-02"},
+			check: func(s *dto.Stock) bool {
+				return s.TradedAt.Equal(time.Date(2026, 1, 2, 14, 30, 0, 0, time.UTC))
+			},
+		},
+		{name: "no change", args: []string{"units=10"}, wantErr: true},
+		{name: "unknown field", args: []string{"symbol=AAPL"}, wantErr: true},
+		{name: "invalid units", args: []string{"units=-1"}, wantErr: true},
+		{name: "invalid side", args: []string{"side=short"}, wantErr: true},
+		{name: "missing value", args: []string{"price"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyStockEdits(before, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("applyStockEdits() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyStockEdits() unexpected error = %v", err)
+			}
+			if !tt.check(got) {
+				t.Errorf("applyStockEdits() = %+v", *got)
+			}
+			if before.Units != 10 || before.Price != 100 || before.Side != dto.StockSideBuy {
+				t.Errorf("applyStockEdits() modified original trade: %+v", *before)
+			}
+		})
+	}
+}
+
+func Test_applyStockChange(t *testing.T) {
+	trades := []*dto.Stock{
+		tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 0),
+		tradeAt(2, "TSLA", dto.StockSideSell, 5, 120, 0),
+	}
+
+	edited := *trades[0]
+	edited.Units = 4
+
+	tests := []struct {
+		name      string
+		change    *pendingStockChange
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "update within holdings",
+			change:    &pendingStockChange{Action: dto.StockHistoryActionUpdate, Before: trades[1], After: func() *dto.Stock { s := *trades[1]; s.Units = 10; return &s }()},
+			wantCount: 2,
+		},
+		{
+			name:      "update causes oversell",
+			change:    &pendingStockChange{Action: dto.StockHistoryActionUpdate, Before: trades[0], After: &edited},
+			wantCount: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "delete sell",
+			change:    &pendingStockChange{Action: dto.StockHistoryActionDelete, Before: trades[1]},
+			wantCount: 1,
+		},
+		{
+			name:      "delete buy leaves sell without holdings",
+			change:    &pendingStockChange{Action: dto.StockHistoryActionDelete, Before: trades[0]},
+			wantCount: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyStockChange(trades, tt.change)
+			if len(got) != tt.wantCount {
+				t.Fatalf("applyStockChange() = %d trades, want %d", len(got), tt.wantCount)
+			}
+			if _, err := MatchLots(got, CostMethodAverage); (err != nil) != tt.wantErr {
+				t.Errorf("MatchLots() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if len(trades) != 2 || trades[0].Units != 10 {
+		t.Errorf("applyStockChange() modified original trades")
+	}
+}
+
+func Test_storePendingStockChange(t *testing.T) {
+	ctx := context.Background()
+	redisClient := NewMockRedisClient()
+
+	edit := &pendingStockChange{Action: dto.StockHistoryActionUpdate, Before: &dto.Stock{ID: 1}}
+	if err := storePendingStockChange(ctx, redisClient, "u1", edit); err != nil {
+		t.Fatalf("storePendingStockChange() unexpected error = %v", err)
+	}
+
+	// 已有待確認的修改時，分割或匯入不可覆蓋
+	split := &pendingStockChange{Action: dto.CorporateActionSplit, Split: &dto.CorporateAction{Symbol: "NVDA"}}
+	err := storePendingStockChange(ctx, redisClient, "u1", split)
+	if err == nil || !strings.Contains(err.Error(), "修改交易紀錄") {
+		t.Errorf("storePendingStockChange() error = %v, want pending edit error", err)
+	}
+	if raw := redisClient.Data[pendingStockKey("u1")]; !strings.Contains(raw, `"action":"update"`) {
+		t.Errorf("pending = %s, want edit kept", raw)
+	}
+
+	// 其他使用者不受影響
+	if err := storePendingStockChange(ctx, redisClient, "u2", split); err != nil {
+		t.Errorf("storePendingStockChange() other user error = %v", err)
+	}
+}
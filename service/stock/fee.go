@@ -0,0 +1,263 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/model/dao/usersetting"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+)
+
+// FeeSchedule 券商的預設費率
+type FeeSchedule struct {
+	Broker string
+	// 手續費率（成交金額的比例）
+	Rate float64
+	// 每筆最低手續費
+	MinFee float64
+	// 費率適用的交易幣別（最低手續費以此幣別計），空字串表示不限幣別
+	Currency string
+	// 賣出交易稅率（例如台股證交稅）
+	SellTaxRate float64
+	// 股息預扣稅率（例如海外投資人的美股股息）
+	DividendTaxRate float64
+}
+
+// AppliesTo 費率是否適用於指定幣別的交易，設定幣別的券商只套用在相同幣別的交易
+func (f *FeeSchedule) AppliesTo(currency string) bool {
+	return f != nil && (f.Currency == "" || f.Currency == holdingCurrency(currency))
+}
+
+// TradeFee 依成交金額計算手續費
+func (f *FeeSchedule) TradeFee(amount float64) float64 {
+	if f == nil || amount <= 0 {
+		return 0
+	}
+	return roundCents(math.Max(amount*f.Rate, f.MinFee))
+}
+
+// TradeTax 依交易方向與成交金額計算交易稅，只有賣出需課稅
+func (f *FeeSchedule) TradeTax(side string, amount float64) float64 {
+	if f == nil || side != dto.StockSideSell || amount <= 0 {
+		return 0
+	}
+	return roundCents(amount * f.SellTaxRate)
+}
+
+// DividendTax 依股息總金額計算預扣稅
+func (f *FeeSchedule) DividendTax(amount float64) float64 {
+	if f == nil || amount <= 0 {
+		return 0
+	}
+	return roundCents(amount * f.DividendTaxRate)
+}
+
+// String 費率顯示格式
+func (f *FeeSchedule) String() string {
+	minFee := fmt.Sprintf("%.2f", f.MinFee)
+	if f.Currency != "" {
+		minFee += " " + f.Currency + "，只適用 " + f.Currency + " 交易"
+	}
+	return fmt.Sprintf("%s: 手續費 %s（最低 %s）, 賣出交易稅 %s, 股息預扣稅 %s",
+		f.Broker, formatRate(f.Rate), minFee, formatRate(f.SellTaxRate), formatRate(f.DividendTaxRate))
+}
+
+// FeeSchedules 所有券商的費率與系統預設券商
+type FeeSchedules struct {
+	Brokers       map[string]*FeeSchedule
+	DefaultBroker string
+}
+
+// Lookup 依券商名稱取得費率（不分大小寫）
+func (f *FeeSchedules) Lookup(broker string) (*FeeSchedule, bool) {
+	if f == nil || broker == "" {
+		return nil, false
+	}
+	schedule, ok := f.Brokers[strings.ToLower(broker)]
+	return schedule, ok
+}
+
+// ForUser 取得使用者適用的費率，使用者未設定時使用系統預設券商，查無費率時回傳 nil
+func (f *FeeSchedules) ForUser(setting *dto.UserSetting) *FeeSchedule {
+	if f == nil {
+		return nil
+	}
+
+	broker := f.DefaultBroker
+	if setting != nil && setting.Broker != "" {
+		broker = setting.Broker
+	}
+
+	schedule, _ := f.Lookup(broker)
+	return schedule
+}
+
+// loadFeeSchedules 讀取設定檔中的券商費率，格式錯誤的項目略過並記錄
+func loadFeeSchedules() *FeeSchedules {
+	feeConfig := config.GetFeeConfig()
+
+	schedules := &FeeSchedules{
+		Brokers:       make(map[string]*FeeSchedule),
+		DefaultBroker: strings.ToLower(feeConfig.DefaultBroker),
+	}
+	for _, entry := range feeConfig.BrokerFeeSchedules {
+		schedule, err := parseFeeSchedule(entry)
+		if err != nil {
+			logger.Error("券商費率設定錯誤", "entry", entry, "error", err)
+			continue
+		}
+		schedules.Brokers[schedule.Broker] = schedule
+	}
+
+	return schedules
+}
+
+// parseFeeSchedule 解析單一券商費率，格式: <券商>:<手續費率>:<最低手續費>[幣別][:<賣出交易稅率>[:<股息預扣稅率>]]
+// 費率可用小數或百分比表示，例如 0.001425 或 0.1425%；最低手續費可加上幣別（例如 20TWD），只套用在該幣別的交易
+func parseFeeSchedule(entry string) (*FeeSchedule, error) {
+	parts := strings.Split(entry, ":")
+	if len(parts) < 3 || len(parts) > 5 {
+		return nil, fmt.Errorf("格式應為 <券商>:<手續費率>:<最低手續費>[幣別][:<賣出交易稅率>[:<股息預扣稅率>]]")
+	}
+
+	broker := strings.ToLower(strings.TrimSpace(parts[0]))
+	if broker == "" {
+		return nil, fmt.Errorf("券商名稱不可為空")
+	}
+
+	schedule := &FeeSchedule{Broker: broker}
+
+	var err error
+	if schedule.Rate, err = parseRate(parts[1]); err != nil {
+		return nil, fmt.Errorf("無效的手續費率: %s", parts[1])
+	}
+
+	minFee := strings.ToUpper(strings.TrimSpace(parts[2]))
+	if n := len(minFee); n > 3 && strings.Trim(minFee[n-3:], "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		minFee, schedule.Currency = minFee[:n-3], minFee[n-3:]
+	}
+	schedule.MinFee, err = strconv.ParseFloat(minFee, 64)
+	if err != nil || schedule.MinFee < 0 {
+		return nil, fmt.Errorf("無效的最低手續費: %s", parts[2])
+	}
+
+	if len(parts) >= 4 {
+		if schedule.SellTaxRate, err = parseRate(parts[3]); err != nil {
+			return nil, fmt.Errorf("無效的賣出交易稅率: %s", parts[3])
+		}
+	}
+
+	if len(parts) == 5 {
+		if schedule.DividendTaxRate, err = parseRate(parts[4]); err != nil {
+			return nil, fmt.Errorf("無效的股息預扣稅率: %s", parts[4])
+		}
+	}
+
+	return schedule, nil
+}
+
+// parseRate 解析介於 0 與 1 之間的比例，支援百分比表示
+func parseRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+
+	scale := 1.0
+	if strings.HasSuffix(value, "%") {
+		value = strings.TrimSuffix(value, "%")
+		scale = 100
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	rate /= scale
+	if rate < 0 || rate >= 1 {
+		return 0, fmt.Errorf("比例需介於 0 與 1 之間: %v", rate)
+	}
+	return rate, nil
+}
+
+// formatRate 以百分比顯示比例
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"
+}
+
+// roundCents 四捨五入到小數第二位
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// BrokerCommand : 查詢券商費率或設定呼叫者的預設券商
+func BrokerCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $broker [name|default]
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $broker [name|default]")
+	}
+
+	schedules := loadFeeSchedules()
+
+	if len(strSlice) == 1 {
+		setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+		if err != nil {
+			return "", err
+		}
+		return formatFeeSchedules(schedules, schedules.ForUser(setting)), nil
+	}
+
+	broker := strings.ToLower(strSlice[1])
+	if broker == "default" {
+		if err := usersetting.UpsertBroker(ctx, nil, m.Author.ID, ""); err != nil {
+			return "", err
+		}
+		return "已改用系統預設券商", nil
+	}
+
+	schedule, ok := schedules.Lookup(broker)
+	if !ok {
+		return "", fmt.Errorf("未設定 %s 的費率", broker)
+	}
+	if err := usersetting.UpsertBroker(ctx, nil, m.Author.ID, schedule.Broker); err != nil {
+		return "", err
+	}
+
+	return "預設券商已設定為 " + schedule.String(), nil
+}
+
+// formatFeeSchedules 列出所有券商費率並標示目前使用的券商
+func formatFeeSchedules(schedules *FeeSchedules, current *FeeSchedule) string {
+	if len(schedules.Brokers) == 0 {
+		return "尚未設定任何券商費率，交易需自行輸入手續費與稅"
+	}
+
+	brokers := make([]string, 0, len(schedules.Brokers))
+	for broker := range schedules.Brokers {
+		brokers = append(brokers, broker)
+	}
+	sort.Strings(brokers)
+
+	var b strings.Builder
+	b.WriteString("券商費率:\n")
+	for _, broker := range brokers {
+		schedule := schedules.Brokers[broker]
+		mark := "  "
+		if schedule == current {
+			mark = "* "
+		}
+		b.WriteString(mark + schedule.String() + "\n")
+	}
+	if current == nil {
+		b.WriteString("目前未使用任何券商費率")
+	} else {
+		b.WriteString("目前使用: " + current.Broker)
+	}
+	return b.String()
+}
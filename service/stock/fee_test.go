@@ -0,0 +1,98 @@
+package stock
+
+import (
+	"testing"
+
+	"discordBot/model/dto"
+)
+
+func Test_parseFeeSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		want    FeeSchedule
+		wantErr bool
+	}{
+		{name: "rate and minimum", entry: "Firstrade:0:0", want: FeeSchedule{Broker: "firstrade"}},
+		{name: "percent rates", entry: "sinopac:0.1425%:20:0.3%", want: FeeSchedule{Broker: "sinopac", Rate: 0.001425, MinFee: 20, SellTaxRate: 0.003}},
+		{name: "dividend withholding", entry: "ib:0.0005:1:0:30%", want: FeeSchedule{Broker: "ib", Rate: 0.0005, MinFee: 1, DividendTaxRate: 0.3}},
+		{name: "minimum fee currency", entry: "fubon:0.1425%:20twd:0.3%", want: FeeSchedule{Broker: "fubon", Rate: 0.001425, MinFee: 20, SellTaxRate: 0.003, Currency: "TWD"}},
+		{name: "invalid minimum fee currency", entry: "fubon:0.1425%:TWD", wantErr: true},
+		{name: "missing minimum", entry: "ib:0.0005", wantErr: true},
+		{name: "empty broker", entry: ":0:0", wantErr: true},
+		{name: "negative minimum", entry: "ib:0:-1", wantErr: true},
+		{name: "rate out of range", entry: "ib:150%:0", wantErr: true},
+		{name: "too many fields", entry: "ib:0:0:0:0:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeeSchedule(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFeeSchedule() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFeeSchedule() unexpected error = %v", err)
+			}
+			if got.Broker != tt.want.Broker || !floatEqual(got.Rate, tt.want.Rate) || got.MinFee != tt.want.MinFee || got.Currency != tt.want.Currency ||
+				!floatEqual(got.SellTaxRate, tt.want.SellTaxRate) || !floatEqual(got.DividendTaxRate, tt.want.DividendTaxRate) {
+				t.Errorf("parseFeeSchedule() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeSchedule(t *testing.T) {
+	schedule := &FeeSchedule{Broker: "sinopac", Rate: 0.001425, MinFee: 20, SellTaxRate: 0.003, DividendTaxRate: 0.3}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "minimum fee", got: schedule.TradeFee(1000), want: 20},
+		{name: "rate fee rounded", got: schedule.TradeFee(123456), want: 175.92},
+		{name: "no fee without amount", got: schedule.TradeFee(0), want: 0},
+		{name: "sell tax", got: schedule.TradeTax(dto.StockSideSell, 600000), want: 1800},
+		{name: "no tax on buy", got: schedule.TradeTax(dto.StockSideBuy, 600000), want: 0},
+		{name: "dividend withholding", got: schedule.DividendTax(12.5), want: 3.75},
+		{name: "nil schedule", got: (*FeeSchedule)(nil).TradeFee(1000), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !floatEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	twd := &FeeSchedule{Broker: "fubon", MinFee: 20, Currency: "TWD"}
+	applies := []struct {
+		schedule *FeeSchedule
+		currency string
+		want     bool
+	}{
+		{schedule: schedule, currency: "USD", want: true},
+		{schedule: twd, currency: "TWD", want: true},
+		{schedule: twd, currency: "", want: false},
+		{schedule: twd, currency: "USD", want: false},
+		{schedule: nil, currency: "USD", want: false},
+	}
+	for _, tt := range applies {
+		if got := tt.schedule.AppliesTo(tt.currency); got != tt.want {
+			t.Errorf("AppliesTo(%q) of %v = %v, want %v", tt.currency, tt.schedule, got, tt.want)
+		}
+	}
+
+	schedules := &FeeSchedules{Brokers: map[string]*FeeSchedule{"sinopac": schedule}, DefaultBroker: "sinopac"}
+	if got := schedules.ForUser(nil); got != schedule {
+		t.Errorf("ForUser(nil) = %v, want default broker", got)
+	}
+	if got := schedules.ForUser(&dto.UserSetting{Broker: "unknown"}); got != nil {
+		t.Errorf("ForUser(unknown) = %v, want nil", got)
+	}
+}
@@ -2,11 +2,20 @@ package stock
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrPlanRestricted Finnhub 方案無權限存取的資料（例如免費方案的日線 stock/candle）
+var ErrPlanRestricted = errors.New("Finnhub 方案不支援此資料（需付費方案）")
+
 // FinnhubClient Finnhub API 客戶端接口
 type FinnhubClient interface {
 	GetQuote(ctx context.Context, symbol string) (*QuoteResponse, error)
+	GetEarningsCalendar(ctx context.Context, from, to string) ([]*EarningsEvent, error)
+	GetCandles(ctx context.Context, symbol string, resolution string, from, to int64) (*Candles, error)
+	GetDividends(ctx context.Context, symbol string, from, to string) ([]*DividendEvent, error)
+	GetSplits(ctx context.Context, symbol string, from, to string) ([]*SplitEvent, error)
+	GetCompanyProfile(ctx context.Context, symbol string) (*CompanyProfile, error)
 }
 
 // QuoteResponse 報價回應
@@ -19,3 +28,50 @@ type QuoteResponse struct {
 	OpenPrice     float32
 	PreviousClose float32
 }
+
+// EarningsEvent 財報行事曆項目
+type EarningsEvent struct {
+	Symbol      string
+	Date        string // 財報日期 (YYYY-MM-DD，美東時間)
+	Hour        string // bmo: 盤前, amc: 盤後, dmh: 盤中
+	Year        int64
+	Quarter     int64
+	EpsEstimate *float32 // 尚無預估時為 nil
+	EpsActual   *float32 // 尚未公布時為 nil
+}
+
+// Candles K 線資料（各欄位索引對應同一根 K 線）
+type Candles struct {
+	Open      []float32
+	High      []float32
+	Low       []float32
+	Close     []float32
+	Volume    []float32
+	Timestamp []int64 // Unix 秒
+}
+
+// DividendEvent 配息資料
+type DividendEvent struct {
+	Symbol   string
+	ExDate   string  // 除息日 (YYYY-MM-DD)
+	PayDate  string  // 發放日 (YYYY-MM-DD)，未公布時為空字串
+	Amount   float32 // 每股配息
+	Currency string
+}
+
+// SplitEvent 股票分割資料（4:1 分割為 FromFactor 1、ToFactor 4）
+type SplitEvent struct {
+	Symbol     string
+	Date       string // 生效日 (YYYY-MM-DD)
+	FromFactor float32
+	ToFactor   float32
+}
+
+// CompanyProfile 公司基本資料（ETF 等無資料的標的各欄位為空字串）
+type CompanyProfile struct {
+	Symbol   string
+	Name     string
+	Country  string
+	Currency string
+	Industry string // Finnhub 產業分類
+}
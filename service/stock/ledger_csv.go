@@ -0,0 +1,617 @@
+package stock
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dto"
+	"discordBot/model/postgresql"
+	"discordBot/service/client"
+)
+
+const (
+	// importMaxBytes 匯入檔案大小上限
+	importMaxBytes = 1 << 20
+	// importMaxRows 單次匯入的交易筆數上限
+	importMaxRows = 500
+	// importPreviewLimit 預覽時最多列出的筆數
+	importPreviewLimit = 10
+	// importErrorLimit 驗證失敗時最多列出的錯誤數
+	importErrorLimit = 10
+)
+
+// pendingActionImport 待確認異動類型: 匯入交易紀錄
+const pendingActionImport = "import"
+
+var attachmentClient = client.NewHTTPClientWithEnv("ATTACHMENT")
+
+// downloadAttachment 下載 Discord 附件（測試時可替換）
+var downloadAttachment = func(ctx context.Context, url string) ([]byte, error) {
+	return attachmentClient.Get(ctx, url)
+}
+
+// ledgerColumns 匯出與預設匯入格式的欄位
+var ledgerColumns = []string{"id", "traded_at", "side", "symbol", "units", "price", "fee", "tax", "currency", "broker"}
+
+// csvFormat 券商匯出檔的欄位對應
+type csvFormat struct {
+	Name string
+	// 各欄位可能的表頭名稱（小寫），空值代表該格式沒有此欄位
+	Date     []string
+	Side     []string
+	Symbol   []string
+	Units    []string
+	Price    []string
+	Fee      []string
+	Tax      []string
+	Currency []string
+	Broker   []string
+	// 日期格式，依序嘗試
+	DateLayouts []string
+	// 交易來源券商（匯入後寫入 broker 欄位，空字串時使用檔案中的 broker 欄位）
+	BrokerName string
+	// 非買賣紀錄（例如股息、轉帳）是否略過而非視為錯誤
+	SkipOtherActions bool
+}
+
+// csvFormats 支援的匯入格式，自動判斷時依序比對表頭
+var csvFormats = []*csvFormat{
+	{
+		Name:        "default",
+		Date:        []string{"traded_at", "date"},
+		Side:        []string{"side"},
+		Symbol:      []string{"symbol"},
+		Units:       []string{"units"},
+		Price:       []string{"price"},
+		Fee:         []string{"fee"},
+		Tax:         []string{"tax"},
+		Currency:    []string{"currency"},
+		Broker:      []string{"broker"},
+		DateLayouts: []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"},
+	},
+	{
+		// Interactive Brokers 交易明細，賣出以負數量表示
+		Name:        "ib",
+		Date:        []string{"date/time", "tradedate"},
+		Symbol:      []string{"symbol"},
+		Units:       []string{"quantity"},
+		Price:       []string{"t. price", "tradeprice"},
+		Fee:         []string{"comm/fee", "ibcommission"},
+		Currency:    []string{"currency", "currencyprimary"},
+		DateLayouts: []string{"2006-01-02, 15:04:05", "2006-01-02;150405", "20060102;150405", "2006-01-02", "20060102"},
+		BrokerName:  "ib",
+	},
+	{
+		Name:             "schwab",
+		Date:             []string{"date"},
+		Side:             []string{"action"},
+		Symbol:           []string{"symbol"},
+		Units:            []string{"quantity"},
+		Price:            []string{"price"},
+		Fee:              []string{"fees & comm"},
+		DateLayouts:      []string{"01/02/2006"},
+		BrokerName:       "schwab",
+		SkipOtherActions: true,
+	},
+	{
+		Name:             "firstrade",
+		Date:             []string{"tradedate"},
+		Side:             []string{"action"},
+		Symbol:           []string{"symbol"},
+		Units:            []string{"quantity"},
+		Price:            []string{"price"},
+		Fee:              []string{"commission", "fee"},
+		DateLayouts:      []string{"2006-01-02", "01/02/2006"},
+		BrokerName:       "firstrade",
+		SkipOtherActions: true,
+	},
+}
+
+// errSkipRow 非買賣紀錄，略過不匯入
+var errSkipRow = errors.New("非買賣紀錄")
+
+// ledgerImport CSV 解析結果
+type ledgerImport struct {
+	Format string
+	Trades []*dto.Stock
+	// 略過的非買賣紀錄筆數
+	Skipped int
+	// 與既有交易紀錄重複而略過的筆數
+	Duplicates int
+}
+
+// ImportStock : 解析附件中的 CSV 交易紀錄並預覽，需 $confirm 確認後才會寫入
+func ImportStock(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $import [default|ib|schwab|firstrade]（附加 CSV 檔）
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 2 || len(m.Attachments) != 1 {
+		return "", fmt.Errorf("參數錯誤，格式: $import [%s]，並附加一個 CSV 檔", strings.Join(csvFormatNames(), "|"))
+	}
+
+	formatName := ""
+	if len(strSlice) == 2 {
+		formatName = strings.ToLower(strSlice[1])
+	}
+
+	attachment := m.Attachments[0]
+	if attachment.Size > importMaxBytes {
+		return "", fmt.Errorf("檔案過大，上限 %d KB", importMaxBytes/1024)
+	}
+
+	data, err := downloadAttachment(ctx, attachment.URL)
+	if err != nil {
+		return "", fmt.Errorf("下載附件錯誤: %w", err)
+	}
+
+	parsed, err := parseLedgerCSV(data, formatName)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := stockDaoDeps{}.Get(ctx, &stockdao.GetInput{UserID: m.Author.ID})
+	if err != nil {
+		return "", err
+	}
+
+	if err := prepareImport(parsed, existing, m.Author.ID); err != nil {
+		return "", err
+	}
+
+	if err := storePendingStockChange(ctx, redisDeps{}, m.Author.ID, &pendingStockChange{
+		Action: pendingActionImport,
+		Import: parsed.Trades,
+	}); err != nil {
+		return "", err
+	}
+
+	return formatImportPreview(parsed), nil
+}
+
+// parseLedgerCSV 解析 CSV，formatName 為空字串時依表頭自動判斷格式
+// 任一列格式錯誤即回傳所有錯誤列（最多 importErrorLimit 筆），不會部分匯入
+func parseLedgerCSV(data []byte, formatName string) (*ledgerImport, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("讀取 CSV 表頭錯誤: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+
+	format, err := detectCSVFormat(columns, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &ledgerImport{Format: format.Name}
+	var rowErrs []string
+	line := 1
+	for {
+		record, err := reader.Read()
+		line++
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, fmt.Sprintf("第 %d 列: %v", line, err))
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		trade, err := format.parseRow(columns, record)
+		if errors.Is(err, errSkipRow) {
+			parsed.Skipped++
+			continue
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, fmt.Sprintf("第 %d 列: %v", line, err))
+			continue
+		}
+		parsed.Trades = append(parsed.Trades, trade)
+	}
+
+	if len(rowErrs) > 0 {
+		if len(rowErrs) > importErrorLimit {
+			rowErrs = append(rowErrs[:importErrorLimit], fmt.Sprintf("...另有 %d 列錯誤", len(rowErrs)-importErrorLimit))
+		}
+		return nil, fmt.Errorf("CSV 驗證失敗，未匯入任何資料:\n%s", strings.Join(rowErrs, "\n"))
+	}
+	if len(parsed.Trades) == 0 {
+		return nil, fmt.Errorf("CSV 中沒有可匯入的交易紀錄")
+	}
+	if len(parsed.Trades) > importMaxRows {
+		return nil, fmt.Errorf("單次最多匯入 %d 筆，檔案中有 %d 筆", importMaxRows, len(parsed.Trades))
+	}
+
+	return parsed, nil
+}
+
+// detectCSVFormat 取得指定格式，未指定時選擇第一個表頭相符的格式
+func detectCSVFormat(columns map[string]int, formatName string) (*csvFormat, error) {
+	for _, format := range csvFormats {
+		if formatName != "" && format.Name != formatName {
+			continue
+		}
+		if missing := format.missingColumns(columns); len(missing) > 0 {
+			if formatName != "" {
+				return nil, fmt.Errorf("%s 格式缺少欄位: %s", formatName, strings.Join(missing, ", "))
+			}
+			continue
+		}
+		return format, nil
+	}
+
+	if formatName != "" {
+		return nil, fmt.Errorf("不支援的格式: %s (可用: %s)", formatName, strings.Join(csvFormatNames(), ", "))
+	}
+	return nil, fmt.Errorf("無法判斷 CSV 格式，請指定格式 (可用: %s)", strings.Join(csvFormatNames(), ", "))
+}
+
+func csvFormatNames() []string {
+	names := make([]string, 0, len(csvFormats))
+	for _, format := range csvFormats {
+		names = append(names, format.Name)
+	}
+	return names
+}
+
+// missingColumns 必要欄位（日期、標的、數量、價格）中表頭沒有的欄位
+func (f *csvFormat) missingColumns(columns map[string]int) []string {
+	var missing []string
+	for _, aliases := range [][]string{f.Date, f.Symbol, f.Units, f.Price} {
+		if _, ok := findColumn(columns, aliases); !ok {
+			missing = append(missing, aliases[0])
+		}
+	}
+	return missing
+}
+
+// parseRow 將一列資料轉換為交易紀錄
+func (f *csvFormat) parseRow(columns map[string]int, record []string) (*dto.Stock, error) {
+	field := func(aliases []string) string {
+		if i, ok := findColumn(columns, aliases); ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	symbol := strings.ToUpper(field(f.Symbol))
+	// 先判斷交易方向，非買賣紀錄的數量欄位可能為空
+	units, unitsErr := parseCSVNumber(field(f.Units))
+	side, err := f.parseSide(field(f.Side), units)
+	if err != nil {
+		return nil, err
+	}
+	if unitsErr != nil {
+		return nil, fmt.Errorf("無效的數量: %s", field(f.Units))
+	}
+	if symbol == "" {
+		return nil, fmt.Errorf("缺少標的")
+	}
+	units = math.Abs(units)
+	if units == 0 {
+		return nil, fmt.Errorf("數量不可為 0")
+	}
+
+	price, err := parseCSVNumber(field(f.Price))
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("無效的價格: %s", field(f.Price))
+	}
+
+	trade := &dto.Stock{
+		Symbol:   symbol,
+		Side:     side,
+		Units:    units,
+		Price:    price,
+		Currency: "USD",
+		Broker:   f.BrokerName,
+	}
+
+	// 券商匯出的手續費常以負數表示支出
+	if value := field(f.Fee); value != "" {
+		fee, err := parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("無效的手續費: %s", value)
+		}
+		trade.Fee = math.Abs(fee)
+	}
+	if value := field(f.Tax); value != "" {
+		tax, err := parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("無效的交易稅: %s", value)
+		}
+		trade.Tax = math.Abs(tax)
+	}
+	if value := field(f.Currency); value != "" {
+		if len(value) != 3 {
+			return nil, fmt.Errorf("無效的幣別: %s", value)
+		}
+		trade.Currency = strings.ToUpper(value)
+	}
+	if value := field(f.Broker); value != "" {
+		trade.Broker = strings.ToLower(value)
+	}
+
+	if trade.TradedAt, err = f.parseDate(field(f.Date)); err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+// parseSide 解析交易方向；格式沒有方向欄位時以數量正負判斷
+func (f *csvFormat) parseSide(value string, units float64) (string, error) {
+	if len(f.Side) == 0 {
+		if units < 0 {
+			return dto.StockSideSell, nil
+		}
+		return dto.StockSideBuy, nil
+	}
+
+	switch strings.ToLower(value) {
+	case "buy", "bought", "b":
+		return dto.StockSideBuy, nil
+	case "sell", "sold", "s":
+		return dto.StockSideSell, nil
+	}
+
+	if f.SkipOtherActions {
+		return "", errSkipRow
+	}
+	return "", fmt.Errorf("無效的交易方向: %s", value)
+}
+
+// parseDate 依格式的日期格式解析交易時間；只有日期時視為美東當天中午，讓快照日期歸屬正確
+func (f *csvFormat) parseDate(value string) (time.Time, error) {
+	// 例如 Schwab 的 "01/02/2026 as of 01/01/2026"
+	if before, _, ok := strings.Cut(value, " as of "); ok {
+		value = before
+	}
+
+	loc := usExchange().Location
+	for _, layout := range f.DateLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "15") {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, loc)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("無效的日期: %s", value)
+}
+
+// findColumn 依別名找出欄位位置
+func findColumn(columns map[string]int, aliases []string) (int, bool) {
+	for _, alias := range aliases {
+		if i, ok := columns[alias]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseCSVNumber 解析券商格式的數字，例如 "$1,234.50"、"(1.00)"
+func parseCSVNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		number = -number
+	}
+	return number, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// prepareImport 排除與既有紀錄重複的交易，並確認匯入後不會賣超
+func prepareImport(parsed *ledgerImport, existing []*dto.Stock, userID string) error {
+	seen := make(map[string]int)
+	for _, trade := range existing {
+		seen[importTradeKey(trade)]++
+	}
+
+	trades := make([]*dto.Stock, 0, len(parsed.Trades))
+	for _, trade := range parsed.Trades {
+		key := importTradeKey(trade)
+		if seen[key] > 0 {
+			seen[key]--
+			parsed.Duplicates++
+			continue
+		}
+		trade.UserID = userID
+		trades = append(trades, trade)
+	}
+	parsed.Trades = trades
+
+	if len(parsed.Trades) == 0 {
+		return fmt.Errorf("所有交易紀錄皆已存在，沒有需要匯入的資料")
+	}
+
+	all := make([]*dto.Stock, 0, len(existing)+len(trades))
+	all = append(all, existing...)
+	all = append(all, trades...)
+	if _, err := MatchLots(all, CostMethodAverage); err != nil {
+		return fmt.Errorf("匯入後交易紀錄不一致: %w", err)
+	}
+
+	return nil
+}
+
+// importTradeKey 判斷重複匯入的依據：同一美東日期、標的、方向、數量與價格
+func importTradeKey(trade *dto.Stock) string {
+	return fmt.Sprintf("%s|%s|%s|%v|%v", snapshotDate(trade.TradedAt).Format("2006-01-02"), trade.Symbol, trade.Side, trade.Units, trade.Price)
+}
+
+// formatImportPreview 匯入預覽訊息
+func formatImportPreview(parsed *ledgerImport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "即將匯入 %d 筆交易紀錄（格式: %s", len(parsed.Trades), parsed.Format)
+	if parsed.Duplicates > 0 {
+		fmt.Fprintf(&b, "，略過 %d 筆已存在", parsed.Duplicates)
+	}
+	if parsed.Skipped > 0 {
+		fmt.Fprintf(&b, "，略過 %d 筆非買賣紀錄", parsed.Skipped)
+	}
+	b.WriteString("）:\n```\n")
+	for i, trade := range parsed.Trades {
+		if i == importPreviewLimit {
+			fmt.Fprintf(&b, "...另有 %d 筆\n", len(parsed.Trades)-importPreviewLimit)
+			break
+		}
+		b.WriteString(strings.TrimPrefix(formatTrade(trade), "#0 ") + "\n")
+	}
+	fmt.Fprintf(&b, "```\n輸入 $confirm 確認或 $cancel 取消（%v 內有效）", pendingStockTTL)
+	return b.String()
+}
+
+// confirmImport 重新檢查後於同一個 transaction 寫入所有匯入的交易紀錄
+func confirmImport(ctx context.Context, redisClient RedisClient, userID string, trades []*dto.Stock) (string, error) {
+	existing, err := stockDaoDeps{}.Get(ctx, &stockdao.GetInput{UserID: userID})
+	if err != nil {
+		return "", err
+	}
+
+	all := make([]*dto.Stock, 0, len(existing)+len(trades))
+	all = append(all, existing...)
+	all = append(all, trades...)
+	if _, err := MatchLots(all, CostMethodAverage); err != nil {
+		_ = redisClient.Del(ctx, pendingStockKey(userID))
+		return "", fmt.Errorf("交易紀錄已變更，匯入後不一致，請重新匯入: %w", err)
+	}
+
+	if err := commitImport(ctx, userID, trades); err != nil {
+		return "", err
+	}
+
+	if err := redisClient.Del(ctx, pendingStockKey(userID)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("已匯入 %d 筆交易紀錄", len(trades)), nil
+}
+
+// commitImport 於同一個 transaction 中新增所有交易紀錄，任一筆失敗即全部取消
+func commitImport(ctx context.Context, userID string, trades []*dto.Stock) (err error) {
+	dbM, err := postgresql.GetConn()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	tx, err := dbM.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("開始 transaction 錯誤: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, trade := range trades {
+		trade.UserID = userID
+		if err = stockdao.Ins(ctx, tx, trade); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit 錯誤: %v", err)
+	}
+
+	return nil
+}
+
+// ExportStock : 以 CSV 附件匯出呼叫者的所有交易紀錄（可再以 $import 匯入）
+func ExportStock(ctx context.Context, m *discordgo.MessageCreate) (*discordgo.MessageSend, error) {
+	// example : $export
+	trades, err := stockDaoDeps{}.Get(ctx, &stockdao.GetInput{UserID: m.Author.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(trades) == 0 {
+		return nil, fmt.Errorf("沒有交易紀錄")
+	}
+
+	var buf bytes.Buffer
+	if err := writeLedgerCSV(&buf, trades); err != nil {
+		return nil, err
+	}
+
+	return &discordgo.MessageSend{
+		Content: fmt.Sprintf("共 %d 筆交易紀錄", len(trades)),
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("ledger-%s.csv", snapshotDate(nowFunc()).Format("20060102")),
+				ContentType: "text/csv",
+				Reader:      &buf,
+			},
+		},
+	}, nil
+}
+
+// writeLedgerCSV 以預設匯入格式寫出交易紀錄
+func writeLedgerCSV(w io.Writer, trades []*dto.Stock) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ledgerColumns); err != nil {
+		return err
+	}
+
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, trade := range trades {
+		if err := writer.Write([]string{
+			strconv.FormatInt(trade.ID, 10),
+			trade.TradedAt.Format(time.RFC3339),
+			trade.Side,
+			trade.Symbol,
+			format(trade.Units),
+			format(trade.Price),
+			format(trade.Fee),
+			format(trade.Tax),
+			trade.Currency,
+			trade.Broker,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,206 @@
+package stock
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/service/market"
+)
+
+func Test_parseLedgerCSV(t *testing.T) {
+	ny := market.US().Location
+	noon := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 12, 0, 0, 0, ny) }
+
+	tests := []struct {
+		name        string
+		data        string
+		format      string
+		wantFormat  string
+		want        []*dto.Stock
+		wantSkipped int
+		wantErr     string
+	}{
+		{
+			name:       "default format",
+			data:       "id,traded_at,side,symbol,units,price,fee,tax,currency,broker\n3,2026-01-02T10:30:00-05:00,buy,aapl,10,150.5,1,0,USD,IB\n",
+			wantFormat: "default",
+			want: []*dto.Stock{
+				{Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 150.5, Fee: 1, Currency: "USD", Broker: "ib", TradedAt: time.Date(2026, 1, 2, 10, 30, 0, 0, ny)},
+			},
+		},
+		{
+			name:       "interactive brokers signed quantity",
+			data:       "Symbol,Date/Time,Quantity,T. Price,Comm/Fee,Currency\nMSFT,\"2026-01-05, 09:45:00\",-3,400,-1.05,USD\n",
+			wantFormat: "ib",
+			want: []*dto.Stock{
+				{Symbol: "MSFT", Side: dto.StockSideSell, Units: 3, Price: 400, Fee: 1.05, Currency: "USD", Broker: "ib", TradedAt: time.Date(2026, 1, 5, 9, 45, 0, 0, ny)},
+			},
+		},
+		{
+			name:       "schwab skips non-trade actions",
+			data:       "\xef\xbb\xbfDate,Action,Symbol,Description,Quantity,Price,Fees & Comm,Amount\n01/06/2026 as of 01/05/2026,Buy,VOO,VANGUARD,2,\"$1,000.25\",$0.00,\"-$2,000.50\"\n01/07/2026,Qualified Dividend,VOO,VANGUARD,,,,$5.00\n",
+			wantFormat: "schwab",
+			want: []*dto.Stock{
+				{Symbol: "VOO", Side: dto.StockSideBuy, Units: 2, Price: 1000.25, Currency: "USD", Broker: "schwab", TradedAt: noon(2026, 1, 6)},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:       "firstrade",
+			data:       "Symbol,Quantity,Price,Action,Description,TradeDate,Commission,Fee\nTSLA,1,250,SELL,TESLA,2026-01-08,0,0.02\n",
+			wantFormat: "firstrade",
+			want: []*dto.Stock{
+				{Symbol: "TSLA", Side: dto.StockSideSell, Units: 1, Price: 250, Currency: "USD", Broker: "firstrade", TradedAt: noon(2026, 1, 8)},
+			},
+		},
+		{
+			name:    "explicit format missing columns",
+			data:    "Symbol,Quantity,Price\nAAPL,1,1\n",
+			format:  "schwab",
+			wantErr: "缺少欄位",
+		},
+		{
+			name:    "unknown header",
+			data:    "foo,bar\n1,2\n",
+			wantErr: "無法判斷",
+		},
+		{
+			name:    "all row errors reported",
+			data:    "traded_at,side,symbol,units,price\n2026-01-02,hold,AAPL,1,1\n2026-13-02,buy,AAPL,1,1\n2026-01-02,buy,AAPL,0,1\n",
+			wantErr: "第 2 列: 無效的交易方向: hold\n第 3 列: 無效的日期: 2026-13-02\n第 4 列: 數量不可為 0",
+		},
+		{
+			name:    "no trades",
+			data:    "Date,Action,Symbol,Quantity,Price\n01/07/2026,Journal,VOO,1,1\n",
+			wantErr: "沒有可匯入",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLedgerCSV([]byte(tt.data), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseLedgerCSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLedgerCSV() unexpected error = %v", err)
+			}
+			if got.Format != tt.wantFormat || got.Skipped != tt.wantSkipped {
+				t.Errorf("parseLedgerCSV() format = %s, skipped = %d, want %s, %d", got.Format, got.Skipped, tt.wantFormat, tt.wantSkipped)
+			}
+			if len(got.Trades) != len(tt.want) {
+				t.Fatalf("parseLedgerCSV() got %d trades, want %d", len(got.Trades), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !sameTrade(got.Trades[i], want) {
+					t.Errorf("trade[%d] = %+v, want %+v", i, got.Trades[i], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_prepareImport(t *testing.T) {
+	day := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	existing := []*dto.Stock{
+		{ID: 1, UserID: "u1", Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: day},
+	}
+
+	tests := []struct {
+		name           string
+		trades         []*dto.Stock
+		wantTrades     int
+		wantDuplicates int
+		wantErr        bool
+	}{
+		{
+			name: "skip duplicate of existing trade",
+			trades: []*dto.Stock{
+				{Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: day.Add(time.Hour)},
+				{Symbol: "AAPL", Side: dto.StockSideSell, Units: 5, Price: 120, TradedAt: day.AddDate(0, 0, 1)},
+			},
+			wantTrades:     1,
+			wantDuplicates: 1,
+		},
+		{
+			name: "identical rows in file are kept once per existing match",
+			trades: []*dto.Stock{
+				{Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: day},
+				{Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: day},
+			},
+			wantTrades:     1,
+			wantDuplicates: 1,
+		},
+		{
+			name: "oversell rejected",
+			trades: []*dto.Stock{
+				{Symbol: "AAPL", Side: dto.StockSideSell, Units: 20, Price: 120, TradedAt: day.AddDate(0, 0, 1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "everything already imported",
+			trades: []*dto.Stock{
+				{Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: day},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed := &ledgerImport{Trades: tt.trades}
+			err := prepareImport(parsed, existing, "u1")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("prepareImport() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareImport() unexpected error = %v", err)
+			}
+			if len(parsed.Trades) != tt.wantTrades || parsed.Duplicates != tt.wantDuplicates {
+				t.Errorf("prepareImport() trades = %d, duplicates = %d, want %d, %d", len(parsed.Trades), parsed.Duplicates, tt.wantTrades, tt.wantDuplicates)
+			}
+			for _, trade := range parsed.Trades {
+				if trade.UserID != "u1" {
+					t.Errorf("prepareImport() UserID = %s, want u1", trade.UserID)
+				}
+			}
+		})
+	}
+}
+
+func Test_writeLedgerCSV_roundTrip(t *testing.T) {
+	trades := []*dto.Stock{
+		{ID: 1, Symbol: "AAPL", Side: dto.StockSideBuy, Units: 10, Price: 150.25, Fee: 1, Currency: "USD", Broker: "ib", TradedAt: time.Date(2026, 1, 2, 15, 30, 0, 0, time.UTC)},
+		{ID: 2, Symbol: "2330", Side: dto.StockSideSell, Units: 1000, Price: 600, Fee: 20, Tax: 1800, Currency: "TWD", Broker: "sinopac", TradedAt: time.Date(2026, 1, 3, 1, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	if err := writeLedgerCSV(&buf, trades); err != nil {
+		t.Fatalf("writeLedgerCSV() error = %v", err)
+	}
+
+	got, err := parseLedgerCSV(buf.Bytes(), "")
+	if err != nil {
+		t.Fatalf("parseLedgerCSV() error = %v", err)
+	}
+	if got.Format != "default" || len(got.Trades) != len(trades) {
+		t.Fatalf("parseLedgerCSV() format = %s, trades = %d", got.Format, len(got.Trades))
+	}
+	for i, want := range trades {
+		trade := *got.Trades[i]
+		trade.ID = want.ID
+		if !sameTrade(&trade, want) {
+			t.Errorf("trade[%d] = %+v, want %+v", i, trade, *want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+// CostMethod 成本計算方式
+type CostMethod string
+
+const (
+	// CostMethodFIFO 先進先出
+	CostMethodFIFO CostMethod = "fifo"
+	// CostMethodLIFO 後進先出
+	CostMethodLIFO CostMethod = "lifo"
+	// CostMethodAverage 平均成本
+	CostMethodAverage CostMethod = "average"
+)
+
+// ParseCostMethod 解析成本計算方式，空字串視為平均成本
+func ParseCostMethod(value string) (CostMethod, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "average", "avg":
+		return CostMethodAverage, nil
+	case "fifo":
+		return CostMethodFIFO, nil
+	case "lifo":
+		return CostMethodLIFO, nil
+	default:
+		return "", fmt.Errorf("不支援的成本計算方式: %s (可用: fifo, lifo, average)", value)
+	}
+}
+
+// Label 成本計算方式顯示名稱
+func (m CostMethod) Label() string {
+	switch m {
+	case CostMethodFIFO:
+		return "先進先出 (FIFO)"
+	case CostMethodLIFO:
+		return "後進先出 (LIFO)"
+	default:
+		return "平均成本"
+	}
+}
+
+// Lot 尚未賣出的買進批次
+type Lot struct {
+	TradeID  int64
+	TradedAt time.Time
+	Units    float64
+	// 每股成本（含分攤的買進手續費與稅）
+	UnitCost float64
+}
+
+// MatchLots 依成本計算方式配對買賣批次，回傳每個標的的持倉與已實現損益
+// 已全數賣出的標的仍會回傳（Units 為 0），以保留已實現損益
+func MatchLots(trades []*dto.Stock, method CostMethod) ([]*Position, error) {
+	positions := make(map[string]*Position)
+	var order []string
+
+	for _, trade := range sortTrades(trades) {
+		symbol := strings.ToUpper(trade.Symbol)
+		position, ok := positions[symbol]
+		if !ok {
+			position = &Position{Symbol: symbol, Currency: trade.Currency}
+			positions[symbol] = position
+			order = append(order, symbol)
+		}
+
+		if trade.Side == dto.StockSideSell {
+			if err := position.sell(trade, method); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		position.buy(trade, method)
+	}
+
+	ret := make([]*Position, 0, len(order))
+	for _, symbol := range order {
+		ret = append(ret, positions[symbol])
+	}
+
+	return ret, nil
+}
+
+// tradeCosts 單筆交易的手續費與稅
+func tradeCosts(trade *dto.Stock) float64 {
+	return trade.Fee + trade.Tax
+}
+
+func (p *Position) buy(trade *dto.Stock, method CostMethod) {
+	if trade.Units <= 0 {
+		return
+	}
+
+	cost := trade.Units*trade.Price + tradeCosts(trade)
+	p.Units += trade.Units
+	p.Cost += cost
+
+	// 平均成本法只保留一個合併批次
+	if method == CostMethodAverage && len(p.Lots) > 0 {
+		lot := p.Lots[0]
+		lot.Units = p.Units
+		lot.UnitCost = p.Cost / p.Units
+		return
+	}
+
+	p.Lots = append(p.Lots, &Lot{
+		TradeID:  trade.ID,
+		TradedAt: trade.TradedAt,
+		Units:    trade.Units,
+		UnitCost: cost / trade.Units,
+	})
+}
+
+func (p *Position) sell(trade *dto.Stock, method CostMethod) error {
+	if trade.Units > p.Units+unitsEpsilon {
+		return fmt.Errorf("%s 賣出數量 %v 超過持有數量 %v", p.Symbol, trade.Units, p.Units)
+	}
+
+	remaining := trade.Units
+	var matchedCost float64
+
+	for remaining > unitsEpsilon && len(p.Lots) > 0 {
+		idx := 0
+		if method == CostMethodLIFO {
+			idx = len(p.Lots) - 1
+		}
+		lot := p.Lots[idx]
+
+		units := remaining
+		if lot.Units < units {
+			units = lot.Units
+		}
+
+		matchedCost += units * lot.UnitCost
+		lot.Units -= units
+		remaining -= units
+
+		if lot.Units <= unitsEpsilon {
+			p.Lots = append(p.Lots[:idx], p.Lots[idx+1:]...)
+		}
+	}
+
+	p.RealizedProfit += trade.Units*trade.Price - tradeCosts(trade) - matchedCost
+	p.Units -= trade.Units
+	p.Cost -= matchedCost
+
+	if p.Units <= unitsEpsilon {
+		p.Units = 0
+		p.Cost = 0
+		p.Lots = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"discordBot/model/dto"
+)
+
+func Test_MatchLots(t *testing.T) {
+	// 兩批買進（含手續費）後部分賣出，再以固定現價計算未實現損益
+	ledger := []*dto.Stock{
+		tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 10),
+		tradeAt(2, "TSLA", dto.StockSideBuy, 10, 200, 10),
+		tradeAt(3, "TSLA", dto.StockSideSell, 15, 300, 15),
+	}
+
+	type wantPosition struct {
+		units      float64
+		cost       float64
+		realized   float64
+		unrealized float64
+		lots       []Lot
+	}
+
+	tests := []struct {
+		name    string
+		trades  []*dto.Stock
+		method  CostMethod
+		price   float64
+		want    map[string]wantPosition
+		wantErr bool
+	}{
+		{
+			name:   "fifo sells oldest lot first",
+			trades: ledger,
+			method: CostMethodFIFO,
+			price:  250,
+			// 賣出成本 10*101 + 5*201 = 2015，所得 4500-15 = 4485
+			want: map[string]wantPosition{
+				"TSLA": {units: 5, cost: 1005, realized: 2470, unrealized: 245, lots: []Lot{{TradeID: 2, Units: 5, UnitCost: 201}}},
+			},
+		},
+		{
+			name:   "lifo sells newest lot first",
+			trades: ledger,
+			method: CostMethodLIFO,
+			price:  250,
+			// 賣出成本 10*201 + 5*101 = 2515
+			want: map[string]wantPosition{
+				"TSLA": {units: 5, cost: 505, realized: 1970, unrealized: 745, lots: []Lot{{TradeID: 1, Units: 5, UnitCost: 101}}},
+			},
+		},
+		{
+			name:   "average pools all lots",
+			trades: ledger,
+			method: CostMethodAverage,
+			price:  250,
+			// 平均成本 3020/20 = 151，賣出成本 2265
+			want: map[string]wantPosition{
+				"TSLA": {units: 5, cost: 755, realized: 2220, unrealized: 495, lots: []Lot{{TradeID: 1, Units: 5, UnitCost: 151}}},
+			},
+		},
+		{
+			name: "fully sold position keeps realized profit",
+			trades: []*dto.Stock{
+				tradeAt(1, "AAPL", dto.StockSideBuy, 2, 100, 0),
+				tradeAt(2, "AAPL", dto.StockSideSell, 2, 90, 2),
+			},
+			method: CostMethodFIFO,
+			price:  120,
+			want: map[string]wantPosition{
+				"AAPL": {realized: -22},
+			},
+		},
+		{
+			name: "sell spanning several lots and symbols",
+			trades: []*dto.Stock{
+				tradeAt(1, "AAPL", dto.StockSideBuy, 1, 100, 0),
+				tradeAt(2, "AAPL", dto.StockSideBuy, 1, 110, 0),
+				tradeAt(3, "MSFT", dto.StockSideBuy, 3, 50, 0),
+				tradeAt(4, "AAPL", dto.StockSideBuy, 1, 120, 0),
+				tradeAt(5, "AAPL", dto.StockSideSell, 2, 130, 0),
+				tradeAt(6, "MSFT", dto.StockSideSell, 1, 40, 0),
+			},
+			method: CostMethodFIFO,
+			price:  100,
+			want: map[string]wantPosition{
+				"AAPL": {units: 1, cost: 120, realized: 50, unrealized: -20, lots: []Lot{{TradeID: 4, Units: 1, UnitCost: 120}}},
+				"MSFT": {units: 2, cost: 100, realized: -10, unrealized: 100, lots: []Lot{{TradeID: 3, Units: 2, UnitCost: 50}}},
+			},
+		},
+		{
+			name: "oversell",
+			trades: []*dto.Stock{
+				tradeAt(1, "TSLA", dto.StockSideBuy, 1, 100, 0),
+				tradeAt(2, "TSLA", dto.StockSideSell, 2, 100, 0),
+			},
+			method:  CostMethodLIFO,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchLots(tt.trades, tt.method)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("MatchLots() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchLots() unexpected error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("MatchLots() = %d positions, want %d", len(got), len(tt.want))
+			}
+			for _, position := range got {
+				want, ok := tt.want[position.Symbol]
+				if !ok {
+					t.Fatalf("MatchLots() unexpected symbol %s", position.Symbol)
+				}
+				if !floatEqual(position.Units, want.units) || !floatEqual(position.Cost, want.cost) ||
+					!floatEqual(position.RealizedProfit, want.realized) ||
+					!floatEqual(position.UnrealizedProfit(tt.price), want.unrealized) {
+					t.Errorf("MatchLots() %s = units %v cost %v realized %v unrealized %v, want %+v",
+						position.Symbol, position.Units, position.Cost, position.RealizedProfit, position.UnrealizedProfit(tt.price), want)
+				}
+
+				if len(position.Lots) != len(want.lots) {
+					t.Fatalf("MatchLots() %s = %d lots, want %d", position.Symbol, len(position.Lots), len(want.lots))
+				}
+				for i, lot := range want.lots {
+					if position.Lots[i].TradeID != lot.TradeID || !floatEqual(position.Lots[i].Units, lot.Units) ||
+						!floatEqual(position.Lots[i].UnitCost, lot.UnitCost) {
+						t.Errorf("MatchLots() %s lot[%d] = %+v, want %+v", position.Symbol, i, *position.Lots[i], lot)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_ParseCostMethod(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    CostMethod
+		wantErr bool
+	}{
+		{input: "FIFO", want: CostMethodFIFO},
+		{input: "lifo", want: CostMethodLIFO},
+		{input: "avg", want: CostMethodAverage},
+		{input: "", want: CostMethodAverage},
+		{input: "hifo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCostMethod(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCostMethod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCostMethod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getCostMethod(t *testing.T) {
+	repo := &MockUserSettingRepository{
+		Settings: map[string]*dto.UserSetting{
+			"u1": {UserID: "u1", CostMethod: "lifo"},
+		},
+	}
+
+	if got, err := getCostMethod(context.Background(), repo, "u1"); err != nil || got != CostMethodLIFO {
+		t.Errorf("getCostMethod(u1) = %v, %v, want lifo", got, err)
+	}
+	if got, err := getCostMethod(context.Background(), repo, "u2"); err != nil || got != CostMethodAverage {
+		t.Errorf("getCostMethod(u2) = %v, %v, want average", got, err)
+	}
+
+	repo.Err = errors.New("db down")
+	if _, err := getCostMethod(context.Background(), repo, "u1"); err == nil {
+		t.Errorf("getCostMethod() error = nil, want error")
+	}
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,156 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/crypto"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	embedColorUp   = 0x2ecc71
+	embedColorDown = 0xe74c3c
+)
+
+// overviewItem 市場概況中的單一標的
+type overviewItem struct {
+	Symbol string
+	Quote  *SessionQuote
+	Err    error
+}
+
+// MarketOverview : 取得指數/ETF 與 ETH 價格的市場概況（目前時段漲跌幅）
+func MarketOverview(ctx context.Context, title string) (*discordgo.MessageEmbed, error) {
+	return marketOverview(ctx, title, config.GetTaskConfig(), false, cachedEthPrice)
+}
+
+// MarketCloseOverview : 取得收盤市場概況，收盤後已進入盤後時段，固定使用正規時段漲跌幅
+func MarketCloseOverview(ctx context.Context, title string) (*discordgo.MessageEmbed, error) {
+	return marketOverview(ctx, title, config.GetTaskConfig(), true, cachedEthPrice)
+}
+
+func cachedEthPrice(ctx context.Context) (float64, error) {
+	return crypto.GetCachedPriceWithContext(ctx, time.Minute)
+}
+
+func marketOverview(ctx context.Context, title string, taskConfig *config.TaskConfig, regularSession bool, ethPrice func(context.Context) (float64, error)) (*discordgo.MessageEmbed, error) {
+	symbols := taskConfig.MarketOverviewSymbols
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("未設定市場概況標的")
+	}
+
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+	maxConcurrency := normalizeConcurrency(taskConfig.CheckChangeMaxConcurrency, 5)
+
+	items := make([]overviewItem, len(symbols))
+
+	// 逾時後剩餘標的標示為取得失敗
+	skipRemaining := func(from int, err error) {
+		logger.Warn("市場概況逾時，停止查詢剩餘標的", "error", err)
+		for j := from; j < len(symbols); j++ {
+			items[j] = overviewItem{Symbol: strings.ToUpper(symbols[j]), Err: err}
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+loop:
+	for i, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			skipRemaining(i, err)
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			skipRemaining(i, ctx.Err())
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			defer func() {
+				<-sem
+			}()
+
+			quoteCtx, quoteCancel := context.WithTimeout(ctx, externalTimeout)
+			var quote *SessionQuote
+			var err error
+			if regularSession {
+				quote, err = GetRegularChange(quoteCtx, symbol)
+			} else {
+				quote, err = GetSessionChange(quoteCtx, symbol)
+			}
+			quoteCancel()
+			if err != nil {
+				logger.Error("取得市場概況報價失敗", "symbol", symbol, "error", err)
+			}
+			items[i] = overviewItem{Symbol: strings.ToUpper(symbol), Quote: quote, Err: err}
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	ethCtx, ethCancel := context.WithTimeout(ctx, externalTimeout)
+	eth, ethErr := ethPrice(ethCtx)
+	ethCancel()
+	if ethErr != nil {
+		logger.Error("取得ETH價格失敗", "error", ethErr)
+	}
+
+	return buildMarketOverview(title, SessionAt(nowFunc()), items, eth, ethErr), nil
+}
+
+// buildMarketOverview 組合市場概況 embed
+func buildMarketOverview(title string, session MarketSession, items []overviewItem, ethPrice float64, ethErr error) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("%s [%s]", title, session.Label()),
+		Timestamp: nowFunc().Format(time.RFC3339),
+	}
+
+	var up, down int
+	for _, item := range items {
+		value := "取得失敗"
+		if item.Err == nil && item.Quote != nil {
+			value = fmt.Sprintf("%.2f (%+.2f %%)", item.Quote.Price, item.Quote.Change)
+			if item.Quote.Change >= 0 {
+				up++
+			} else {
+				down++
+			}
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   item.Symbol,
+			Value:  value,
+			Inline: true,
+		})
+	}
+
+	ethValue := "取得失敗"
+	if ethErr == nil {
+		ethValue = fmt.Sprintf("%.2f USD", ethPrice)
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   "ETH",
+		Value:  ethValue,
+		Inline: true,
+	})
+
+	embed.Description = fmt.Sprintf("上漲 %d / 下跌 %d", up, down)
+	if up >= down {
+		embed.Color = embedColorUp
+	} else {
+		embed.Color = embedColorDown
+	}
+
+	return embed
+}
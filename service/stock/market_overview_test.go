@@ -0,0 +1,127 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"discordBot/pkg/config"
+)
+
+func Test_marketOverview(t *testing.T) {
+	ctx := context.Background()
+
+	nowFunc = func() time.Time { return time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	mock := NewMockFinnhubClient()
+	mock.AddQuote("SPY", &QuoteResponse{CurrentPrice: 600, PreviousClose: 594, PercentChange: 1.01})
+	mock.AddQuote("QQQ", &QuoteResponse{CurrentPrice: 500, PreviousClose: 505, PercentChange: -0.99})
+	mock.AddQuote("SOXX", &QuoteResponse{CurrentPrice: 250, PreviousClose: 245, PercentChange: 2.04})
+	SetDefaultClient(mock)
+	defer ResetDefaultClient()
+
+	taskConfig := &config.TaskConfig{MarketOverviewSymbols: []string{"spy", "QQQ", "SOXX", "EWT"}}
+
+	embed, err := marketOverview(ctx, "市場概況", taskConfig, false, func(ctx context.Context) (float64, error) {
+		return 2500.5, nil
+	})
+	if err != nil {
+		t.Fatalf("marketOverview() unexpected error = %v", err)
+	}
+
+	wantFields := []struct{ name, value string }{
+		{"SPY", "600.00 (+1.01 %)"},
+		{"QQQ", "500.00 (-0.99 %)"},
+		{"SOXX", "250.00 (+2.04 %)"},
+		{"EWT", "取得失敗"},
+		{"ETH", "2500.50 USD"},
+	}
+	if len(embed.Fields) != len(wantFields) {
+		t.Fatalf("marketOverview() fields = %d, want %d", len(embed.Fields), len(wantFields))
+	}
+	for i, want := range wantFields {
+		if embed.Fields[i].Name != want.name || embed.Fields[i].Value != want.value {
+			t.Errorf("field[%d] = %s: %s, want %s: %s", i, embed.Fields[i].Name, embed.Fields[i].Value, want.name, want.value)
+		}
+	}
+
+	if embed.Title != "市場概況 [盤中]" {
+		t.Errorf("marketOverview() title = %v, want 市場概況 [盤中]", embed.Title)
+	}
+	if embed.Description != "上漲 2 / 下跌 1" || embed.Color != embedColorUp {
+		t.Errorf("marketOverview() description = %v color = %x", embed.Description, embed.Color)
+	}
+}
+
+func Test_marketOverview_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := marketOverview(ctx, "市場概況", &config.TaskConfig{}, false, nil); err == nil {
+		t.Errorf("marketOverview() without symbols error = nil, want error")
+	}
+
+	mock := NewMockFinnhubClient()
+	mock.Err = errors.New("rate limit")
+	SetDefaultClient(mock)
+	defer ResetDefaultClient()
+
+	embed, err := marketOverview(ctx, "市場概況", &config.TaskConfig{MarketOverviewSymbols: []string{"SPY"}}, false, func(ctx context.Context) (float64, error) {
+		return 0, errors.New("coingecko down")
+	})
+	if err != nil {
+		t.Fatalf("marketOverview() unexpected error = %v", err)
+	}
+	for _, field := range embed.Fields {
+		if field.Value != "取得失敗" {
+			t.Errorf("field %s = %v, want 取得失敗", field.Name, field.Value)
+		}
+	}
+}
+
+func Test_marketOverview_Close(t *testing.T) {
+	ctx := context.Background()
+
+	// 美東 16:05 收盤後已是盤後時段，即時串流有盤後成交仍以正規時段漲跌幅統計
+	nowFunc = func() time.Time { return time.Date(2026, 10, 20, 20, 5, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	mock := NewMockFinnhubClient()
+	mock.AddQuote("SPY", &QuoteResponse{CurrentPrice: 600, PreviousClose: 594, PercentChange: 1.01})
+	mock.AddQuote("QQQ", &QuoteResponse{CurrentPrice: 500, PreviousClose: 505, PercentChange: -0.99})
+	SetDefaultClient(mock)
+	defer ResetDefaultClient()
+
+	SetLastPriceSource(fakeLastPriceSource{"SPY": 590})
+	defer SetLastPriceSource(nil)
+
+	taskConfig := &config.TaskConfig{MarketOverviewSymbols: []string{"SPY", "QQQ"}}
+	embed, err := marketOverview(ctx, "收盤市場概況", taskConfig, true, func(ctx context.Context) (float64, error) {
+		return 2500.5, nil
+	})
+	if err != nil {
+		t.Fatalf("marketOverview() unexpected error = %v", err)
+	}
+	if embed.Fields[0].Value != "600.00 (+1.01 %)" || embed.Fields[1].Value != "500.00 (-0.99 %)" {
+		t.Errorf("marketOverview() fields = %s, %s, want regular-session change", embed.Fields[0].Value, embed.Fields[1].Value)
+	}
+	if embed.Description != "上漲 1 / 下跌 1" {
+		t.Errorf("marketOverview() description = %v, want 上漲 1 / 下跌 1", embed.Description)
+	}
+
+	// 逾時後不再派發查詢
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	embed, err = marketOverview(canceled, "收盤市場概況", &config.TaskConfig{MarketOverviewSymbols: []string{"SPY", "QQQ", "SOXX"}, CheckChangeMaxConcurrency: 1}, true, func(ctx context.Context) (float64, error) {
+		return 0, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("marketOverview() unexpected error = %v", err)
+	}
+	for _, field := range embed.Fields {
+		if field.Value != "取得失敗" {
+			t.Errorf("canceled field %s = %v, want 取得失敗", field.Name, field.Value)
+		}
+	}
+}
@@ -2,16 +2,26 @@ package stock
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	corporateactiondao "discordBot/model/dao/corporateaction"
+	dividenddao "discordBot/model/dao/dividend"
+	pricealertdao "discordBot/model/dao/pricealert"
+	snapshotdao "discordBot/model/dao/snapshot"
 	stockdao "discordBot/model/dao/stock"
 	"discordBot/model/dto"
 )
 
 // MockFinnhubClient FinnhubClient 的 mock 實現
 type MockFinnhubClient struct {
-	Quotes map[string]*QuoteResponse
-	Err    error
+	Quotes    map[string]*QuoteResponse
+	Earnings  []*EarningsEvent
+	Candles   map[string]*Candles
+	Dividends []*DividendEvent
+	Splits    []*SplitEvent
+	Profiles  map[string]*CompanyProfile
+	Err       error
 }
 
 // GetQuote 實現 FinnhubClient 接口
@@ -28,10 +38,102 @@ func (m *MockFinnhubClient) GetQuote(ctx context.Context, symbol string) (*Quote
 	return &QuoteResponse{}, nil
 }
 
+// GetEarningsCalendar 實現 FinnhubClient 接口
+func (m *MockFinnhubClient) GetEarningsCalendar(ctx context.Context, from, to string) ([]*EarningsEvent, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var events []*EarningsEvent
+	for _, e := range m.Earnings {
+		if e.Date >= from && e.Date <= to {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// GetCandles 實現 FinnhubClient 接口（依時間區間過濾）
+func (m *MockFinnhubClient) GetCandles(ctx context.Context, symbol string, resolution string, from, to int64) (*Candles, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	ret := &Candles{}
+	candles, ok := m.Candles[symbol]
+	if !ok {
+		return ret, nil
+	}
+
+	for i, ts := range candles.Timestamp {
+		if ts < from || ts > to {
+			continue
+		}
+		ret.Timestamp = append(ret.Timestamp, ts)
+		ret.Close = append(ret.Close, candles.Close[i])
+		if i < len(candles.Open) {
+			ret.Open = append(ret.Open, candles.Open[i])
+		}
+		if i < len(candles.High) {
+			ret.High = append(ret.High, candles.High[i])
+		}
+		if i < len(candles.Low) {
+			ret.Low = append(ret.Low, candles.Low[i])
+		}
+		if i < len(candles.Volume) {
+			ret.Volume = append(ret.Volume, candles.Volume[i])
+		}
+	}
+	return ret, nil
+}
+
+// GetDividends 實現 FinnhubClient 接口（依標的與除息日區間過濾）
+func (m *MockFinnhubClient) GetDividends(ctx context.Context, symbol string, from, to string) ([]*DividendEvent, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var events []*DividendEvent
+	for _, e := range m.Dividends {
+		if e.Symbol == symbol && e.ExDate >= from && e.ExDate <= to {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// GetSplits 實現 FinnhubClient 接口（依標的與生效日區間過濾）
+func (m *MockFinnhubClient) GetSplits(ctx context.Context, symbol string, from, to string) ([]*SplitEvent, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var events []*SplitEvent
+	for _, e := range m.Splits {
+		if e.Symbol == symbol && e.Date >= from && e.Date <= to {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// GetCompanyProfile 實現 FinnhubClient 接口（查無資料時回傳空資料）
+func (m *MockFinnhubClient) GetCompanyProfile(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	if profile, ok := m.Profiles[symbol]; ok {
+		return profile, nil
+	}
+	return &CompanyProfile{Symbol: symbol}, nil
+}
+
 // NewMockFinnhubClient 創建一個新的 mock client
 func NewMockFinnhubClient() *MockFinnhubClient {
 	return &MockFinnhubClient{
-		Quotes: make(map[string]*QuoteResponse),
+		Quotes:  make(map[string]*QuoteResponse),
+		Candles: make(map[string]*Candles),
 	}
 }
 
@@ -44,6 +146,7 @@ func (m *MockFinnhubClient) AddQuote(symbol string, quote *QuoteResponse) {
 type MockRedisClient struct {
 	Data  map[string]string
 	Lists map[string][]string
+	Sets  map[string]map[string]struct{}
 	Err   error
 }
 
@@ -52,6 +155,7 @@ func NewMockRedisClient() *MockRedisClient {
 	return &MockRedisClient{
 		Data:  make(map[string]string),
 		Lists: make(map[string][]string),
+		Sets:  make(map[string]map[string]struct{}),
 	}
 }
 
@@ -68,7 +172,7 @@ func (m *MockRedisClient) Set(ctx context.Context, key string, value interface{}
 	if m.Err != nil {
 		return m.Err
 	}
-	m.Data[key] = value.(string)
+	m.Data[key] = fmt.Sprint(value)
 	return nil
 }
 
@@ -84,6 +188,67 @@ func (m *MockRedisClient) LRange(ctx context.Context, key string, start, stop in
 	return list, nil
 }
 
+// Del 實現 Client 接口
+func (m *MockRedisClient) Del(ctx context.Context, key string) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	delete(m.Data, key)
+	delete(m.Lists, key)
+	delete(m.Sets, key)
+	return nil
+}
+
+// SAdd 實現 Client 接口
+func (m *MockRedisClient) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	set, ok := m.Sets[key]
+	if !ok {
+		set = make(map[string]struct{})
+		m.Sets[key] = set
+	}
+	var added int64
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// SRem 實現 Client 接口
+func (m *MockRedisClient) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := m.Sets[key][member]; ok {
+			delete(m.Sets[key], member)
+			removed++
+		}
+	}
+	if len(m.Sets[key]) == 0 {
+		delete(m.Sets, key)
+	}
+	return removed, nil
+}
+
+// SMembers 實現 Client 接口
+func (m *MockRedisClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	members := make([]string, 0, len(m.Sets[key]))
+	for member := range m.Sets[key] {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
 // MockStockRepository Stock Repository 的 mock 實現
 type MockStockRepository struct {
 	Stocks []*dto.Stock
@@ -95,5 +260,217 @@ func (m *MockStockRepository) Get(ctx context.Context, input *stockdao.GetInput)
 	if m.Err != nil {
 		return nil, m.Err
 	}
-	return m.Stocks, nil
+	// 與 DAO 相同，沒有查詢條件時回傳錯誤
+	if input == nil || (input.UserID == "" && input.Symbol == "") {
+		return nil, fmt.Errorf("sql 語法錯誤")
+	}
+
+	var ret []*dto.Stock
+	for _, s := range m.Stocks {
+		if input.Symbol != "" && s.Symbol != input.Symbol {
+			continue
+		}
+		if input.UserID == "" || s.UserID == "" || s.UserID == input.UserID {
+			ret = append(ret, s)
+		}
+	}
+	return ret, nil
+}
+
+// GetUserIDs 實現 StockRepository 接口
+func (m *MockStockRepository) GetUserIDs(ctx context.Context) ([]string, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	seen := make(map[string]bool)
+	var ret []string
+	for _, s := range m.Stocks {
+		if !seen[s.UserID] {
+			seen[s.UserID] = true
+			ret = append(ret, s.UserID)
+		}
+	}
+	return ret, nil
+}
+
+// MockUserSettingRepository UserSetting Repository 的 mock 實現
+type MockUserSettingRepository struct {
+	Settings map[string]*dto.UserSetting
+	Err      error
+}
+
+// GetSetting 實現 UserSettingRepository 接口
+func (m *MockUserSettingRepository) GetSetting(ctx context.Context, userID string) (*dto.UserSetting, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Settings[userID], nil
+}
+
+// MockSnapshotRepository Snapshot Repository 的 mock 實現
+type MockSnapshotRepository struct {
+	Snapshots []*dto.PortfolioSnapshot
+	Err       error
+}
+
+// SaveSnapshots 實現 SnapshotRepository 接口
+func (m *MockSnapshotRepository) SaveSnapshots(ctx context.Context, snapshots []*dto.PortfolioSnapshot) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Snapshots = append(m.Snapshots, snapshots...)
+	return nil
+}
+
+// GetSnapshots 實現 SnapshotRepository 接口（Snapshots 需依日期由舊到新排列）
+func (m *MockSnapshotRepository) GetSnapshots(ctx context.Context, input *snapshotdao.GetInput) ([]*dto.PortfolioSnapshot, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var ret []*dto.PortfolioSnapshot
+	for _, s := range m.Snapshots {
+		if s.UserID != input.UserID || (input.Symbol != "" && s.Symbol != input.Symbol) {
+			continue
+		}
+		if (!input.From.IsZero() && s.Date.Before(input.From)) || (!input.To.IsZero() && s.Date.After(input.To)) {
+			continue
+		}
+		ret = append(ret, s)
+	}
+
+	if input.Desc {
+		for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+			ret[i], ret[j] = ret[j], ret[i]
+		}
+	}
+	if input.Limit > 0 && len(ret) > input.Limit {
+		ret = ret[:input.Limit]
+	}
+	return ret, nil
+}
+
+// MockDividendRepository Dividend Repository 的 mock 實現
+type MockDividendRepository struct {
+	Dividends []*dto.Dividend
+	Err       error
+}
+
+// find 依使用者、標的與發放日尋找既有紀錄
+func (m *MockDividendRepository) find(d *dto.Dividend) int {
+	for i, existing := range m.Dividends {
+		if existing.UserID == d.UserID && existing.Symbol == d.Symbol && existing.PayDate.Equal(d.PayDate) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SaveDividend 實現 DividendRepository 接口（同日覆蓋）
+func (m *MockDividendRepository) SaveDividend(ctx context.Context, dividend *dto.Dividend) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if i := m.find(dividend); i >= 0 {
+		m.Dividends[i] = dividend
+		return nil
+	}
+	m.Dividends = append(m.Dividends, dividend)
+	return nil
+}
+
+// AddDividends 實現 DividendRepository 接口（同日已存在時略過）
+func (m *MockDividendRepository) AddDividends(ctx context.Context, dividends []*dto.Dividend) (int64, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	var inserted int64
+	for _, d := range dividends {
+		if m.find(d) >= 0 {
+			continue
+		}
+		m.Dividends = append(m.Dividends, d)
+		inserted++
+	}
+	return inserted, nil
+}
+
+// GetDividends 實現 DividendRepository 接口
+func (m *MockDividendRepository) GetDividends(ctx context.Context, input *dividenddao.GetInput) ([]*dto.Dividend, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var ret []*dto.Dividend
+	for _, d := range m.Dividends {
+		if d.UserID != input.UserID || (input.Symbol != "" && d.Symbol != input.Symbol) {
+			continue
+		}
+		if (!input.From.IsZero() && d.PayDate.Before(input.From)) || (!input.To.IsZero() && d.PayDate.After(input.To)) {
+			continue
+		}
+		ret = append(ret, d)
+	}
+	return ret, nil
+}
+
+// MockCorporateActionRepository CorporateAction Repository 的 mock 實現
+type MockCorporateActionRepository struct {
+	Actions []*dto.CorporateAction
+	Err     error
+}
+
+// GetCorporateActions 實現 CorporateActionRepository 接口
+func (m *MockCorporateActionRepository) GetCorporateActions(ctx context.Context, input *corporateactiondao.GetInput) ([]*dto.CorporateAction, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var ret []*dto.CorporateAction
+	for _, a := range m.Actions {
+		if a.UserID != input.UserID || (input.Symbol != "" && a.Symbol != input.Symbol) || (input.Type != "" && a.Type != input.Type) {
+			continue
+		}
+		ret = append(ret, a)
+	}
+	return ret, nil
+}
+
+// MockPriceAlertRepository PriceAlert Repository 的 mock 實現
+type MockPriceAlertRepository struct {
+	Alerts  []*dto.PriceAlert
+	Updated []*dto.PriceAlert
+	Err     error
+}
+
+// GetPriceAlerts 實現 PriceAlertRepository 接口
+func (m *MockPriceAlertRepository) GetPriceAlerts(ctx context.Context, input *pricealertdao.GetInput) ([]*dto.PriceAlert, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	var ret []*dto.PriceAlert
+	for _, a := range m.Alerts {
+		if (input.UserID != "" && a.UserID != input.UserID) || (input.Active && !a.Armed && a.RearmPercent <= 0) {
+			continue
+		}
+		ret = append(ret, a)
+	}
+	return ret, nil
+}
+
+// UpdatePriceAlertState 實現 PriceAlertRepository 接口
+func (m *MockPriceAlertRepository) UpdatePriceAlertState(ctx context.Context, alert *dto.PriceAlert) error {
+	if m.Err != nil {
+		return m.Err
+	}
+
+	m.Updated = append(m.Updated, alert)
+	for i, a := range m.Alerts {
+		if a.ID == alert.ID {
+			m.Alerts[i] = alert
+		}
+	}
+	return nil
 }
@@ -0,0 +1,236 @@
+package stock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	snapshotdao "discordBot/model/dao/snapshot"
+	"discordBot/model/dto"
+	"discordBot/pkg/chart"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+)
+
+// Performance : 繪製呼叫者在指定區間的市值與成本走勢圖，可選擇比較基準
+func Performance(ctx context.Context, m *discordgo.MessageCreate) (*discordgo.MessageSend, error) {
+	// example : $perf 1M SPY
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) < 2 || len(strSlice) > 3 {
+		return nil, fmt.Errorf("參數錯誤，格式: $perf <1W|1M|YTD|1Y> [benchmark]")
+	}
+
+	var benchmark string
+	if len(strSlice) == 3 {
+		benchmark = strings.ToUpper(strSlice[2])
+	}
+
+	return performanceMessage(ctx, snapshotDaoDeps{}, GetClient("finnhub"), m.Author.ID, strSlice[1], benchmark)
+}
+
+// performanceMessage 產生附帶績效圖的訊息
+func performanceMessage(ctx context.Context, snapshotRepo SnapshotRepository, client FinnhubClient, userID string, code string, benchmark string) (*discordgo.MessageSend, error) {
+	code = strings.ToUpper(code)
+	today := snapshotDate(nowFunc())
+	from, err := historyRangeStart(code, today)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := snapshotRepo.GetSnapshots(ctx, &snapshotdao.GetInput{
+		UserID: userID,
+		Symbol: dto.PortfolioSnapshotTotal,
+		From:   from,
+		To:     today,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots = latestCurrencySnapshots(snapshots)
+	if len(snapshots) < 2 {
+		return nil, fmt.Errorf("%s 區間內快照不足，至少需要 2 筆", code)
+	}
+
+	lineChart, legend := performanceChart(ctx, client, snapshots, benchmark)
+
+	var buf bytes.Buffer
+	if err := lineChart.Render(&buf); err != nil {
+		return nil, err
+	}
+
+	return &discordgo.MessageSend{
+		Content: formatHistory(code, snapshots) + "\n" + legend,
+		Files: []*discordgo.File{
+			{
+				Name:        "performance.png",
+				ContentType: "image/png",
+				Reader:      &buf,
+			},
+		},
+	}, nil
+}
+
+// performanceChart 組合市值、成本與比較基準的折線圖，回傳圖表與圖例說明
+func performanceChart(ctx context.Context, client FinnhubClient, snapshots []*dto.PortfolioSnapshot, benchmark string) (*chart.LineChart, string) {
+	labels := make([]string, len(snapshots))
+	values := make([]float64, len(snapshots))
+	costs := make([]float64, len(snapshots))
+	for i, snapshot := range snapshots {
+		labels[i] = snapshot.Date.Format("01-02")
+		values[i] = snapshot.Value
+		costs[i] = snapshot.Cost
+	}
+
+	lineChart := &chart.LineChart{
+		Labels: labels,
+		Series: []chart.Series{
+			{Name: "市值", Color: chart.Blue, Values: values},
+			{Name: "成本", Color: chart.Gray, Values: costs},
+		},
+	}
+	legend := "藍線: 市值, 灰線: 成本"
+
+	if benchmark == "" {
+		return lineChart, legend
+	}
+
+	benchmarkValues, err := benchmarkSeries(ctx, client, benchmark, snapshots)
+	if errors.Is(err, ErrPlanRestricted) {
+		// 免費方案無法取得日線，略過比較基準
+		logger.Warn("Finnhub 方案不支援日線，略過比較基準", "symbol", benchmark)
+		return lineChart, legend + fmt.Sprintf("（%s 比較基準需 Finnhub 付費方案，已略過）", benchmark)
+	}
+	if err != nil {
+		logger.Error("取得比較基準失敗", "symbol", benchmark, "error", err)
+		return lineChart, legend + fmt.Sprintf("（%s 取得失敗: %v）", benchmark, err)
+	}
+
+	lineChart.Series = append(lineChart.Series, chart.Series{Name: benchmark, Color: chart.Orange, Values: benchmarkValues})
+	return lineChart, legend + fmt.Sprintf(", 橘線: %s（以期初市值為基準）", benchmark)
+}
+
+// benchmarkSeries 取得比較基準日線，依快照日期對齊並換算成與期初市值相同的起點
+func benchmarkSeries(ctx context.Context, client FinnhubClient, symbol string, snapshots []*dto.PortfolioSnapshot) ([]float64, error) {
+	first := snapshots[0].Date
+	last := snapshots[len(snapshots)-1].Date
+
+	candles, err := client.GetCandles(ctx, symbol, "D", first.AddDate(0, 0, -7).Unix(), last.AddDate(0, 0, 1).Unix())
+	if err != nil {
+		return nil, err
+	}
+	if len(candles.Close) == 0 {
+		return nil, fmt.Errorf("查無 %s 日線資料", symbol)
+	}
+
+	// 日線時間戳為 UTC 當日 00:00，取每個快照日期當天或之前最近的收盤價
+	closes := make([]float64, len(snapshots))
+	idx := -1
+	for i, snapshot := range snapshots {
+		for idx+1 < len(candles.Timestamp) && !time.Unix(candles.Timestamp[idx+1], 0).UTC().After(snapshot.Date) {
+			idx++
+		}
+		if idx < 0 {
+			closes[i] = math.NaN()
+			continue
+		}
+		closes[i] = float64(candles.Close[idx])
+	}
+
+	// 以第一個同時有快照市值與收盤價的日期為基準
+	scale := math.NaN()
+	for i, c := range closes {
+		if !math.IsNaN(c) && c > 0 && snapshots[i].Value > 0 {
+			scale = snapshots[i].Value / c
+			break
+		}
+	}
+	if math.IsNaN(scale) {
+		return nil, fmt.Errorf("%s 日線與快照日期無法對齊", symbol)
+	}
+
+	for i := range closes {
+		closes[i] *= scale
+	}
+	return closes, nil
+}
+
+// WeeklyReport : 發送每週投資組合報告（近一週表現與近一個月績效圖）
+func WeeklyReport(s *discordgo.Session) {
+	WeeklyReportWithDeps(s, stockDaoDeps{}, userSettingDaoDeps{}, snapshotDaoDeps{})
+}
+
+// WeeklyReportWithDeps 使用指定依賴發送每週報告（用於測試）
+func WeeklyReportWithDeps(s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, snapshotRepo SnapshotRepository) {
+	taskConfig := config.GetTaskConfig()
+	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), durationFromSeconds(taskConfig.CalculateProfitTimeoutSeconds, 3*time.Minute))
+	defer cancel()
+
+	userIDs, err := repo.GetUserIDs(ctx)
+	if err != nil {
+		logger.Error("取得使用者清單失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"weekly_report:get_users",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.ProfitReportChannelID,
+				Content:   fmt.Sprintf("每週報告取使用者清單時錯誤: %v", err),
+			},
+		)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := sendWeeklyReport(ctx, s, repo, settingRepo, snapshotRepo, taskConfig, userID); err != nil {
+			logger.Error("發送每週報告失敗", "userID", userID, "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"weekly_report:"+userID,
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.ProfitReportChannelID,
+					Content:   fmt.Sprintf("發送 <@%s> 每週報告時錯誤: %v", userID, err),
+				},
+			)
+		}
+	}
+
+	logger.Info("完成每週報告")
+}
+
+// sendWeeklyReport 發送單一使用者的每週報告
+func sendWeeklyReport(ctx context.Context, s *discordgo.Session, repo StockRepository, settingRepo UserSettingRepository, snapshotRepo SnapshotRepository, taskConfig *config.TaskConfig, userID string) error {
+	setting, err := settingRepo.GetSetting(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("取使用者設定錯誤: %w", err)
+	}
+	if setting != nil && !setting.ReportEnabled {
+		return nil
+	}
+
+	week, err := history(ctx, repo, snapshotRepo, userID, "1W")
+	if err != nil {
+		logger.Info("略過每週報告", "userID", userID, "reason", err)
+		return nil
+	}
+
+	msg := &discordgo.MessageSend{}
+	perf, err := performanceMessage(ctx, snapshotRepo, GetClient("finnhub"), userID, "1M", taskConfig.PerformanceBenchmark)
+	if err != nil {
+		logger.Warn("每週報告無法產生績效圖", "userID", userID, "error", err)
+		msg.Content = fmt.Sprintf("<@%s> 每週投資組合報告\n%s", userID, week)
+	} else {
+		msg.Content = fmt.Sprintf("<@%s> 每週投資組合報告\n%s\n\n%s", userID, week, perf.Content)
+		msg.Files = perf.Files
+	}
+
+	return sendToReportTarget(s, setting, taskConfig.ProfitReportChannelID, userID, msg)
+}
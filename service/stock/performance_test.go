@@ -0,0 +1,111 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_benchmarkSeries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC) }
+
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.Candles["SPY"] = &Candles{
+		Close:     []float32{100, 110, 99},
+		Timestamp: []int64{day(7).Unix(), day(8).Unix(), day(10).Unix()},
+	}
+
+	snapshots := []*dto.PortfolioSnapshot{
+		{Date: day(6), Value: 900},
+		{Date: day(7), Value: 1000},
+		{Date: day(9), Value: 1200},
+		{Date: day(10), Value: 1100},
+	}
+
+	got, err := benchmarkSeries(context.Background(), mockFinnhub, "SPY", snapshots)
+	if err != nil {
+		t.Fatalf("benchmarkSeries() unexpected error = %v", err)
+	}
+
+	// 7/6 沒有收盤價；7/9 沿用 7/8 收盤價；以 7/7 市值 1000 為基準
+	want := []float64{math.NaN(), 1000, 1100, 990}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("benchmarkSeries()[%d] = %v, want NaN", i, got[i])
+			}
+			continue
+		}
+		if !floatEqual(got[i], want[i]) {
+			t.Errorf("benchmarkSeries()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if _, err := benchmarkSeries(context.Background(), mockFinnhub, "QQQ", snapshots); err == nil {
+		t.Errorf("benchmarkSeries() error = nil, want no data error")
+	}
+}
+
+func Test_performanceMessage(t *testing.T) {
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC) }
+
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.Candles["SPY"] = &Candles{
+		Close:     []float32{500, 510, 505},
+		Timestamp: []int64{day(10).Unix(), day(13).Unix(), day(15).Unix()},
+	}
+	restricted := NewMockFinnhubClient()
+	restricted.Err = fmt.Errorf("%w: 403 Forbidden", ErrPlanRestricted)
+
+	repo := &MockSnapshotRepository{
+		Snapshots: []*dto.PortfolioSnapshot{
+			{UserID: "u1", Date: day(10), Symbol: dto.PortfolioSnapshotTotal, Value: 1000, Cost: 900, Profit: 100},
+			{UserID: "u1", Date: day(13), Symbol: dto.PortfolioSnapshotTotal, Value: 1100, Cost: 900, Profit: 200},
+			{UserID: "u1", Date: day(15), Symbol: dto.PortfolioSnapshotTotal, Value: 1050, Cost: 950, Profit: 100},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		client    FinnhubClient
+		benchmark string
+		want      string
+	}{
+		{name: "without benchmark", client: mockFinnhub, want: "藍線: 市值, 灰線: 成本"},
+		{name: "with benchmark", client: mockFinnhub, benchmark: "SPY", want: "橘線: SPY（以期初市值為基準）"},
+		{name: "benchmark without data", client: mockFinnhub, benchmark: "QQQ", want: "QQQ 取得失敗"},
+		{name: "candles not in plan", client: restricted, benchmark: "SPY", want: "SPY 比較基準需 Finnhub 付費方案，已略過"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := performanceMessage(context.Background(), repo, tt.client, "u1", "1m", tt.benchmark)
+			if err != nil {
+				t.Fatalf("performanceMessage() unexpected error = %v", err)
+			}
+			if !strings.Contains(got.Content, tt.want) || !strings.Contains(got.Content, "1M 投資組合表現") {
+				t.Errorf("performanceMessage() content = %q, want contains %q", got.Content, tt.want)
+			}
+			if len(got.Files) != 1 || got.Files[0].ContentType != "image/png" {
+				t.Fatalf("performanceMessage() files = %+v, want one png", got.Files)
+			}
+			if _, err := png.Decode(got.Files[0].Reader); err != nil {
+				t.Errorf("performanceMessage() invalid png: %v", err)
+			}
+		})
+	}
+
+	if _, err := performanceMessage(context.Background(), repo, mockFinnhub, "u2", "1M", ""); err == nil {
+		t.Errorf("performanceMessage() error = nil, want not enough snapshots")
+	}
+}
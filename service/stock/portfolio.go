@@ -0,0 +1,299 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	dividenddao "discordBot/model/dao/dividend"
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/exchange"
+)
+
+// PositionView 附帶現價的持倉
+type PositionView struct {
+	*Position
+	Price float64
+	Value float64
+	// 未實現損益與報酬率（%）
+	Profit        float64
+	ProfitPercent float64
+	// 換算基準幣別後的市值
+	BaseValue float64
+	// 佔投資組合市值比例（%）
+	Allocation float64
+	// 累計股息收入
+	DividendIncome float64
+	Err            error
+}
+
+// Portfolio 使用者投資組合，總計金額皆已換算為基準幣別
+type Portfolio struct {
+	Method         CostMethod
+	BaseCurrency   string
+	Views          []*PositionView
+	TotalCost      float64
+	TotalValue     float64
+	TotalProfit    float64
+	RealizedProfit float64
+	DividendIncome float64
+}
+
+// GetPosition : 取得呼叫者單一標的的合併持倉
+func GetPosition(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $position TSLA
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) != 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $position <symbol>")
+	}
+	symbol := strings.ToUpper(strSlice[1])
+
+	portfolio, err := loadPortfolio(ctx, stockDaoDeps{}, userSettingDaoDeps{}, dividendDaoDeps{}, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, view := range portfolio.Views {
+		if view.Symbol == symbol {
+			return formatPosition(view, portfolio.Method), nil
+		}
+	}
+
+	return "", fmt.Errorf("未持有 %s", symbol)
+}
+
+// GetPortfolio : 取得呼叫者所有持倉，依市值排序並顯示配置比例
+func GetPortfolio(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $portfolio
+	portfolio, err := loadPortfolio(ctx, stockDaoDeps{}, userSettingDaoDeps{}, dividendDaoDeps{}, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(portfolio.Views) == 0 {
+		return "", fmt.Errorf("目前沒有持倉")
+	}
+
+	return formatPortfolio(portfolio), nil
+}
+
+// loadPositions 以使用者設定的成本計算方式推導所有標的持倉（含已全數賣出者）
+func loadPositions(ctx context.Context, repo StockRepository, settingRepo UserSettingRepository, userID string) ([]*Position, CostMethod, error) {
+	method, err := getCostMethod(ctx, settingRepo, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	positions, err := MatchLots(trades, method)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return positions, method, nil
+}
+
+// loadPortfolio 推導持倉、取得現價並加總股息收入，總計依使用者基準幣別換算
+func loadPortfolio(ctx context.Context, repo StockRepository, settingRepo UserSettingRepository, dividendRepo DividendRepository, userID string) (*Portfolio, error) {
+	positions, method, err := loadPositions(ctx, repo, settingRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dividends, err := dividendRepo.GetDividends(ctx, &dividenddao.GetInput{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	setting, err := settingRepo.GetSetting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	taskConfig := config.GetTaskConfig()
+	base := baseCurrency(setting, taskConfig.DefaultBaseCurrency)
+
+	rates, err := portfolioRates(ctx, positions, dividends, base)
+	if err != nil {
+		return nil, err
+	}
+	toBase := func(amount float64, currency string) float64 {
+		converted, _ := rates.Convert(amount, holdingCurrency(currency), base)
+		return converted
+	}
+
+	portfolio := buildPortfolio(ctx, taskConfig, OpenPositions(positions), toBase)
+	portfolio.Method = method
+	portfolio.BaseCurrency = base
+	for _, position := range positions {
+		portfolio.RealizedProfit += toBase(position.RealizedProfit, position.Currency)
+	}
+
+	// 各標的沿用原幣別的股息，總計換算為基準幣別
+	_, dividendBySymbol := dividendIncome(dividends)
+	for _, dividend := range dividends {
+		portfolio.DividendIncome += toBase(netDividend(dividend), dividend.Currency)
+	}
+	for _, view := range portfolio.Views {
+		view.DividendIncome = dividendBySymbol[view.Symbol]
+	}
+
+	return portfolio, nil
+}
+
+// portfolioRates 持倉或股息有非基準幣別時才取得匯率，並先確認所有幣別都能換算
+func portfolioRates(ctx context.Context, positions []*Position, dividends []*dto.Dividend, base string) (exchange.Rates, error) {
+	currencies := make(map[string]bool)
+	for _, position := range positions {
+		currencies[holdingCurrency(position.Currency)] = true
+	}
+	for _, dividend := range dividends {
+		currencies[holdingCurrency(dividend.Currency)] = true
+	}
+	delete(currencies, base)
+	if len(currencies) == 0 {
+		return exchange.Rates{}, nil
+	}
+
+	rates, err := getRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("取得匯率錯誤: %w", err)
+	}
+	for currency := range currencies {
+		if _, err := rates.Convert(1, currency, base); err != nil {
+			return nil, fmt.Errorf("換算 %s 至 %s 錯誤: %w", currency, base, err)
+		}
+	}
+	return rates, nil
+}
+
+// buildPortfolio 取得各持倉現價並計算市值、損益與配置比例，toBase 將原幣別金額換算為基準幣別
+func buildPortfolio(ctx context.Context, taskConfig *config.TaskConfig, positions []*Position, toBase func(amount float64, currency string) float64) *Portfolio {
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+	maxConcurrency := normalizeConcurrency(taskConfig.CalculateProfitMaxConcurrency, 5)
+
+	views := make([]*PositionView, len(positions))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+	for i, position := range positions {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, position *Position) {
+			defer wg.Done()
+			defer func() {
+				<-sem
+			}()
+
+			view := &PositionView{Position: position}
+			views[i] = view
+
+			quoteCtx, quoteCancel := context.WithTimeout(ctx, externalTimeout)
+			quote, err := GetClient("finnhub").GetQuote(quoteCtx, position.Symbol)
+			quoteCancel()
+			if err == nil && quote.CurrentPrice == 0 {
+				err = fmt.Errorf("搜尋失敗")
+			}
+			if err != nil {
+				logger.Error("取得持倉報價失敗", "symbol", position.Symbol, "error", err)
+				view.Err = err
+				return
+			}
+
+			view.Price = float64(quote.CurrentPrice)
+			view.Value = position.Units * view.Price
+			view.BaseValue = toBase(view.Value, position.Currency)
+			view.Profit = position.UnrealizedProfit(view.Price)
+			if position.Cost > 0 {
+				view.ProfitPercent = view.Profit / position.Cost * 100
+			}
+		}(i, position)
+	}
+	wg.Wait()
+
+	portfolio := &Portfolio{Views: views}
+	for _, view := range views {
+		if view.Err != nil {
+			continue
+		}
+		portfolio.TotalCost += toBase(view.Cost, view.Currency)
+		portfolio.TotalValue += view.BaseValue
+		portfolio.TotalProfit += toBase(view.Profit, view.Currency)
+	}
+
+	for _, view := range views {
+		if view.Err == nil && portfolio.TotalValue > 0 {
+			view.Allocation = view.BaseValue / portfolio.TotalValue * 100
+		}
+	}
+
+	// 依換算後市值由大到小排序，報價失敗者排最後
+	sort.SliceStable(views, func(i, j int) bool {
+		if (views[i].Err == nil) != (views[j].Err == nil) {
+			return views[i].Err == nil
+		}
+		return views[i].BaseValue > views[j].BaseValue
+	})
+
+	return portfolio
+}
+
+// formatPosition 單一標的持倉顯示格式
+func formatPosition(view *PositionView, method CostMethod) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s 持倉（%s）\n", view.Symbol, method.Label())
+	fmt.Fprintf(&b, "數量: %v 股\n", view.Units)
+	fmt.Fprintf(&b, "平均成本: %.2f %s\n", view.AveragePrice(), view.Currency)
+	fmt.Fprintf(&b, "總成本: %.2f\n", view.Cost)
+	if view.Err != nil {
+		fmt.Fprintf(&b, "目前價格: 取得失敗 (%v)\n", view.Err)
+	} else {
+		fmt.Fprintf(&b, "目前價格: %.2f\n", view.Price)
+		fmt.Fprintf(&b, "目前市值: %.2f\n", view.Value)
+		fmt.Fprintf(&b, "未實現損益: %.2f (%+.2f %%)\n", view.Profit, view.ProfitPercent)
+	}
+	fmt.Fprintf(&b, "已實現損益: %.2f\n", view.RealizedProfit)
+	fmt.Fprintf(&b, "累計股息: %.2f", view.DividendIncome)
+	return b.String()
+}
+
+// formatPortfolio 投資組合顯示格式，各標的以原幣別顯示，總計以基準幣別顯示
+func formatPortfolio(portfolio *Portfolio) string {
+	var b strings.Builder
+	b.WriteString("```\n")
+	fmt.Fprintf(&b, "%-8s %10s %4s %12s %12s %9s %7s\n", "標的", "數量", "幣別", "市值", "未實現損益", "報酬率", "配置")
+	for _, view := range portfolio.Views {
+		if view.Err != nil {
+			fmt.Fprintf(&b, "%-8s %10v %4s %12s\n", view.Symbol, view.Units, holdingCurrency(view.Currency), "報價失敗")
+			continue
+		}
+		fmt.Fprintf(&b, "%-8s %10v %4s %12.2f %12.2f %8.2f%% %6.2f%%\n",
+			view.Symbol, view.Units, holdingCurrency(view.Currency), view.Value, view.Profit, view.ProfitPercent, view.Allocation)
+	}
+	b.WriteString("```\n")
+
+	var totalPercent float64
+	if portfolio.TotalCost > 0 {
+		totalPercent = portfolio.TotalProfit / portfolio.TotalCost * 100
+	}
+	totalReturn := portfolio.TotalProfit + portfolio.RealizedProfit + portfolio.DividendIncome
+	fmt.Fprintf(&b, "總計（%s）: 總成本: %.2f, 總市值: %.2f, 未實現損益: %.2f (%+.2f %%), 已實現損益: %.2f, 股息收入: %.2f, 總報酬: %.2f（%s）",
+		portfolio.BaseCurrency, portfolio.TotalCost, portfolio.TotalValue, portfolio.TotalProfit, totalPercent, portfolio.RealizedProfit, portfolio.DividendIncome, totalReturn, portfolio.Method.Label())
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"discordBot/model/dto"
+	"discordBot/service/exchange"
+)
+
+func Test_loadPortfolio(t *testing.T) {
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 300})
+	mockFinnhub.AddQuote("AAPL", &QuoteResponse{CurrentPrice: 150})
+	SetDefaultClient(mockFinnhub)
+	defer ResetDefaultClient()
+
+	own := []*dto.Stock{
+		tradeAt(1, "AAPL", dto.StockSideBuy, 2, 100, 0),
+		tradeAt(2, "TSLA", dto.StockSideBuy, 2, 200, 0),
+		tradeAt(3, "MSFT", dto.StockSideBuy, 1, 300, 0),
+		tradeAt(4, "MSFT", dto.StockSideSell, 1, 350, 0),
+		tradeAt(5, "NVDA", dto.StockSideBuy, 1, 100, 0),
+	}
+	for _, trade := range own {
+		trade.UserID = "u1"
+	}
+	other := tradeAt(6, "TSLA", dto.StockSideBuy, 100, 1, 0)
+	other.UserID = "u2"
+
+	repo := &MockStockRepository{Stocks: append(own, other)}
+	settingRepo := &MockUserSettingRepository{Settings: map[string]*dto.UserSetting{"u1": {UserID: "u1", BaseCurrency: "USD"}}}
+
+	dividendRepo := &MockDividendRepository{
+		Dividends: []*dto.Dividend{
+			{UserID: "u1", Symbol: "TSLA", Amount: 3},
+			{UserID: "u1", Symbol: "MSFT", Amount: 2},
+			{UserID: "u2", Symbol: "TSLA", Amount: 100},
+		},
+	}
+
+	got, err := loadPortfolio(context.Background(), repo, settingRepo, dividendRepo, "u1")
+	if err != nil {
+		t.Fatalf("loadPortfolio() unexpected error = %v", err)
+	}
+
+	// NVDA 沒有報價，排在最後且不列入總值
+	wantOrder := []string{"TSLA", "AAPL", "NVDA"}
+	if len(got.Views) != len(wantOrder) {
+		t.Fatalf("loadPortfolio() = %d views, want %d", len(got.Views), len(wantOrder))
+	}
+	for i, symbol := range wantOrder {
+		if got.Views[i].Symbol != symbol {
+			t.Errorf("loadPortfolio() views[%d] = %s, want %s", i, got.Views[i].Symbol, symbol)
+		}
+	}
+
+	tsla := got.Views[0]
+	if !floatEqual(tsla.Value, 600) || !floatEqual(tsla.Profit, 200) || !floatEqual(tsla.ProfitPercent, 50) || !floatEqual(tsla.Allocation, 66.66666666666667) || !floatEqual(tsla.DividendIncome, 3) {
+		t.Errorf("loadPortfolio() TSLA = %+v", *tsla)
+	}
+	if got.Views[2].Err == nil {
+		t.Errorf("loadPortfolio() NVDA error = nil, want quote error")
+	}
+
+	if !floatEqual(got.TotalValue, 900) || !floatEqual(got.TotalCost, 600) || !floatEqual(got.TotalProfit, 300) || !floatEqual(got.RealizedProfit, 50) || !floatEqual(got.DividendIncome, 5) {
+		t.Errorf("loadPortfolio() totals = %+v", *got)
+	}
+	if got.Method != CostMethodAverage {
+		t.Errorf("loadPortfolio() method = %v, want average", got.Method)
+	}
+
+	text := formatPortfolio(got)
+	for _, want := range []string{"TSLA", "66.67%", "報價失敗", "總計（USD）", "已實現損益: 50.00", "股息收入: 5.00", "總報酬: 355.00"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("formatPortfolio() = %q, want contains %q", text, want)
+		}
+	}
+}
+
+func Test_loadPortfolio_BaseCurrency(t *testing.T) {
+	mockFinnhub := NewMockFinnhubClient()
+	mockFinnhub.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 300})
+	mockFinnhub.AddQuote("2330.TW", &QuoteResponse{CurrentPrice: 600})
+	SetDefaultClient(mockFinnhub)
+	defer ResetDefaultClient()
+
+	tw := tradeAt(1, "2330.TW", dto.StockSideBuy, 1000, 500, 0)
+	tw.Currency = "TWD"
+	trades := []*dto.Stock{tw, tradeAt(2, "TSLA", dto.StockSideBuy, 2, 200, 0)}
+	for _, trade := range trades {
+		trade.UserID = "u1"
+	}
+	us := tradeAt(3, "TSLA", dto.StockSideBuy, 2, 200, 0)
+	us.UserID = "u2"
+
+	repo := &MockStockRepository{Stocks: append(trades, us)}
+	settingRepo := &MockUserSettingRepository{Settings: map[string]*dto.UserSetting{
+		"u1": {UserID: "u1", BaseCurrency: "TWD"},
+		"u2": {UserID: "u2", BaseCurrency: "USD"},
+	}}
+	dividendRepo := &MockDividendRepository{
+		Dividends: []*dto.Dividend{{UserID: "u1", Symbol: "TSLA", Amount: 3, Currency: "USD"}},
+	}
+
+	defer func() { getRates = exchange.GetRatesWithContext }()
+	getRates = func(ctx context.Context) (exchange.Rates, error) {
+		return exchange.Rates{"USD": 1, "TWD": 30}, nil
+	}
+
+	got, err := loadPortfolio(context.Background(), repo, settingRepo, dividendRepo, "u1")
+	if err != nil {
+		t.Fatalf("loadPortfolio() unexpected error = %v", err)
+	}
+
+	// 各標的維持原幣別，總計與配置比例以台幣計算
+	if got.BaseCurrency != "TWD" || got.Views[0].Symbol != "2330.TW" || !floatEqual(got.Views[1].Value, 600) || !floatEqual(got.Views[1].BaseValue, 18000) {
+		t.Errorf("loadPortfolio() views = %+v, %+v", *got.Views[0], *got.Views[1])
+	}
+	if !floatEqual(got.TotalValue, 618000) || !floatEqual(got.TotalCost, 512000) || !floatEqual(got.TotalProfit, 106000) || !floatEqual(got.DividendIncome, 90) {
+		t.Errorf("loadPortfolio() totals = %+v", *got)
+	}
+	if !floatEqual(got.Views[0].Allocation, 600000.0/618000*100) {
+		t.Errorf("loadPortfolio() 2330.TW allocation = %v", got.Views[0].Allocation)
+	}
+	if text := formatPortfolio(got); !strings.Contains(text, "總計（TWD）: 總成本: 512000.00, 總市值: 618000.00") || !strings.Contains(text, " USD ") {
+		t.Errorf("formatPortfolio() = %q", text)
+	}
+
+	// 匯率取得失敗時，需要換算的使用者回傳錯誤，不需換算者照常計算
+	getRates = func(ctx context.Context) (exchange.Rates, error) {
+		return nil, errors.New("rates down")
+	}
+	if _, err := loadPortfolio(context.Background(), repo, settingRepo, dividendRepo, "u1"); err == nil {
+		t.Errorf("loadPortfolio(u1) error = nil, want rates error")
+	}
+	if got, err := loadPortfolio(context.Background(), repo, settingRepo, dividendRepo, "u2"); err != nil || !floatEqual(got.TotalValue, 600) {
+		t.Errorf("loadPortfolio(u2) = %+v, %v, want USD portfolio", got, err)
+	}
+}
+
+func Test_formatPosition(t *testing.T) {
+	view := &PositionView{
+		Position:      &Position{Symbol: "TSLA", Currency: "USD", Units: 4, Cost: 400, RealizedProfit: 10},
+		Price:         125,
+		Value:         500,
+		Profit:        100,
+		ProfitPercent: 25,
+	}
+
+	got := formatPosition(view, CostMethodFIFO)
+	for _, want := range []string{"TSLA 持倉（先進先出 (FIFO)）", "數量: 4 股", "平均成本: 100.00 USD", "未實現損益: 100.00 (+25.00 %)", "已實現損益: 10.00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatPosition() = %q, want contains %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package stock
+
+import (
+	"sort"
+
+	"discordBot/model/dto"
+)
+
+// unitsEpsilon 判斷持股歸零的誤差容忍
+const unitsEpsilon = 1e-9
+
+// Position 由交易紀錄推導出的持倉
+type Position struct {
+	Symbol   string
+	Currency string
+	// 目前持有數量
+	Units float64
+	// 目前持股成本（含買進手續費與稅）
+	Cost float64
+	// 已實現損益（賣出所得扣除賣出手續費、交易稅與對應成本）
+	RealizedProfit float64
+	// 尚未賣出的批次（依成本計算方式排列）
+	Lots []*Lot
+}
+
+// AveragePrice 每股平均成本
+func (p *Position) AveragePrice() float64 {
+	if p.Units <= unitsEpsilon {
+		return 0
+	}
+	return p.Cost / p.Units
+}
+
+// UnrealizedProfit 以指定現價計算未實現損益
+func (p *Position) UnrealizedProfit(price float64) float64 {
+	return p.Units*price - p.Cost
+}
+
+// DerivePositions 依交易時間順序推導各標的目前持倉（平均成本法）
+// 賣出數量超過持有數量時回傳錯誤，已全數賣出的標的不列入結果
+func DerivePositions(trades []*dto.Stock) ([]*Position, error) {
+	positions, err := MatchLots(trades, CostMethodAverage)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenPositions(positions), nil
+}
+
+// OpenPositions 過濾出仍有持股的持倉
+func OpenPositions(positions []*Position) []*Position {
+	ret := make([]*Position, 0, len(positions))
+	for _, position := range positions {
+		if position.Units > unitsEpsilon {
+			ret = append(ret, position)
+		}
+	}
+	return ret
+}
+
+// sortTrades 依交易時間排序（同時間依流水號），不修改原始 slice
+func sortTrades(trades []*dto.Stock) []*dto.Stock {
+	sorted := make([]*dto.Stock, 0, len(trades))
+	for _, trade := range trades {
+		if trade != nil {
+			sorted = append(sorted, trade)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].TradedAt.Equal(sorted[j].TradedAt) {
+			return sorted[i].TradedAt.Before(sorted[j].TradedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	return sorted
+}
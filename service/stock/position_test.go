@@ -0,0 +1,103 @@
+package stock
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func tradeAt(day int, symbol, side string, units, price, fee float64) *dto.Stock {
+	return &dto.Stock{
+		ID:       int64(day),
+		Symbol:   symbol,
+		Side:     side,
+		Units:    units,
+		Price:    price,
+		Fee:      fee,
+		Currency: "USD",
+		TradedAt: time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func Test_DerivePositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		trades  []*dto.Stock
+		want    []Position
+		wantErr bool
+	}{
+		{
+			name: "buys accumulate with fees",
+			trades: []*dto.Stock{
+				tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 1),
+				tradeAt(2, "tsla", dto.StockSideBuy, 10, 200, 1),
+			},
+			want: []Position{{Symbol: "TSLA", Currency: "USD", Units: 20, Cost: 3002}},
+		},
+		{
+			name: "sell reduces cost at average price",
+			trades: []*dto.Stock{
+				tradeAt(1, "TSLA", dto.StockSideBuy, 10, 100, 0),
+				tradeAt(2, "TSLA", dto.StockSideBuy, 10, 200, 0),
+				tradeAt(3, "TSLA", dto.StockSideSell, 5, 300, 0),
+			},
+			want: []Position{{Symbol: "TSLA", Currency: "USD", Units: 15, Cost: 2250}},
+		},
+		{
+			name: "fully sold position is omitted",
+			trades: []*dto.Stock{
+				tradeAt(1, "AAPL", dto.StockSideBuy, 1, 100, 0),
+				tradeAt(2, "TSLA", dto.StockSideBuy, 2, 100, 0),
+				tradeAt(3, "AAPL", dto.StockSideSell, 1, 120, 0),
+			},
+			want: []Position{{Symbol: "TSLA", Currency: "USD", Units: 2, Cost: 200}},
+		},
+		{
+			name: "legacy rows without side count as buys and are sorted by time",
+			trades: []*dto.Stock{
+				tradeAt(3, "TSLA", dto.StockSideSell, 1, 100, 0),
+				tradeAt(1, "TSLA", "", 2, 50, 0),
+			},
+			want: []Position{{Symbol: "TSLA", Currency: "USD", Units: 1, Cost: 50}},
+		},
+		{
+			name: "oversell",
+			trades: []*dto.Stock{
+				tradeAt(1, "TSLA", dto.StockSideBuy, 1, 100, 0),
+				tradeAt(2, "TSLA", dto.StockSideSell, 2, 100, 0),
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty ledger",
+			want: []Position{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DerivePositions(tt.trades)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DerivePositions() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DerivePositions() unexpected error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("DerivePositions() = %d positions, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Symbol != want.Symbol || got[i].Currency != want.Currency ||
+					math.Abs(got[i].Units-want.Units) > 1e-9 || math.Abs(got[i].Cost-want.Cost) > 1e-9 {
+					t.Errorf("DerivePositions()[%d] = %+v, want %+v", i, *got[i], want)
+				}
+			}
+		})
+	}
+}
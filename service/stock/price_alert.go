@@ -0,0 +1,369 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	pricealertdao "discordBot/model/dao/pricealert"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/discord"
+)
+
+// priceAlertMaxPerUser 每位使用者的價格警示上限
+const priceAlertMaxPerUser = 50
+
+// PriceAlertRepository 價格警示倉庫接口類型
+type PriceAlertRepository interface {
+	GetPriceAlerts(ctx context.Context, input *pricealertdao.GetInput) ([]*dto.PriceAlert, error)
+	UpdatePriceAlertState(ctx context.Context, alert *dto.PriceAlert) error
+}
+
+// priceAlertDaoDeps 封裝 PriceAlert DAO 依賴
+type priceAlertDaoDeps struct{}
+
+func (d priceAlertDaoDeps) GetPriceAlerts(ctx context.Context, input *pricealertdao.GetInput) ([]*dto.PriceAlert, error) {
+	return pricealertdao.Get(ctx, input)
+}
+
+func (d priceAlertDaoDeps) UpdatePriceAlertState(ctx context.Context, alert *dto.PriceAlert) error {
+	return pricealertdao.UpdState(ctx, nil, alert)
+}
+
+// priceAlertResult 單一價格警示的檢查結果
+type priceAlertResult struct {
+	Alert *dto.PriceAlert
+	// 檢查時的價格
+	Price float64
+	// 本次是否觸發
+	Fired bool
+	// 狀態是否需要寫回
+	Changed bool
+}
+
+// AlertCommand : 新增、列出或刪除呼叫者的價格警示
+func AlertCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $alert TSLA above 300 / $alert NVDA crosses 120 rearm=2% / $alert list / $alert delete 12
+	usage := fmt.Errorf("參數錯誤，格式: $alert <symbol> above|below|crosses <價格> [rearm=<%%>]、$alert list 或 $alert delete <id>")
+
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) < 2 {
+		return "", usage
+	}
+
+	repo := priceAlertDaoDeps{}
+	switch strings.ToLower(strSlice[1]) {
+	case "list":
+		if len(strSlice) != 2 {
+			return "", usage
+		}
+		alerts, err := repo.GetPriceAlerts(ctx, &pricealertdao.GetInput{UserID: m.Author.ID})
+		if err != nil {
+			return "", err
+		}
+		return formatPriceAlerts(alerts), nil
+	case "delete":
+		if len(strSlice) != 3 {
+			return "", usage
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(strSlice[2], "#"), 10, 64)
+		if err != nil || id <= 0 {
+			return "", fmt.Errorf("無效的警示編號: %s", strSlice[2])
+		}
+		affected, err := pricealertdao.Del(ctx, nil, m.Author.ID, id)
+		if err != nil {
+			return "", err
+		}
+		if affected == 0 {
+			return "", fmt.Errorf("找不到價格警示 #%d", id)
+		}
+		return fmt.Sprintf("已刪除價格警示 #%d", id), nil
+	}
+
+	alert, err := parsePriceAlert(strSlice[1:])
+	if err != nil {
+		return "", err
+	}
+	alert.UserID = m.Author.ID
+	alert.ChannelID = m.ChannelID
+
+	existing, err := repo.GetPriceAlerts(ctx, &pricealertdao.GetInput{UserID: m.Author.ID})
+	if err != nil {
+		return "", err
+	}
+	if len(existing) >= priceAlertMaxPerUser {
+		return "", fmt.Errorf("價格警示最多 %d 筆，請先以 $alert delete <id> 刪除", priceAlertMaxPerUser)
+	}
+
+	externalTimeout := durationFromSeconds(config.GetTaskConfig().ExternalCallTimeoutSeconds, 15*time.Second)
+	quoteCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+	quote, err := GetClient("finnhub").GetQuote(quoteCtx, alert.Symbol)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("驗證 %s 錯誤: %w", alert.Symbol, err)
+	}
+	if quote.CurrentPrice == 0 {
+		return "", fmt.Errorf("查無標的: %s", alert.Symbol)
+	}
+	alert.LastPrice = float64(quote.CurrentPrice)
+
+	id, err := pricealertdao.Ins(ctx, nil, alert)
+	if err != nil {
+		return "", err
+	}
+	alert.ID = id
+
+	res := fmt.Sprintf("已新增價格警示 #%d: %s（目前價格 %.2f），觸發時發送到此頻道", id, formatPriceAlertRule(alert), alert.LastPrice)
+	if fired, _ := evaluatePriceAlert(&dto.PriceAlert{Condition: alert.Condition, Price: alert.Price, Armed: true}, alert.LastPrice); fired {
+		res += "\n目前價格已符合條件，下次檢查時即會觸發"
+	}
+	return res, nil
+}
+
+// parsePriceAlert 解析 <symbol> above|below|crosses <價格> [rearm=<%>]
+func parsePriceAlert(args []string) (*dto.PriceAlert, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("參數錯誤，格式: $alert <symbol> above|below|crosses <價格> [rearm=<%%>]")
+	}
+
+	alert := &dto.PriceAlert{
+		Symbol: strings.ToUpper(args[0]),
+		Armed:  true,
+	}
+
+	switch condition := strings.ToLower(args[1]); condition {
+	case dto.PriceAlertAbove, dto.PriceAlertBelow, dto.PriceAlertCrosses:
+		alert.Condition = condition
+	default:
+		return nil, fmt.Errorf("不支援的條件: %s（可用 above、below、crosses）", args[1])
+	}
+
+	price, err := strconv.ParseFloat(args[2], 64)
+	if err != nil || price <= 0 {
+		return nil, fmt.Errorf("無效的價格: %s", args[2])
+	}
+	alert.Price = price
+
+	if len(args) == 4 {
+		value, ok := strings.CutPrefix(strings.ToLower(args[3]), "rearm=")
+		if !ok {
+			return nil, fmt.Errorf("不支援的參數: %s", args[3])
+		}
+		rearm, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || rearm < 0 || rearm >= 100 {
+			return nil, fmt.Errorf("無效的重新啟用百分比: %s", value)
+		}
+		alert.RearmPercent = rearm
+	}
+
+	return alert, nil
+}
+
+// evaluatePriceAlert 依目前價格判斷是否觸發，回傳是否觸發與更新後的狀態
+// 觸發後停用；設定 rearm 時，價格離開目標價超過該百分比（above 需跌回、below 需漲回）後重新啟用
+func evaluatePriceAlert(alert *dto.PriceAlert, price float64) (bool, *dto.PriceAlert) {
+	next := *alert
+	next.LastPrice = price
+	if price <= 0 {
+		next.LastPrice = alert.LastPrice
+		return false, &next
+	}
+
+	band := alert.Price * alert.RearmPercent / 100
+
+	if !alert.Armed {
+		if alert.RearmPercent <= 0 {
+			return false, &next
+		}
+		switch alert.Condition {
+		case dto.PriceAlertAbove:
+			next.Armed = price <= alert.Price-band
+		case dto.PriceAlertBelow:
+			next.Armed = price >= alert.Price+band
+		case dto.PriceAlertCrosses:
+			next.Armed = math.Abs(price-alert.Price) >= band
+		}
+		return false, &next
+	}
+
+	var fired bool
+	switch alert.Condition {
+	case dto.PriceAlertAbove:
+		fired = price >= alert.Price
+	case dto.PriceAlertBelow:
+		fired = price <= alert.Price
+	case dto.PriceAlertCrosses:
+		last := alert.LastPrice
+		fired = last > 0 && ((last < alert.Price && price >= alert.Price) || (last > alert.Price && price <= alert.Price))
+	}
+
+	if fired {
+		next.Armed = false
+		next.TriggeredAt = nowFunc()
+	}
+	return fired, &next
+}
+
+// evaluatePriceAlerts 取得各標的報價（同一標的只查詢一次）並判斷所有警示
+func evaluatePriceAlerts(ctx context.Context, externalTimeout time.Duration, alerts []*dto.PriceAlert) []*priceAlertResult {
+	prices := make(map[string]float64)
+	failed := make(map[string]bool)
+
+	var results []*priceAlertResult
+	for _, alert := range alerts {
+		if failed[alert.Symbol] {
+			continue
+		}
+
+		price, ok := prices[alert.Symbol]
+		if !ok {
+			quoteCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+			quote, err := GetClient("finnhub").GetQuote(quoteCtx, alert.Symbol)
+			cancel()
+			if err != nil || quote.CurrentPrice == 0 {
+				logger.Error("取得價格警示報價失敗", "symbol", alert.Symbol, "error", err)
+				failed[alert.Symbol] = true
+				continue
+			}
+			price = float64(quote.CurrentPrice)
+			prices[alert.Symbol] = price
+		}
+
+		fired, next := evaluatePriceAlert(alert, price)
+		results = append(results, &priceAlertResult{
+			Alert:   next,
+			Price:   price,
+			Fired:   fired,
+			Changed: next.Armed != alert.Armed || next.LastPrice != alert.LastPrice,
+		})
+	}
+
+	return results
+}
+
+// CheckPriceAlerts : 檢查所有價格警示
+func CheckPriceAlerts(s *discordgo.Session) {
+	CheckPriceAlertsWithDeps(s, priceAlertDaoDeps{})
+}
+
+// CheckPriceAlertsWithDeps 使用指定依賴檢查價格警示（用於測試）
+func CheckPriceAlertsWithDeps(s *discordgo.Session, repo PriceAlertRepository) {
+	taskConfig := config.GetTaskConfig()
+	taskErrorReporter.SetCooldown(durationFromSeconds(taskConfig.ErrorNotifyCooldownSeconds, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), durationFromSeconds(taskConfig.CheckChangeTimeoutSeconds, 2*time.Minute))
+	defer cancel()
+
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+
+	alerts, err := repo.GetPriceAlerts(ctx, &pricealertdao.GetInput{Active: true})
+	if err != nil {
+		logger.Error("取得價格警示失敗", "error", err)
+		taskErrorReporter.Notify(
+			s,
+			"price_alert:get",
+			&discord.SendMessageInput{
+				ChannelID: taskConfig.WatchListChannelID,
+				Content:   fmt.Sprintf("取得價格警示時錯誤: %v", err),
+			},
+		)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	fired := 0
+	for _, result := range evaluatePriceAlerts(ctx, externalTimeout, alerts) {
+		alert := result.Alert
+		if result.Fired {
+			_, err := s.ChannelMessageSendComplex(alert.ChannelID, &discordgo.MessageSend{
+				Content: formatPriceAlertMessage(alert, result.Price),
+				AllowedMentions: &discordgo.MessageAllowedMentions{
+					Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+				},
+			})
+			if err != nil {
+				// 不寫回狀態，下次檢查重新發送
+				logger.Error("發送價格警示失敗", "id", alert.ID, "symbol", alert.Symbol, "error", err)
+				taskErrorReporter.Notify(
+					s,
+					"price_alert:send:"+alert.UserID,
+					&discord.SendMessageInput{
+						ChannelID: taskConfig.WatchListChannelID,
+						Content:   fmt.Sprintf("發送 <@%s> 價格警示時錯誤: %v", alert.UserID, err),
+					},
+				)
+				continue
+			}
+			fired++
+		}
+
+		if !result.Changed {
+			continue
+		}
+		if err := repo.UpdatePriceAlertState(ctx, alert); err != nil {
+			logger.Error("更新價格警示狀態失敗", "id", alert.ID, "error", err)
+			taskErrorReporter.Notify(
+				s,
+				"price_alert:update",
+				&discord.SendMessageInput{
+					ChannelID: taskConfig.WatchListChannelID,
+					Content:   fmt.Sprintf("更新價格警示狀態時錯誤: %v", err),
+				},
+			)
+		}
+	}
+
+	logger.Info("完成價格警示檢查", "alerts", len(alerts), "fired", fired)
+}
+
+// formatPriceAlertRule 警示條件說明
+func formatPriceAlertRule(alert *dto.PriceAlert) string {
+	labels := map[string]string{
+		dto.PriceAlertAbove:   "高於",
+		dto.PriceAlertBelow:   "低於",
+		dto.PriceAlertCrosses: "穿越",
+	}
+	rule := fmt.Sprintf("%s %s %.2f", alert.Symbol, labels[alert.Condition], alert.Price)
+	if alert.RearmPercent > 0 {
+		rule += fmt.Sprintf("（離開 %s%% 後重新啟用）", formatWeight(alert.RearmPercent))
+	}
+	return rule
+}
+
+// formatPriceAlertMessage 價格警示觸發訊息
+func formatPriceAlertMessage(alert *dto.PriceAlert, price float64) string {
+	return fmt.Sprintf("<@%s> 價格警示 #%d: %s，目前價格 %.2f", alert.UserID, alert.ID, formatPriceAlertRule(alert), price)
+}
+
+// formatPriceAlerts 價格警示列表
+func formatPriceAlerts(alerts []*dto.PriceAlert) string {
+	if len(alerts) == 0 {
+		return "尚未設定價格警示，使用 $alert <symbol> above|below|crosses <價格> 新增"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("價格警示（%d）:", len(alerts)))
+	for _, alert := range alerts {
+		status := "啟用中"
+		if !alert.Armed {
+			status = "已觸發"
+			if alert.RearmPercent > 0 {
+				status = "等待重新啟用"
+			}
+			if !alert.TriggeredAt.IsZero() {
+				status += " " + alert.TriggeredAt.In(usExchange().Location).Format("01/02 15:04 ET")
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\n#%d %s [%s]", alert.ID, formatPriceAlertRule(alert), status))
+	}
+	return sb.String()
+}
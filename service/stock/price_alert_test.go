@@ -0,0 +1,167 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pricealertdao "discordBot/model/dao/pricealert"
+	"discordBot/model/dto"
+)
+
+func Test_parsePriceAlert(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    dto.PriceAlert
+		wantErr bool
+	}{
+		{name: "above", args: []string{"tsla", "above", "300"}, want: dto.PriceAlert{Symbol: "TSLA", Condition: dto.PriceAlertAbove, Price: 300, Armed: true}},
+		{name: "crosses with rearm", args: []string{"NVDA", "Crosses", "120.5", "rearm=2%"}, want: dto.PriceAlert{Symbol: "NVDA", Condition: dto.PriceAlertCrosses, Price: 120.5, RearmPercent: 2, Armed: true}},
+		{name: "unknown condition", args: []string{"TSLA", "near", "300"}, wantErr: true},
+		{name: "invalid price", args: []string{"TSLA", "below", "-1"}, wantErr: true},
+		{name: "invalid rearm", args: []string{"TSLA", "below", "200", "rearm=100"}, wantErr: true},
+		{name: "unknown option", args: []string{"TSLA", "below", "200", "once"}, wantErr: true},
+		{name: "missing price", args: []string{"TSLA", "below"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePriceAlert(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePriceAlert() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil || *got != tt.want {
+				t.Errorf("parsePriceAlert() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_evaluatePriceAlert(t *testing.T) {
+	tests := []struct {
+		name   string
+		alert  dto.PriceAlert
+		prices []float64
+		// 每個價格是否觸發
+		want string
+	}{
+		{name: "above fires once", alert: dto.PriceAlert{Condition: dto.PriceAlertAbove, Price: 100}, prices: []float64{95, 101, 102, 90, 105}, want: "01000"},
+		{name: "below fires once", alert: dto.PriceAlert{Condition: dto.PriceAlertBelow, Price: 100}, prices: []float64{105, 100, 99, 110, 90}, want: "01000"},
+		{name: "above with hysteresis", alert: dto.PriceAlert{Condition: dto.PriceAlertAbove, Price: 100, RearmPercent: 5}, prices: []float64{101, 99, 101, 95, 100}, want: "10001"},
+		{name: "below with hysteresis", alert: dto.PriceAlert{Condition: dto.PriceAlertBelow, Price: 100, RearmPercent: 5}, prices: []float64{99, 104, 99, 105, 99}, want: "10001"},
+		{name: "crosses needs previous side", alert: dto.PriceAlert{Condition: dto.PriceAlertCrosses, Price: 100}, prices: []float64{101, 102, 99, 101}, want: "0010"},
+		{name: "crosses with hysteresis", alert: dto.PriceAlert{Condition: dto.PriceAlertCrosses, Price: 100, RearmPercent: 2, LastPrice: 98}, prices: []float64{100.5, 99.5, 103, 99}, want: "1001"},
+		{name: "invalid price ignored", alert: dto.PriceAlert{Condition: dto.PriceAlertBelow, Price: 100}, prices: []float64{0, 99}, want: "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := tt.alert
+			alert.Armed = true
+			current := &alert
+
+			var got strings.Builder
+			for _, price := range tt.prices {
+				fired, next := evaluatePriceAlert(current, price)
+				if fired {
+					got.WriteString("1")
+					if next.Armed || next.TriggeredAt.IsZero() {
+						t.Errorf("fired alert = %+v, want disarmed with TriggeredAt", next)
+					}
+				} else {
+					got.WriteString("0")
+				}
+				current = next
+			}
+			if got.String() != tt.want {
+				t.Errorf("fired = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_evaluatePriceAlerts(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	repo := &MockPriceAlertRepository{Alerts: []*dto.PriceAlert{
+		{ID: 1, UserID: "u1", Symbol: "TSLA", Condition: dto.PriceAlertAbove, Price: 300, Armed: true, LastPrice: 280},
+		{ID: 2, UserID: "u2", Symbol: "TSLA", Condition: dto.PriceAlertCrosses, Price: 290, RearmPercent: 1, Armed: true, LastPrice: 280},
+		{ID: 3, UserID: "u1", Symbol: "BAD", Condition: dto.PriceAlertBelow, Price: 10, Armed: true},
+	}}
+
+	// check 模擬一次排程檢查：判斷、寫回狀態並回傳觸發的警示編號
+	check := func(price float32) []int64 {
+		mockClient.AddQuote("TSLA", &QuoteResponse{CurrentPrice: price})
+		alerts, err := repo.GetPriceAlerts(context.Background(), &pricealertdao.GetInput{Active: true})
+		if err != nil {
+			t.Fatalf("GetPriceAlerts() unexpected error = %v", err)
+		}
+
+		var fired []int64
+		for _, result := range evaluatePriceAlerts(context.Background(), time.Second, alerts) {
+			if result.Fired {
+				fired = append(fired, result.Alert.ID)
+			}
+			if result.Changed {
+				_ = repo.UpdatePriceAlertState(context.Background(), result.Alert)
+			}
+		}
+		return fired
+	}
+
+	steps := []struct {
+		price float32
+		want  []int64
+	}{
+		{price: 295, want: []int64{2}},
+		{price: 305, want: []int64{1}},
+		{price: 310},
+		// 穿越警示離開目標價 1% 以上後重新啟用，再次穿越時觸發
+		{price: 285, want: []int64{2}},
+		{price: 320},
+	}
+	for i, step := range steps {
+		got := check(step.price)
+		if len(got) != len(step.want) || (len(got) > 0 && got[0] != step.want[0]) {
+			t.Errorf("step %d price %v fired = %v, want %v", i, step.price, got, step.want)
+		}
+	}
+
+	// 一次性警示觸發後不再檢查；報價失敗的標的不寫回狀態
+	if active, _ := repo.GetPriceAlerts(context.Background(), &pricealertdao.GetInput{Active: true}); len(active) != 2 {
+		t.Errorf("active alerts = %d, want 2", len(active))
+	}
+	for _, updated := range repo.Updated {
+		if updated.Symbol == "BAD" {
+			t.Errorf("BAD alert updated without quote: %+v", updated)
+		}
+	}
+
+	mockClient.Err = errors.New("rate limited")
+	if results := evaluatePriceAlerts(context.Background(), time.Second, repo.Alerts); len(results) != 0 {
+		t.Errorf("results with quote error = %d, want 0", len(results))
+	}
+}
+
+func Test_formatPriceAlerts(t *testing.T) {
+	alerts := []*dto.PriceAlert{
+		{ID: 1, Symbol: "TSLA", Condition: dto.PriceAlertAbove, Price: 300, Armed: true},
+		{ID: 2, Symbol: "NVDA", Condition: dto.PriceAlertCrosses, Price: 120, RearmPercent: 2},
+		{ID: 3, Symbol: "VOO", Condition: dto.PriceAlertBelow, Price: 400},
+	}
+
+	got := formatPriceAlerts(alerts)
+	for _, want := range []string{"#1 TSLA 高於 300.00 [啟用中]", "#2 NVDA 穿越 120.00（離開 2% 後重新啟用） [等待重新啟用]", "#3 VOO 低於 400.00 [已觸發]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatPriceAlerts() = %q, want contains %q", got, want)
+		}
+	}
+}
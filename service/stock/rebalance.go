@@ -0,0 +1,507 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/model/dao/targetweight"
+	"discordBot/model/dto"
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+	"discordBot/service/exchange"
+)
+
+// TargetWeightRepository TargetWeight Repository 接口
+type TargetWeightRepository interface {
+	GetTargetWeights(ctx context.Context, userID string) ([]*dto.TargetWeight, error)
+}
+
+// targetWeightDaoDeps 封裝 TargetWeight DAO 依賴
+type targetWeightDaoDeps struct{}
+
+func (d targetWeightDaoDeps) GetTargetWeights(ctx context.Context, userID string) ([]*dto.TargetWeight, error) {
+	return targetweight.Get(ctx, userID)
+}
+
+// TargetCommand : 查詢、設定或刪除呼叫者的目標配置
+func TargetCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $target AAPL 30% / $target sector:Energy 10 / $target remove AAPL / $target clear
+	usage := fmt.Errorf("參數錯誤，格式: $target [<標的|%s:<名稱>> <權重%%>] | $target remove <目標> | $target clear",
+		strings.Join(allocationDimensions, "|"))
+
+	strSlice := strings.Fields(m.Content)
+	repo := targetWeightDaoDeps{}
+
+	if len(strSlice) == 1 {
+		targets, err := repo.GetTargetWeights(ctx, m.Author.ID)
+		if err != nil {
+			return "", err
+		}
+		return formatTargets(targets), nil
+	}
+
+	switch strings.ToLower(strSlice[1]) {
+	case "clear":
+		if len(strSlice) != 2 {
+			return "", usage
+		}
+		affected, err := targetweight.Del(ctx, nil, m.Author.ID, "")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("已清除 %d 筆目標配置", affected), nil
+	case "remove":
+		if len(strSlice) < 3 {
+			return "", usage
+		}
+		target, err := parseTargetKey(strings.Join(strSlice[2:], " "))
+		if err != nil {
+			return "", err
+		}
+		affected, err := targetweight.Del(ctx, nil, m.Author.ID, target)
+		if err != nil {
+			return "", err
+		}
+		if affected == 0 {
+			return "", fmt.Errorf("找不到目標配置: %s", target)
+		}
+		return "已刪除目標配置: " + target, nil
+	}
+
+	if len(strSlice) < 3 {
+		return "", usage
+	}
+
+	target, err := parseTargetKey(strings.Join(strSlice[1:len(strSlice)-1], " "))
+	if err != nil {
+		return "", err
+	}
+	weight, err := parseTargetWeight(strSlice[len(strSlice)-1])
+	if err != nil {
+		return "", err
+	}
+
+	targets, err := repo.GetTargetWeights(ctx, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+	total := weight
+	for _, t := range targets {
+		if t.Target != target {
+			total += t.Weight
+		}
+	}
+	if total > 100+1e-9 {
+		return "", fmt.Errorf("目標權重合計 %.2f%% 超過 100%%", total)
+	}
+
+	if err := targetweight.Upsert(ctx, nil, &dto.TargetWeight{UserID: m.Author.ID, Target: target, Weight: weight}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("已設定 %s 目標權重 %s%%（合計 %s%%，其餘為現金）", target, formatWeight(weight), formatWeight(total)), nil
+}
+
+// parseTargetKey 解析目標：標的代號（轉大寫）或 <分組方式>:<名稱>
+func parseTargetKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	dimension, name, isCategory := strings.Cut(key, ":")
+	if !isCategory {
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return "", fmt.Errorf("無效的標的: %s", key)
+		}
+		return strings.ToUpper(key), nil
+	}
+
+	dimension = strings.ToLower(strings.TrimSpace(dimension))
+	name = strings.TrimSpace(name)
+	if _, ok := allocationLabels[dimension]; !ok {
+		return "", fmt.Errorf("不支援的分類: %s (可用: %s)", dimension, strings.Join(allocationDimensions, ", "))
+	}
+	if name == "" {
+		return "", fmt.Errorf("分類名稱不可為空")
+	}
+	if dimension == allocationCurrency {
+		name = strings.ToUpper(name)
+	}
+	return dimension + ":" + name, nil
+}
+
+// parseTargetWeight 解析 0 到 100 之間的百分比權重，可加上 %
+func parseTargetWeight(value string) (float64, error) {
+	weight, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || weight < 0 || weight > 100 {
+		return 0, fmt.Errorf("無效的權重: %s（需介於 0 與 100 之間）", value)
+	}
+	return weight, nil
+}
+
+func formatWeight(weight float64) string {
+	return strconv.FormatFloat(weight, 'f', -1, 64)
+}
+
+// formatTargets 目標配置顯示格式
+func formatTargets(targets []*dto.TargetWeight) string {
+	if len(targets) == 0 {
+		return "尚未設定目標配置，使用 $target <標的|sector:<名稱>> <權重%> 設定"
+	}
+
+	var b strings.Builder
+	b.WriteString("目標配置:\n```\n")
+	var total float64
+	for _, t := range targets {
+		fmt.Fprintf(&b, "%-32s %6.2f%%\n", t.Target, t.Weight)
+		total += t.Weight
+	}
+	fmt.Fprintf(&b, "%-32s %6.2f%%\n", "現金", 100-total)
+	b.WriteString("```\n未設定目標的持倉，再平衡時建議全數賣出")
+	return b.String()
+}
+
+// rebalanceHolding 再平衡計算用的持倉（目標中尚未持有的標的數量為 0）
+type rebalanceHolding struct {
+	Symbol   string
+	Currency string
+	Units    float64
+	// 原幣別現價與換算基準幣別後的現價
+	Price     float64
+	BasePrice float64
+	// 基準幣別市值
+	Value   float64
+	Profile *CompanyProfile
+}
+
+// rebalanceTrade 建議交易
+type rebalanceTrade struct {
+	Symbol   string
+	Side     string
+	Units    float64
+	Price    float64
+	Currency string
+	// 基準幣別金額
+	Value float64
+	// 目前與目標佔總資產的比例（%）
+	Current float64
+	Target  float64
+}
+
+// rebalancePlan 再平衡建議
+type rebalancePlan struct {
+	BaseCurrency string
+	// 持倉市值加現金
+	Total     float64
+	Cash      float64
+	CashAfter float64
+	Trades    []*rebalanceTrade
+	Warnings  []string
+}
+
+// Rebalance : 依目標配置與目前價格計算回到目標所需的交易（整數股，略過低於最小金額的交易）
+func Rebalance(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $rebalance 10000
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $rebalance [現金（基準幣別）]")
+	}
+
+	var cash float64
+	if len(strSlice) == 2 {
+		var err error
+		cash, err = strconv.ParseFloat(strings.ReplaceAll(strSlice[1], ",", ""), 64)
+		if err != nil || cash < 0 {
+			return "", fmt.Errorf("無效的現金金額: %s", strSlice[1])
+		}
+	}
+
+	targets, err := targetWeightDaoDeps{}.GetTargetWeights(ctx, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("尚未設定目標配置，請使用 $target <標的|sector:<名稱>> <權重%%> 設定")
+	}
+
+	portfolio, err := loadPortfolio(ctx, stockDaoDeps{}, userSettingDaoDeps{}, dividendDaoDeps{}, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+
+	setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+	base := baseCurrency(setting, config.GetTaskConfig().DefaultBaseCurrency)
+
+	rates, err := getRates(ctx)
+	if err != nil {
+		return "", fmt.Errorf("取得匯率錯誤: %w", err)
+	}
+
+	allocationConfig := config.GetAllocationConfig()
+	holdings, err := rebalanceHoldings(ctx, redisDeps{}, portfolio.Views, targets, rates, base, time.Duration(allocationConfig.ProfileCacheHours)*time.Hour)
+	if err != nil {
+		return "", err
+	}
+
+	plan := planRebalance(holdings, targets, cash, float64(allocationConfig.RebalanceMinTrade))
+	plan.BaseCurrency = base
+	return formatRebalance(plan), nil
+}
+
+// rebalanceHoldings 整理持倉現價與基準幣別市值，補上目標中尚未持有的標的
+// 任一標的報價失敗時無法正確計算總資產，直接回傳錯誤
+func rebalanceHoldings(ctx context.Context, redisClient RedisClient, views []*PositionView, targets []*dto.TargetWeight, rates exchange.Rates, base string, cacheTTL time.Duration) ([]*rebalanceHolding, error) {
+	var failed []string
+	for _, view := range views {
+		if view.Err != nil {
+			failed = append(failed, view.Symbol)
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("無法取得 %s 報價，請稍後再試", strings.Join(failed, ", "))
+	}
+
+	hasCategory := false
+	held := make(map[string]bool, len(views))
+	holdings := make([]*rebalanceHolding, 0, len(views))
+	for _, view := range views {
+		held[view.Symbol] = true
+		holdings = append(holdings, &rebalanceHolding{
+			Symbol:   view.Symbol,
+			Currency: holdingCurrency(view.Currency),
+			Units:    view.Units,
+			Price:    view.Price,
+			Value:    view.Value,
+		})
+	}
+
+	externalTimeout := durationFromSeconds(config.GetTaskConfig().ExternalCallTimeoutSeconds, 15*time.Second)
+	for _, target := range targets {
+		if strings.Contains(target.Target, ":") {
+			hasCategory = true
+			continue
+		}
+		if held[target.Target] {
+			continue
+		}
+
+		// 尚未持有的標的無法得知交易幣別，視為美元計價
+		quoteCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+		quote, err := GetClient("finnhub").GetQuote(quoteCtx, target.Target)
+		cancel()
+		if err == nil && quote.CurrentPrice == 0 {
+			err = fmt.Errorf("搜尋失敗")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("無法取得 %s 報價: %w", target.Target, err)
+		}
+		holdings = append(holdings, &rebalanceHolding{
+			Symbol:   target.Target,
+			Currency: holdingCurrency(""),
+			Price:    float64(quote.CurrentPrice),
+		})
+	}
+
+	for _, holding := range holdings {
+		rate, err := rates.Convert(1, holding.Currency, base)
+		if err != nil {
+			return nil, fmt.Errorf("%s 換算 %s 錯誤: %w", holding.Symbol, base, err)
+		}
+		holding.BasePrice = holding.Price * rate
+		holding.Value *= rate
+
+		if !hasCategory || holding.Units == 0 {
+			continue
+		}
+		profile, err := getCompanyProfile(ctx, redisClient, holding.Symbol, cacheTTL)
+		if err != nil {
+			logger.Warn("取得公司基本資料失敗", "symbol", holding.Symbol, "error", err)
+			profile = &CompanyProfile{Symbol: holding.Symbol}
+		}
+		holding.Profile = profile
+	}
+
+	return holdings, nil
+}
+
+// planRebalance 計算回到目標配置所需的交易
+// 個股目標優先；分類目標依目前市值比例分配給該分類中未設定個股目標的持倉；其餘持倉目標為 0（全數賣出）
+// 買進與部分賣出皆為整數股，金額低於 minTrade 的交易略過，買進金額不超過現金加賣出所得
+func planRebalance(holdings []*rebalanceHolding, targets []*dto.TargetWeight, cash float64, minTrade float64) *rebalancePlan {
+	plan := &rebalancePlan{Cash: cash, Total: cash}
+	bySymbol := make(map[string]*rebalanceHolding, len(holdings))
+	for _, holding := range holdings {
+		plan.Total += holding.Value
+		bySymbol[holding.Symbol] = holding
+	}
+	if plan.Total <= 0 {
+		plan.Warnings = append(plan.Warnings, "總資產為 0，請輸入可投入的現金")
+		return plan
+	}
+
+	targetValues := make(map[string]float64, len(holdings))
+	assigned := make(map[string]string, len(holdings))
+
+	var categories []*dto.TargetWeight
+	for _, target := range targets {
+		dimension, _, isCategory := strings.Cut(target.Target, ":")
+		if isCategory {
+			if _, ok := allocationLabels[dimension]; ok {
+				categories = append(categories, target)
+			}
+			continue
+		}
+		if _, ok := bySymbol[target.Target]; !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s 沒有報價，略過", target.Target))
+			continue
+		}
+		targetValues[target.Target] = target.Weight / 100 * plan.Total
+		assigned[target.Target] = target.Target
+	}
+
+	// 分類目標依分組方式順序套用，同時符合多個分類的持倉歸屬於第一個
+	sort.SliceStable(categories, func(i, j int) bool {
+		return dimensionOrder(categories[i].Target) < dimensionOrder(categories[j].Target)
+	})
+	for _, target := range categories {
+		dimension, name, _ := strings.Cut(target.Target, ":")
+
+		var members []*rebalanceHolding
+		var memberValue float64
+		for _, holding := range holdings {
+			if _, ok := assigned[holding.Symbol]; ok || holding.Units <= 0 {
+				continue
+			}
+			if strings.EqualFold(allocationKey(&allocationHolding{Currency: holding.Currency, Profile: holding.Profile}, dimension), name) {
+				members = append(members, holding)
+				memberValue += holding.Value
+			}
+		}
+		if len(members) == 0 || memberValue <= 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s 沒有可調整的持倉，請改設定個股目標", target.Target))
+			continue
+		}
+
+		for _, holding := range members {
+			targetValues[holding.Symbol] = target.Weight / 100 * plan.Total * holding.Value / memberValue
+			assigned[holding.Symbol] = target.Target
+		}
+	}
+
+	available := cash
+	var sells, buys []*rebalanceTrade
+	for _, holding := range holdings {
+		if holding.BasePrice <= 0 {
+			continue
+		}
+
+		targetValue := targetValues[holding.Symbol]
+		diff := targetValue - holding.Value
+		trade := &rebalanceTrade{
+			Symbol:   holding.Symbol,
+			Price:    holding.Price,
+			Currency: holding.Currency,
+			Current:  holding.Value / plan.Total * 100,
+			Target:   targetValue / plan.Total * 100,
+		}
+
+		switch {
+		case diff < 0 && targetValue == 0:
+			trade.Side = dto.StockSideSell
+			trade.Units = holding.Units
+		case diff < 0:
+			trade.Side = dto.StockSideSell
+			trade.Units = math.Min(math.Floor(-diff/holding.BasePrice+1e-9), holding.Units)
+		case diff > 0:
+			trade.Side = dto.StockSideBuy
+			trade.Units = math.Floor(diff/holding.BasePrice + 1e-9)
+		}
+		trade.Value = trade.Units * holding.BasePrice
+		if trade.Units <= 0 || trade.Value < minTrade {
+			continue
+		}
+
+		if trade.Side == dto.StockSideSell {
+			sells = append(sells, trade)
+			available += trade.Value
+		} else {
+			buys = append(buys, trade)
+		}
+	}
+
+	// 整數股與略過的小額賣出可能讓買進金額超過可用現金，由金額大的買進開始依可用現金減少股數
+	sortTrades := func(trades []*rebalanceTrade) {
+		sort.Slice(trades, func(i, j int) bool {
+			if trades[i].Value != trades[j].Value {
+				return trades[i].Value > trades[j].Value
+			}
+			return trades[i].Symbol < trades[j].Symbol
+		})
+	}
+	sortTrades(sells)
+	sortTrades(buys)
+
+	plan.Trades = append(plan.Trades, sells...)
+	for _, trade := range buys {
+		basePrice := trade.Value / trade.Units
+		if trade.Value > available+1e-9 {
+			trade.Units = math.Floor(available/basePrice + 1e-9)
+			trade.Value = trade.Units * basePrice
+			if trade.Units <= 0 || trade.Value < minTrade {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("現金不足，略過買進 %s", trade.Symbol))
+				continue
+			}
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("現金不足，%s 減少為 %v 股", trade.Symbol, trade.Units))
+		}
+		available -= trade.Value
+		plan.Trades = append(plan.Trades, trade)
+	}
+	plan.CashAfter = available
+
+	return plan
+}
+
+// dimensionOrder 分類目標的套用順序（依 allocationDimensions）
+func dimensionOrder(target string) int {
+	dimension, _, _ := strings.Cut(target, ":")
+	for i, d := range allocationDimensions {
+		if d == dimension {
+			return i
+		}
+	}
+	return len(allocationDimensions)
+}
+
+// formatRebalance 再平衡建議顯示格式
+func formatRebalance(plan *rebalancePlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "再平衡建議（總資產 %.2f %s，含現金 %.2f）\n", plan.Total, plan.BaseCurrency, plan.Cash)
+
+	if len(plan.Trades) == 0 {
+		b.WriteString("目前配置已接近目標，無需交易")
+	} else {
+		b.WriteString("```\n")
+		for _, trade := range plan.Trades {
+			side := "買進"
+			if trade.Side == dto.StockSideSell {
+				side = "賣出"
+			}
+			fmt.Fprintf(&b, "%s %-8s %8v 股 @ %10.2f %s ≈ %12.2f %s  %6.2f%% → %6.2f%%\n",
+				side, trade.Symbol, trade.Units, trade.Price, trade.Currency, trade.Value, plan.BaseCurrency, trade.Current, trade.Target)
+		}
+		b.WriteString("```\n")
+		fmt.Fprintf(&b, "交易後剩餘現金: %.2f %s（未計手續費與稅）", plan.CashAfter, plan.BaseCurrency)
+	}
+
+	for _, warning := range plan.Warnings {
+		b.WriteString("\n提醒: " + warning)
+	}
+	return b.String()
+}
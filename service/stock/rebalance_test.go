@@ -0,0 +1,210 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/service/exchange"
+)
+
+func Test_parseTargetKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "aapl", want: "AAPL"},
+		{key: "Sector:Information Technology", want: "sector:Information Technology"},
+		{key: "currency: twd", want: "currency:TWD"},
+		{key: "region:Asia", wantErr: true},
+		{key: "country:", wantErr: true},
+		{key: "BRK B", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := parseTargetKey(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTargetKey() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseTargetKey() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"-1", "101", "abc"} {
+		if _, err := parseTargetWeight(value); err == nil {
+			t.Errorf("parseTargetWeight(%q) error = nil, want error", value)
+		}
+	}
+	if got, err := parseTargetWeight("12.5%"); err != nil || got != 12.5 {
+		t.Errorf("parseTargetWeight(12.5%%) = %v, %v, want 12.5", got, err)
+	}
+}
+
+func Test_planRebalance(t *testing.T) {
+	tech := &CompanyProfile{Country: "US", Industry: "Technology"}
+	energy := &CompanyProfile{Country: "US", Industry: "Energy"}
+
+	tests := []struct {
+		name         string
+		holdings     []*rebalanceHolding
+		targets      []*dto.TargetWeight
+		cash         float64
+		minTrade     float64
+		want         []string
+		wantCash     float64
+		wantWarnings []string
+	}{
+		{
+			name: "symbol targets with whole shares",
+			holdings: []*rebalanceHolding{
+				{Symbol: "AAPL", Currency: "USD", Units: 10, Price: 100, BasePrice: 100, Value: 1000},
+				{Symbol: "MSFT", Currency: "USD", Price: 300, BasePrice: 300},
+			},
+			targets:  []*dto.TargetWeight{{Target: "AAPL", Weight: 50}, {Target: "MSFT", Weight: 50}},
+			cash:     1000,
+			want:     []string{"buy MSFT 3"},
+			wantCash: 100,
+		},
+		{
+			name: "untargeted holding sold in full",
+			holdings: []*rebalanceHolding{
+				{Symbol: "AAPL", Currency: "USD", Units: 10, Price: 100, BasePrice: 100, Value: 1000},
+				{Symbol: "OLD", Currency: "USD", Units: 2.5, Price: 40, BasePrice: 40, Value: 100},
+			},
+			targets:  []*dto.TargetWeight{{Target: "AAPL", Weight: 100}},
+			want:     []string{"sell OLD 2.5", "buy AAPL 1"},
+			wantCash: 0,
+		},
+		{
+			name: "min trade skips small adjustments",
+			holdings: []*rebalanceHolding{
+				{Symbol: "AAPL", Currency: "USD", Units: 10, Price: 100, BasePrice: 100, Value: 1000},
+				{Symbol: "MSFT", Currency: "USD", Units: 3, Price: 300, BasePrice: 300, Value: 900},
+			},
+			targets:  []*dto.TargetWeight{{Target: "AAPL", Weight: 50}, {Target: "MSFT", Weight: 50}},
+			minTrade: 100,
+			wantCash: 0,
+		},
+		{
+			name: "category target split by current value",
+			holdings: []*rebalanceHolding{
+				{Symbol: "AAPL", Currency: "USD", Units: 10, Price: 10, BasePrice: 10, Value: 100, Profile: tech},
+				{Symbol: "MSFT", Currency: "USD", Units: 10, Price: 30, BasePrice: 30, Value: 300, Profile: tech},
+				{Symbol: "XOM", Currency: "USD", Units: 12, Price: 50, BasePrice: 50, Value: 600, Profile: energy},
+			},
+			targets:  []*dto.TargetWeight{{Target: "sector:information technology", Weight: 80}, {Target: "XOM", Weight: 20}},
+			want:     []string{"sell XOM 8", "buy MSFT 10", "buy AAPL 10"},
+			wantCash: 0,
+		},
+		{
+			name: "category without holdings",
+			holdings: []*rebalanceHolding{
+				{Symbol: "AAPL", Currency: "USD", Units: 10, Price: 100, BasePrice: 100, Value: 1000, Profile: tech},
+			},
+			targets:      []*dto.TargetWeight{{Target: "AAPL", Weight: 90}, {Target: "sector:Energy", Weight: 10}},
+			cash:         0,
+			want:         []string{"sell AAPL 1"},
+			wantCash:     100,
+			wantWarnings: []string{"sector:Energy 沒有可調整的持倉"},
+		},
+		{
+			name: "converted prices",
+			holdings: []*rebalanceHolding{
+				{Symbol: "2330", Currency: "TWD", Units: 1000, Price: 640, BasePrice: 20, Value: 20000},
+				{Symbol: "VOO", Currency: "USD", Price: 500, BasePrice: 500},
+			},
+			targets:  []*dto.TargetWeight{{Target: "2330", Weight: 50}, {Target: "VOO", Weight: 50}},
+			want:     []string{"sell 2330 500", "buy VOO 20"},
+			wantCash: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planRebalance(tt.holdings, tt.targets, tt.cash, tt.minTrade)
+
+			var got []string
+			for _, trade := range plan.Trades {
+				got = append(got, fmt.Sprintf("%s %s %v", trade.Side, trade.Symbol, trade.Units))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("trades = %v, want %v", got, tt.want)
+			}
+			if !floatEqual(plan.CashAfter, tt.wantCash) {
+				t.Errorf("CashAfter = %v, want %v", plan.CashAfter, tt.wantCash)
+			}
+			warnings := strings.Join(plan.Warnings, "|")
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(warnings, want) {
+					t.Errorf("Warnings = %v, want %q", plan.Warnings, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_planRebalance_cashLimit(t *testing.T) {
+	holdings := []*rebalanceHolding{
+		{Symbol: "AAPL", Currency: "USD", Units: 1, Price: 100, BasePrice: 100, Value: 100},
+		{Symbol: "MSFT", Currency: "USD", Units: 1, Price: 300, BasePrice: 300, Value: 300},
+	}
+	// MSFT 需賣出的金額不足一股，AAPL 買進受限於現金
+	targets := []*dto.TargetWeight{{Target: "AAPL", Weight: 80}, {Target: "MSFT", Weight: 20}}
+
+	plan := planRebalance(holdings, targets, 250, 150)
+	if len(plan.Trades) != 1 || plan.Trades[0].Units != 2 {
+		t.Fatalf("trades = %+v, want buy AAPL 2", plan.Trades)
+	}
+	if !floatEqual(plan.CashAfter, 50) || len(plan.Warnings) != 1 {
+		t.Errorf("CashAfter = %v, Warnings = %v, want 50 and one warning", plan.CashAfter, plan.Warnings)
+	}
+}
+
+func Test_rebalanceHoldings(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	mockClient.AddQuote("VOO", &QuoteResponse{CurrentPrice: 500})
+	mockClient.Profiles = map[string]*CompanyProfile{"2330": {Country: "TW", Industry: "Semiconductors"}}
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	rates := exchange.Rates{"USD": 1, "TWD": 32}
+	views := []*PositionView{
+		{Position: &Position{Symbol: "2330", Currency: "TWD", Units: 1000}, Price: 640, Value: 640000},
+	}
+	targets := []*dto.TargetWeight{{Target: "VOO", Weight: 50}, {Target: "country:TW", Weight: 50}}
+
+	holdings, err := rebalanceHoldings(context.Background(), NewMockRedisClient(), views, targets, rates, "USD", time.Hour)
+	if err != nil {
+		t.Fatalf("rebalanceHoldings() unexpected error = %v", err)
+	}
+	if len(holdings) != 2 {
+		t.Fatalf("holdings = %d, want 2", len(holdings))
+	}
+	if !floatEqual(holdings[0].Value, 20000) || !floatEqual(holdings[0].BasePrice, 20) || holdings[0].Profile == nil || holdings[0].Profile.Country != "TW" {
+		t.Errorf("2330 = %+v, want converted value with profile", holdings[0])
+	}
+	if holdings[1].Symbol != "VOO" || holdings[1].Units != 0 || !floatEqual(holdings[1].BasePrice, 500) {
+		t.Errorf("VOO = %+v, want unheld target with quote", holdings[1])
+	}
+
+	failed := append(views, &PositionView{Position: &Position{Symbol: "BAD"}, Err: errors.New("搜尋失敗")})
+	if _, err := rebalanceHoldings(context.Background(), NewMockRedisClient(), failed, targets, rates, "USD", time.Hour); err == nil {
+		t.Errorf("rebalanceHoldings() with failed quote error = nil, want error")
+	}
+
+	missing := []*dto.TargetWeight{{Target: "NOPE", Weight: 10}}
+	if _, err := rebalanceHoldings(context.Background(), NewMockRedisClient(), views, missing, rates, "USD", time.Hour); err == nil {
+		t.Errorf("rebalanceHoldings() with unknown symbol error = nil, want error")
+	}
+}
@@ -0,0 +1,251 @@
+package stock
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/service/exchange"
+)
+
+// daysPerYear 年化與 XIRR 使用的天數
+const daysPerYear = 365.0
+
+// CashFlow 投資人角度的現金流：投入為負、取回為正
+type CashFlow struct {
+	Date   time.Time
+	Amount float64
+}
+
+// Returns 區間報酬率（皆為比例，0.1 代表 10%）
+type Returns struct {
+	From time.Time
+	To   time.Time
+	// 時間加權報酬率（不受期間投入/取出金額影響）
+	TWR           float64
+	AnnualizedTWR float64
+	// 金額加權報酬率（年化）
+	XIRR float64
+	// XIRR 無法收斂或現金流不足時的原因
+	XIRRErr error
+}
+
+// tradeCashFlow 單筆交易的現金流：買進為投入（負），賣出為取回（正）
+func tradeCashFlow(trade *dto.Stock) CashFlow {
+	if trade.Side == dto.StockSideSell {
+		return CashFlow{Date: trade.TradedAt, Amount: trade.Units*trade.Price - tradeCosts(trade)}
+	}
+	return CashFlow{Date: trade.TradedAt, Amount: -(trade.Units*trade.Price + tradeCosts(trade))}
+}
+
+// PortfolioReturns 以總計快照與交易紀錄計算區間的時間加權報酬率與 XIRR
+// snapshots 需依日期由舊到新排序；交易日期以快照同樣的美東日期歸屬，
+// 股息依快照累計股息收入的增加視為當日取回的現金；
+// 快照有幣別且 rates 不為 nil 時，交易金額以 rates 換算為快照幣別
+func PortfolioReturns(snapshots []*dto.PortfolioSnapshot, trades []*dto.Stock, rates exchange.Rates) (*Returns, error) {
+	if len(snapshots) < 2 {
+		return nil, fmt.Errorf("快照不足，至少需要 2 筆")
+	}
+
+	first := snapshots[0]
+	last := snapshots[len(snapshots)-1]
+
+	// 區間內（不含期初當日）的交易現金流，以美東日期歸屬到快照
+	var flows []CashFlow
+	for _, trade := range sortTrades(trades) {
+		date := snapshotDate(trade.TradedAt)
+		if !date.After(first.Date) || date.After(last.Date) {
+			continue
+		}
+		flow := tradeCashFlow(trade)
+		flow.Date = date
+		if last.Currency != "" && rates != nil {
+			amount, err := rates.Convert(flow.Amount, holdingCurrency(trade.Currency), last.Currency)
+			if err != nil {
+				return nil, err
+			}
+			flow.Amount = amount
+		}
+		flows = append(flows, flow)
+	}
+	for i := 1; i < len(snapshots); i++ {
+		if received := snapshots[i].DividendIncome - snapshots[i-1].DividendIncome; received != 0 {
+			flows = append(flows, CashFlow{Date: snapshots[i].Date, Amount: received})
+		}
+	}
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].Date.Before(flows[j].Date)
+	})
+
+	twr, err := TimeWeightedReturn(snapshots, flows)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Returns{
+		From:          first.Date,
+		To:            last.Date,
+		TWR:           twr,
+		AnnualizedTWR: AnnualizeReturn(twr, last.Date.Sub(first.Date)),
+	}
+
+	// XIRR：期初市值視為投入，期末市值視為取回
+	xirrFlows := make([]CashFlow, 0, len(flows)+2)
+	xirrFlows = append(xirrFlows, CashFlow{Date: first.Date, Amount: -first.Value})
+	xirrFlows = append(xirrFlows, flows...)
+	xirrFlows = append(xirrFlows, CashFlow{Date: last.Date, Amount: last.Value})
+	ret.XIRR, ret.XIRRErr = XIRR(xirrFlows)
+
+	return ret, nil
+}
+
+// TimeWeightedReturn 依相鄰快照切分子區間並連乘報酬率
+// flows 為投資人角度的現金流（買進為負），日期需與快照日期對齊；
+// 子區間報酬率 = (期末市值 - 期間淨投入) / 期初市值 - 1
+func TimeWeightedReturn(snapshots []*dto.PortfolioSnapshot, flows []CashFlow) (float64, error) {
+	if len(snapshots) < 2 {
+		return 0, fmt.Errorf("快照不足，至少需要 2 筆")
+	}
+
+	growth := 1.0
+	periods := 0
+	j := 0
+	for i := 1; i < len(snapshots); i++ {
+		start, end := snapshots[i-1], snapshots[i]
+
+		var invested float64
+		for j < len(flows) && !flows[j].Date.After(end.Date) {
+			if flows[j].Date.After(start.Date) {
+				invested -= flows[j].Amount
+			}
+			j++
+		}
+
+		// 期初沒有持倉的子區間無法計算報酬率，略過
+		if start.Value <= 0 {
+			continue
+		}
+
+		growth *= (end.Value - invested) / start.Value
+		periods++
+	}
+
+	if periods == 0 {
+		return 0, fmt.Errorf("沒有可計算報酬率的區間")
+	}
+
+	return growth - 1, nil
+}
+
+// AnnualizeReturn 將區間報酬率換算為年化報酬率，區間不足一天時回傳原值
+func AnnualizeReturn(r float64, period time.Duration) float64 {
+	days := period.Hours() / 24
+	if days < 1 || r <= -1 {
+		return r
+	}
+	return math.Pow(1+r, daysPerYear/days) - 1
+}
+
+// XIRR 計算不定期現金流的年化內部報酬率
+// 先以牛頓法求解，未收斂時改用二分法
+func XIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, fmt.Errorf("現金流不足，至少需要 2 筆")
+	}
+
+	sorted := make([]CashFlow, len(flows))
+	copy(sorted, flows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var hasIn, hasOut bool
+	for _, flow := range sorted {
+		if flow.Amount < 0 {
+			hasIn = true
+		}
+		if flow.Amount > 0 {
+			hasOut = true
+		}
+	}
+	if !hasIn || !hasOut {
+		return 0, fmt.Errorf("現金流需同時包含投入與取回")
+	}
+
+	start := sorted[0].Date
+	years := make([]float64, len(sorted))
+	for i, flow := range sorted {
+		years[i] = flow.Date.Sub(start).Hours() / 24 / daysPerYear
+	}
+
+	npv := func(rate float64) (value, derivative float64) {
+		for i, flow := range sorted {
+			discount := math.Pow(1+rate, years[i])
+			value += flow.Amount / discount
+			derivative -= years[i] * flow.Amount / (discount * (1 + rate))
+		}
+		return value, derivative
+	}
+
+	const (
+		tolerance     = 1e-9
+		maxIterations = 100
+	)
+
+	rate := 0.1
+	for i := 0; i < maxIterations; i++ {
+		value, derivative := npv(rate)
+		if math.Abs(value) < tolerance {
+			return rate, nil
+		}
+		if derivative == 0 || math.IsNaN(derivative) {
+			break
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < tolerance {
+			return next, nil
+		}
+		rate = next
+	}
+
+	// 二分法：NPV 隨利率遞減（期初投入、期末取回的常見情況）
+	low, high := -0.9999, 1.0
+	lowValue, _ := npv(low)
+	highValue, _ := npv(high)
+	for highValue*lowValue > 0 && high < 1e6 {
+		high *= 10
+		highValue, _ = npv(high)
+	}
+	if highValue*lowValue > 0 {
+		return 0, fmt.Errorf("XIRR 無法收斂")
+	}
+
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		midValue, _ := npv(mid)
+		if math.Abs(midValue) < tolerance || (high-low)/2 < tolerance {
+			return mid, nil
+		}
+		if midValue*lowValue > 0 {
+			low, lowValue = mid, midValue
+		} else {
+			high = mid
+		}
+	}
+
+	return (low + high) / 2, nil
+}
+
+// formatReturns 報酬率顯示格式
+func formatReturns(r *Returns) string {
+	text := fmt.Sprintf("時間加權報酬率: %+.2f %% (年化 %+.2f %%)", r.TWR*100, r.AnnualizedTWR*100)
+	if r.XIRRErr != nil {
+		return text + fmt.Sprintf(", XIRR: 無法計算 (%v)", r.XIRRErr)
+	}
+	return text + fmt.Sprintf(", XIRR: %+.2f %%", r.XIRR*100)
+}
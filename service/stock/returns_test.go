@@ -0,0 +1,238 @@
+package stock
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+	"discordBot/service/exchange"
+)
+
+func Test_TimeWeightedReturn(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC) }
+	snapshot := func(d int, value float64) *dto.PortfolioSnapshot {
+		return &dto.PortfolioSnapshot{Date: day(d), Value: value}
+	}
+
+	tests := []struct {
+		name      string
+		snapshots []*dto.PortfolioSnapshot
+		flows     []CashFlow
+		want      float64
+		wantErr   bool
+	}{
+		{
+			name:      "no cash flows",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 100), snapshot(2, 110), snapshot(3, 121)},
+			want:      0.21,
+		},
+		{
+			name:      "deposit does not count as return",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 100), snapshot(2, 220), snapshot(3, 242)},
+			flows:     []CashFlow{{Date: day(2), Amount: -100}},
+			want:      0.32,
+		},
+		{
+			name:      "withdrawal does not count as loss",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 200), snapshot(2, 160)},
+			flows:     []CashFlow{{Date: day(2), Amount: 50}},
+			want:      0.05,
+		},
+		{
+			name:      "flows before the range are ignored",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(2, 100), snapshot(3, 90)},
+			flows:     []CashFlow{{Date: day(1), Amount: -100}, {Date: day(2), Amount: -50}},
+			want:      -0.1,
+		},
+		{
+			name:      "period starting empty is skipped",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 0), snapshot(2, 100), snapshot(3, 110)},
+			flows:     []CashFlow{{Date: day(2), Amount: -100}},
+			want:      0.1,
+		},
+		{
+			name:      "single snapshot",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 100)},
+			wantErr:   true,
+		},
+		{
+			name:      "never invested",
+			snapshots: []*dto.PortfolioSnapshot{snapshot(1, 0), snapshot(2, 0)},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TimeWeightedReturn(tt.snapshots, tt.flows)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("TimeWeightedReturn() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TimeWeightedReturn() unexpected error = %v", err)
+			}
+			if !floatEqual(got, tt.want) {
+				t.Errorf("TimeWeightedReturn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_XIRR(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		flows   []CashFlow
+		want    float64
+		wantErr bool
+	}{
+		{
+			// Excel XIRR 說明文件範例
+			name: "irregular flows",
+			flows: []CashFlow{
+				{Date: date(2008, 1, 1), Amount: -10000},
+				{Date: date(2008, 3, 1), Amount: 2750},
+				{Date: date(2008, 10, 30), Amount: 4250},
+				{Date: date(2009, 2, 15), Amount: 3250},
+				{Date: date(2009, 4, 1), Amount: 2750},
+			},
+			want: 0.373362535,
+		},
+		{
+			name: "one year gain",
+			flows: []CashFlow{
+				{Date: date(2025, 1, 1), Amount: -1000},
+				{Date: date(2026, 1, 1), Amount: 1100},
+			},
+			want: 0.1,
+		},
+		{
+			name: "one year loss, unsorted input",
+			flows: []CashFlow{
+				{Date: date(2026, 1, 1), Amount: 900},
+				{Date: date(2025, 1, 1), Amount: -1000},
+			},
+			want: -0.1,
+		},
+		{
+			name: "additional deposit",
+			flows: []CashFlow{
+				{Date: date(2025, 1, 1), Amount: -1000},
+				{Date: date(2026, 1, 1), Amount: -1000},
+				{Date: date(2027, 1, 1), Amount: 2310},
+			},
+			want: 0.1,
+		},
+		{
+			name:    "only deposits",
+			flows:   []CashFlow{{Date: date(2025, 1, 1), Amount: -1000}, {Date: date(2026, 1, 1), Amount: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "single flow",
+			flows:   []CashFlow{{Date: date(2025, 1, 1), Amount: -1000}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := XIRR(tt.flows)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("XIRR() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("XIRR() unexpected error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("XIRR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_AnnualizeReturn(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      float64
+		period time.Duration
+		want   float64
+	}{
+		{name: "two years", r: 0.21, period: 730 * 24 * time.Hour, want: 0.1},
+		{name: "half year", r: 0.1, period: 182.5 * 24 * time.Hour, want: 0.21},
+		{name: "less than a day", r: 0.01, period: time.Hour, want: 0.01},
+		{name: "total loss", r: -1, period: 365 * 24 * time.Hour, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnnualizeReturn(tt.r, tt.period); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("AnnualizeReturn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PortfolioReturns(t *testing.T) {
+	snapshots := []*dto.PortfolioSnapshot{
+		{Date: time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC), Value: 1000},
+		{Date: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), Value: 2100},
+	}
+
+	// 美東 7/15 晚間買進（UTC 已是 7/16），應歸屬 7/15 快照
+	buy := &dto.Stock{ID: 2, Side: dto.StockSideBuy, Units: 10, Price: 99, Fee: 10, TradedAt: time.Date(2026, 7, 16, 1, 0, 0, 0, time.UTC)}
+	// 期初之前的交易不列入
+	old := &dto.Stock{ID: 1, Side: dto.StockSideBuy, Units: 10, Price: 100, TradedAt: time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC)}
+
+	got, err := PortfolioReturns(snapshots, []*dto.Stock{buy, old}, nil)
+	if err != nil {
+		t.Fatalf("PortfolioReturns() unexpected error = %v", err)
+	}
+
+	if !floatEqual(got.TWR, 0.1) {
+		t.Errorf("PortfolioReturns() TWR = %v, want 0.1", got.TWR)
+	}
+	if got.XIRRErr != nil || got.XIRR <= 0 {
+		t.Errorf("PortfolioReturns() XIRR = %v, %v, want positive", got.XIRR, got.XIRRErr)
+	}
+	if !got.From.Equal(snapshots[0].Date) || !got.To.Equal(snapshots[1].Date) {
+		t.Errorf("PortfolioReturns() range = %v ~ %v", got.From, got.To)
+	}
+
+	// 股息視為取回的現金：市值不變但領到 50 股息，報酬率 5%
+	withDividend := []*dto.PortfolioSnapshot{
+		{Date: time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC), Value: 1000, DividendIncome: 20},
+		{Date: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), Value: 1000, DividendIncome: 70},
+	}
+	got, err = PortfolioReturns(withDividend, nil, nil)
+	if err != nil {
+		t.Fatalf("PortfolioReturns() unexpected error = %v", err)
+	}
+	if !floatEqual(got.TWR, 0.05) {
+		t.Errorf("PortfolioReturns() with dividend TWR = %v, want 0.05", got.TWR)
+	}
+
+	// 台幣快照：美元交易以匯率換算後計入現金流
+	inTWD := []*dto.PortfolioSnapshot{
+		{Date: time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC), Value: 30000, Currency: "TWD"},
+		{Date: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), Value: 63000, Currency: "TWD"},
+	}
+	got, err = PortfolioReturns(inTWD, []*dto.Stock{buy}, exchange.Rates{"USD": 1, "TWD": 30})
+	if err != nil {
+		t.Fatalf("PortfolioReturns() unexpected error = %v", err)
+	}
+	if !floatEqual(got.TWR, 0.1) {
+		t.Errorf("PortfolioReturns() in TWD TWR = %v, want 0.1", got.TWR)
+	}
+	if _, err := PortfolioReturns(inTWD, []*dto.Stock{buy}, exchange.Rates{"USD": 1}); err == nil {
+		t.Errorf("PortfolioReturns() missing rate error = nil, want error")
+	}
+}
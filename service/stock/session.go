@@ -0,0 +1,138 @@
+package stock
+
+import (
+	"sync"
+	"time"
+
+	"discordBot/service/market"
+)
+
+// MarketSession 美股交易時段
+type MarketSession string
+
+const (
+	SessionPreMarket  = MarketSession(market.SessionPreMarket)
+	SessionRegular    = MarketSession(market.SessionRegular)
+	SessionAfterHours = MarketSession(market.SessionAfterHours)
+	SessionClosed     = MarketSession(market.SessionClosed)
+)
+
+// Label 時段顯示名稱
+func (s MarketSession) Label() string {
+	switch s {
+	case SessionPreMarket:
+		return "盤前"
+	case SessionRegular:
+		return "盤中"
+	case SessionAfterHours:
+		return "盤後"
+	default:
+		return "休市"
+	}
+}
+
+// IsExtended 是否為延長交易時段
+func (s MarketSession) IsExtended() bool {
+	return s == SessionPreMarket || s == SessionAfterHours
+}
+
+// changeLabel 漲跌幅描述（延長時段相對前一個收盤價）
+func (s MarketSession) changeLabel() string {
+	if s.IsExtended() {
+		return s.Label()
+	}
+	return "今日"
+}
+
+// nowFunc 取得目前時間（用於測試替換）
+var nowFunc = time.Now
+
+var (
+	usMarketOnce sync.Once
+	usMarket     *market.Exchange
+)
+
+// usExchange 美股行事曆，第一次使用時建立（需在環境變數載入後才讀取額外休市日設定）
+func usExchange() *market.Exchange {
+	usMarketOnce.Do(func() {
+		usMarket = market.US()
+	})
+	return usMarket
+}
+
+// SessionAt 判斷指定時間所屬的美股交易時段（依美股行事曆，休市日視為休市）
+func SessionAt(t time.Time) MarketSession {
+	return MarketSession(usExchange().SessionAt(t))
+}
+
+// SessionQuote 標示所屬時段的報價
+type SessionQuote struct {
+	Session MarketSession
+	// 目前價格（延長時段有即時成交時為最新成交價）
+	Price float64
+	// 計算漲跌幅的參考收盤價
+	ReferenceClose float64
+	// 相對參考收盤價的漲跌幅（%）
+	Change float32
+	// 延長時段尚無成交資料，價格與漲跌幅為最近一次正規時段（不作為延長時段警告依據）
+	RegularFallback bool
+}
+
+// LastPriceSource 最新成交價來源（例如即時串流）
+type LastPriceSource interface {
+	LastPrice(symbol string) (float64, bool)
+}
+
+var (
+	lastPriceMu     sync.RWMutex
+	lastPriceSource LastPriceSource
+)
+
+// SetLastPriceSource 設置延長時段的最新成交價來源
+func SetLastPriceSource(source LastPriceSource) {
+	lastPriceMu.Lock()
+	defer lastPriceMu.Unlock()
+	lastPriceSource = source
+}
+
+func getLastPrice(symbol string) float64 {
+	lastPriceMu.RLock()
+	defer lastPriceMu.RUnlock()
+
+	if lastPriceSource == nil {
+		return 0
+	}
+	price, ok := lastPriceSource.LastPrice(symbol)
+	if !ok {
+		return 0
+	}
+	return price
+}
+
+// NewSessionQuote 依時段整理報價
+// 盤中與休市沿用正規時段漲跌幅（盤中有即時成交價時以其計算）；延長時段有即時成交價時，
+// 以最近一次正規收盤價（報價的 c）為基準計算延長時段漲跌幅，沒有成交價時退回正規時段漲跌幅並標示 RegularFallback。
+func NewSessionQuote(q *QuoteResponse, session MarketSession, lastPrice float64) *SessionQuote {
+	if session.IsExtended() && lastPrice > 0 {
+		reference := float64(q.CurrentPrice)
+		return &SessionQuote{
+			Session:        session,
+			Price:          lastPrice,
+			ReferenceClose: reference,
+			Change:         changePercent(lastPrice, reference),
+		}
+	}
+
+	quote := &SessionQuote{
+		Session:         session,
+		Price:           float64(q.CurrentPrice),
+		ReferenceClose:  float64(q.PreviousClose),
+		Change:          q.PercentChange,
+		RegularFallback: session.IsExtended(),
+	}
+	if session == SessionRegular && lastPrice > 0 {
+		quote.Price = lastPrice
+		quote.Change = changePercent(lastPrice, quote.ReferenceClose)
+	}
+	return quote
+}
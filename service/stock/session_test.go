@@ -0,0 +1,115 @@
+package stock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeLastPriceSource 固定成交價來源
+type fakeLastPriceSource map[string]float64
+
+func (f fakeLastPriceSource) LastPrice(symbol string) (float64, bool) {
+	price, ok := f[symbol]
+	return price, ok
+}
+
+func Test_SessionAt(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want MarketSession
+	}{
+		// 夏令時間 (EDT, UTC-4)
+		{name: "summer pre-market", time: time.Date(2026, 7, 14, 8, 0, 0, 0, time.UTC), want: SessionPreMarket},
+		{name: "summer open", time: time.Date(2026, 7, 14, 13, 30, 0, 0, time.UTC), want: SessionRegular},
+		{name: "summer after-hours", time: time.Date(2026, 7, 14, 20, 0, 0, 0, time.UTC), want: SessionAfterHours},
+		{name: "summer overnight", time: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), want: SessionClosed},
+		// 標準時間 (EST, UTC-5)
+		{name: "winter pre-market at 13:30 UTC", time: time.Date(2026, 12, 15, 13, 30, 0, 0, time.UTC), want: SessionPreMarket},
+		{name: "winter open", time: time.Date(2026, 12, 15, 14, 30, 0, 0, time.UTC), want: SessionRegular},
+		{name: "winter close", time: time.Date(2026, 12, 15, 21, 0, 0, 0, time.UTC), want: SessionAfterHours},
+		{name: "saturday", time: time.Date(2026, 12, 19, 15, 0, 0, 0, time.UTC), want: SessionClosed},
+		{name: "thanksgiving holiday", time: time.Date(2026, 11, 26, 15, 0, 0, 0, time.UTC), want: SessionClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionAt(tt.time); got != tt.want {
+				t.Errorf("SessionAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NewSessionQuote(t *testing.T) {
+	quote := &QuoteResponse{CurrentPrice: 100, PreviousClose: 95, PercentChange: 5.2632}
+
+	tests := []struct {
+		name          string
+		session       MarketSession
+		lastPrice     float64
+		wantPrice     float64
+		wantReference float64
+		wantChange    float32
+		wantFallback  bool
+	}{
+		{name: "regular uses quote change", session: SessionRegular, wantPrice: 100, wantReference: 95, wantChange: 5.2632},
+		{name: "regular with live trade", session: SessionRegular, lastPrice: 104.5, wantPrice: 104.5, wantReference: 95, wantChange: 10},
+		{name: "closed uses quote change", session: SessionClosed, lastPrice: 90, wantPrice: 100, wantReference: 95, wantChange: 5.2632},
+		{name: "after-hours against regular close", session: SessionAfterHours, lastPrice: 110, wantPrice: 110, wantReference: 100, wantChange: 10},
+		{name: "pre-market without trade falls back to regular", session: SessionPreMarket, wantPrice: 100, wantReference: 95, wantChange: 5.2632, wantFallback: true},
+		{name: "after-hours without trade falls back to regular", session: SessionAfterHours, wantPrice: 100, wantReference: 95, wantChange: 5.2632, wantFallback: true},
+		{name: "pre-market drop", session: SessionPreMarket, lastPrice: 97, wantPrice: 97, wantReference: 100, wantChange: -3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSessionQuote(quote, tt.session, tt.lastPrice)
+			if got.Session != tt.session || got.Price != tt.wantPrice || got.ReferenceClose != tt.wantReference || got.RegularFallback != tt.wantFallback {
+				t.Errorf("NewSessionQuote() = %+v, want price %v reference %v fallback %v", got, tt.wantPrice, tt.wantReference, tt.wantFallback)
+			}
+			if diff := got.Change - tt.wantChange; diff < -0.001 || diff > 0.001 {
+				t.Errorf("NewSessionQuote() change = %v, want %v", got.Change, tt.wantChange)
+			}
+		})
+	}
+}
+
+func Test_Quote_ExtendedHours(t *testing.T) {
+	ctx := context.Background()
+
+	// 美東 17:00 盤後
+	nowFunc = func() time.Time { return time.Date(2026, 10, 20, 21, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	mock := NewMockFinnhubClient()
+	mock.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 200, PreviousClose: 190, PercentChange: 5.2632})
+	SetDefaultClient(mock)
+	defer ResetDefaultClient()
+
+	SetLastPriceSource(fakeLastPriceSource{"TSLA": 210})
+	defer SetLastPriceSource(nil)
+
+	got, err := Quote(ctx, "$TSLA")
+	if err != nil {
+		t.Fatalf("Quote() unexpected error = %v", err)
+	}
+
+	want := "Finnhub 查詢標的為:TSLA [盤後] 目前價格為:210.00 盤後漲跌幅:5.00% (參考收盤價:200.00)"
+	if got != want {
+		t.Errorf("Quote() = %v, want %v", got, want)
+	}
+
+	// 盤後尚無成交時顯示正規時段漲跌幅
+	SetLastPriceSource(nil)
+	got, err = Quote(ctx, "$TSLA")
+	if err != nil {
+		t.Fatalf("Quote() unexpected error = %v", err)
+	}
+
+	want = "Finnhub 查詢標的為:TSLA [盤後] 尚無盤後成交資料 最近收盤價為:200 正規時段漲跌幅:5.2632%"
+	if got != want {
+		t.Errorf("Quote() without trade = %v, want %v", got, want)
+	}
+}
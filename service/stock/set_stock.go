@@ -51,7 +51,167 @@ func SetStock(ctx context.Context, m *discordgo.MessageCreate) error {
 	return nil
 }
 
-// GetStock : DB 取得股票
+// Trade : 新增買進/賣出交易紀錄
+func Trade(ctx context.Context, m *discordgo.MessageCreate, side string) (*dto.Stock, error) {
+	// example : $buy TSLA units price [fee] [currency] [tax=<tax>] [broker=<broker>]
+	setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := loadFeeSchedules()
+	trade, err := parseTrade(m.Content, side, schedules, schedules.ForUser(setting))
+	if err != nil {
+		return nil, err
+	}
+	trade.UserID = m.Author.ID
+
+	if side == dto.StockSideSell {
+		if err := checkSellable(ctx, trade); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := stock.Ins(ctx, nil, trade); err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+// parseTrade 解析交易指令參數
+// 未輸入手續費或交易稅時，依指定券商（或使用者預設券商 defaultSchedule）的費率計算，券商費率的幣別需與交易相同
+func parseTrade(content string, side string, schedules *FeeSchedules, defaultSchedule *FeeSchedule) (*dto.Stock, error) {
+	strSlice := strings.Fields(content)
+	usage := fmt.Errorf("參數錯誤，格式: $%s <symbol> <units> <price> [fee] [currency] [tax=<tax>] [broker=<broker>]", side)
+
+	if len(strSlice) < 4 {
+		return nil, usage
+	}
+
+	units, err := strconv.ParseFloat(strSlice[2], 64)
+	if err != nil || units <= 0 {
+		return nil, fmt.Errorf("無效的數量: %s", strSlice[2])
+	}
+
+	price, err := strconv.ParseFloat(strSlice[3], 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("無效的價格: %s", strSlice[3])
+	}
+
+	trade := &dto.Stock{
+		Symbol:   strings.ToUpper(strSlice[1]),
+		Side:     side,
+		Units:    units,
+		Price:    price,
+		Currency: "USD",
+	}
+
+	schedule := defaultSchedule
+	var feeSet, taxSet, brokerSet bool
+	var positional []string
+	for _, arg := range strSlice[4:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "fee":
+			fee, err := strconv.ParseFloat(value, 64)
+			if err != nil || fee < 0 {
+				return nil, fmt.Errorf("無效的手續費: %s", value)
+			}
+			trade.Fee, feeSet = fee, true
+		case "tax":
+			tax, err := strconv.ParseFloat(value, 64)
+			if err != nil || tax < 0 {
+				return nil, fmt.Errorf("無效的交易稅: %s", value)
+			}
+			trade.Tax, taxSet = tax, true
+		case "broker":
+			if schedule, ok = schedules.Lookup(value); !ok {
+				return nil, fmt.Errorf("未設定 %s 的費率", value)
+			}
+			brokerSet = true
+		default:
+			return nil, fmt.Errorf("不支援的參數: %s", key)
+		}
+	}
+
+	if len(positional) > 2 {
+		return nil, usage
+	}
+
+	if len(positional) >= 1 {
+		fee, err := strconv.ParseFloat(positional[0], 64)
+		if err != nil || fee < 0 {
+			return nil, fmt.Errorf("無效的手續費: %s", positional[0])
+		}
+		trade.Fee, feeSet = fee, true
+	}
+
+	if len(positional) == 2 {
+		currency := strings.ToUpper(positional[1])
+		if len(currency) != 3 {
+			return nil, fmt.Errorf("無效的幣別: %s", positional[1])
+		}
+		trade.Currency = currency
+	}
+
+	// 券商費率只套用在相同幣別的交易（最低手續費以券商幣別計），預設券商不適用時需自行輸入手續費
+	if schedule != nil && !schedule.AppliesTo(trade.Currency) {
+		if brokerSet {
+			return nil, fmt.Errorf("%s 的費率只適用 %s 交易，%s 交易請自行輸入手續費與稅", schedule.Broker, schedule.Currency, trade.Currency)
+		}
+		schedule = nil
+	}
+
+	if schedule != nil {
+		trade.Broker = schedule.Broker
+		amount := units * price
+		if !feeSet {
+			trade.Fee = schedule.TradeFee(amount)
+		}
+		if !taxSet {
+			trade.Tax = schedule.TradeTax(side, amount)
+		}
+	}
+
+	return trade, nil
+}
+
+// checkSellable 確認賣出數量未超過目前持有數量
+func checkSellable(ctx context.Context, trade *dto.Stock) error {
+	trades, err := stock.Get(
+		ctx,
+		&stock.GetInput{
+			UserID: trade.UserID,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	positions, err := DerivePositions(trades)
+	if err != nil {
+		return err
+	}
+
+	for _, position := range positions {
+		if position.Symbol == trade.Symbol {
+			if trade.Units > position.Units+unitsEpsilon {
+				return fmt.Errorf("賣出數量 %v 超過持有數量 %v", trade.Units, position.Units)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("未持有 %s", trade.Symbol)
+}
+
+// GetStock : DB 取得呼叫者的交易紀錄
 func GetStock(ctx context.Context, m *discordgo.MessageCreate) ([]*dto.Stock, error) {
 	// example : $get_stock TSLA
 	strSlice := strings.Fields(m.Content)
@@ -65,6 +225,7 @@ func GetStock(ctx context.Context, m *discordgo.MessageCreate) ([]*dto.Stock, er
 	res, err := stock.Get(
 		ctx,
 		&stock.GetInput{
+			UserID: m.Author.ID,
 			Symbol: symbol,
 		},
 	)
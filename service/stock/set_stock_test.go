@@ -0,0 +1,104 @@
+package stock
+
+import (
+	"testing"
+
+	"discordBot/model/dto"
+)
+
+func Test_parseTrade(t *testing.T) {
+	sinopac := &FeeSchedule{Broker: "sinopac", Rate: 0.001425, MinFee: 20, SellTaxRate: 0.003}
+	firstrade := &FeeSchedule{Broker: "firstrade", DividendTaxRate: 0.3}
+	fubon := &FeeSchedule{Broker: "fubon", Rate: 0.001425, MinFee: 20, SellTaxRate: 0.003, Currency: "TWD"}
+	schedules := &FeeSchedules{Brokers: map[string]*FeeSchedule{"sinopac": sinopac, "firstrade": firstrade, "fubon": fubon}}
+
+	tests := []struct {
+		name     string
+		content  string
+		side     string
+		schedule *FeeSchedule
+		want     *dto.Stock
+		wantErr  bool
+	}{
+		{
+			name:    "buy with defaults",
+			content: "$buy tsla 10 250.5",
+			side:    dto.StockSideBuy,
+			want:    &dto.Stock{Symbol: "TSLA", Side: dto.StockSideBuy, Units: 10, Price: 250.5, Currency: "USD"},
+		},
+		{
+			name:    "sell with fee and currency",
+			content: "$sell  2330.TW  1000 600 20 twd ",
+			side:    dto.StockSideSell,
+			want:    &dto.Stock{Symbol: "2330.TW", Side: dto.StockSideSell, Units: 1000, Price: 600, Fee: 20, Currency: "TWD"},
+		},
+		{
+			name:     "tax from default broker with explicit fee",
+			content:  "$sell 2330.TW 1000 600 0 TWD",
+			side:     dto.StockSideSell,
+			schedule: sinopac,
+			want:     &dto.Stock{Symbol: "2330.TW", Side: dto.StockSideSell, Units: 1000, Price: 600, Fee: 0, Tax: 1800, Broker: "sinopac", Currency: "TWD"},
+		},
+		{
+			name:     "schedule fee on buy",
+			content:  "$buy 0050 1000 150",
+			side:     dto.StockSideBuy,
+			schedule: sinopac,
+			want:     &dto.Stock{Symbol: "0050", Side: dto.StockSideBuy, Units: 1000, Price: 150, Fee: 213.75, Broker: "sinopac", Currency: "USD"},
+		},
+		{
+			name:     "broker option overrides default",
+			content:  "$sell 0050 10 150 broker=SINOPAC tax=0",
+			side:     dto.StockSideSell,
+			schedule: firstrade,
+			want:     &dto.Stock{Symbol: "0050", Side: dto.StockSideSell, Units: 10, Price: 150, Fee: 20, Broker: "sinopac", Currency: "USD"},
+		},
+		{
+			name:     "currency schedule on same currency",
+			content:  "$sell 2330.TW 1000 600 0 TWD broker=fubon",
+			side:     dto.StockSideSell,
+			schedule: firstrade,
+			want:     &dto.Stock{Symbol: "2330.TW", Side: dto.StockSideSell, Units: 1000, Price: 600, Fee: 0, Tax: 1800, Broker: "fubon", Currency: "TWD"},
+		},
+		{
+			name:     "default currency schedule skipped for other currency",
+			content:  "$sell TSLA 10 150",
+			side:     dto.StockSideSell,
+			schedule: fubon,
+			want:     &dto.Stock{Symbol: "TSLA", Side: dto.StockSideSell, Units: 10, Price: 150, Currency: "USD"},
+		},
+		{name: "broker currency mismatch", content: "$buy TSLA 1 100 broker=fubon", side: dto.StockSideBuy, wantErr: true},
+		{
+			name:    "explicit fee and tax options",
+			content: "$sell TSLA 1 100 fee=1.5 tax=0.01",
+			side:    dto.StockSideSell,
+			want:    &dto.Stock{Symbol: "TSLA", Side: dto.StockSideSell, Units: 1, Price: 100, Fee: 1.5, Tax: 0.01, Currency: "USD"},
+		},
+		{name: "unknown broker", content: "$buy TSLA 1 100 broker=unknown", side: dto.StockSideBuy, wantErr: true},
+		{name: "unsupported option", content: "$buy TSLA 1 100 currency=TWD", side: dto.StockSideBuy, wantErr: true},
+		{name: "negative tax", content: "$sell TSLA 1 100 tax=-1", side: dto.StockSideSell, wantErr: true},
+		{name: "too many arguments", content: "$buy TSLA 1 100 0 USD extra", side: dto.StockSideBuy, wantErr: true},
+		{name: "missing price", content: "$buy TSLA 10", side: dto.StockSideBuy, wantErr: true},
+		{name: "zero units", content: "$buy TSLA 0 100", side: dto.StockSideBuy, wantErr: true},
+		{name: "negative fee", content: "$buy TSLA 1 100 -1", side: dto.StockSideBuy, wantErr: true},
+		{name: "invalid currency", content: "$buy TSLA 1 100 0 DOLLAR", side: dto.StockSideBuy, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTrade(tt.content, tt.side, schedules, tt.schedule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTrade() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTrade() unexpected error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("parseTrade() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
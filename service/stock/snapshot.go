@@ -0,0 +1,166 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	snapshotdao "discordBot/model/dao/snapshot"
+	stockdao "discordBot/model/dao/stock"
+	"discordBot/model/dto"
+	"discordBot/pkg/logger"
+	"discordBot/service/exchange"
+)
+
+// SnapshotRepository 每日快照倉庫接口類型
+type SnapshotRepository interface {
+	SaveSnapshots(ctx context.Context, snapshots []*dto.PortfolioSnapshot) error
+	GetSnapshots(ctx context.Context, input *snapshotdao.GetInput) ([]*dto.PortfolioSnapshot, error)
+}
+
+// snapshotDaoDeps 封裝 Snapshot DAO 依賴
+type snapshotDaoDeps struct{}
+
+func (d snapshotDaoDeps) SaveSnapshots(ctx context.Context, snapshots []*dto.PortfolioSnapshot) error {
+	return snapshotdao.Upsert(ctx, nil, snapshots)
+}
+
+func (d snapshotDaoDeps) GetSnapshots(ctx context.Context, input *snapshotdao.GetInput) ([]*dto.PortfolioSnapshot, error) {
+	return snapshotdao.Get(ctx, input)
+}
+
+// snapshotDate 快照日期，以美東日期為準（收盤後結算仍屬同一交易日）
+func snapshotDate(t time.Time) time.Time {
+	local := t.In(usExchange().Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// historyRangeStart 依區間代碼（1W/1M/YTD/1Y）計算起始日期
+func historyRangeStart(code string, today time.Time) (time.Time, error) {
+	switch strings.ToUpper(code) {
+	case "1W":
+		return today.AddDate(0, 0, -7), nil
+	case "1M":
+		return today.AddDate(0, -1, 0), nil
+	case "YTD":
+		return time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location()), nil
+	case "1Y":
+		return today.AddDate(-1, 0, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("不支援的區間: %s (可用: 1W, 1M, YTD, 1Y)", code)
+	}
+}
+
+// History : 查詢呼叫者在指定區間的投資組合表現
+func History(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $history 1M
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) != 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $history <1W|1M|YTD|1Y>")
+	}
+
+	return history(ctx, stockDaoDeps{}, snapshotDaoDeps{}, m.Author.ID, strSlice[1])
+}
+
+func history(ctx context.Context, repo StockRepository, snapshotRepo SnapshotRepository, userID string, code string) (string, error) {
+	today := snapshotDate(nowFunc())
+	from, err := historyRangeStart(code, today)
+	if err != nil {
+		return "", err
+	}
+
+	snapshots, err := snapshotRepo.GetSnapshots(ctx, &snapshotdao.GetInput{
+		UserID: userID,
+		Symbol: dto.PortfolioSnapshotTotal,
+		From:   from,
+		To:     today,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(snapshots) == 0 {
+		return "", fmt.Errorf("%s 區間內沒有快照資料", strings.ToUpper(code))
+	}
+	snapshots = latestCurrencySnapshots(snapshots)
+
+	text := formatHistory(strings.ToUpper(code), snapshots)
+	if len(snapshots) < 2 {
+		return text, nil
+	}
+
+	trades, err := repo.Get(
+		ctx,
+		&stockdao.GetInput{
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	rates, err := historyRates(ctx, snapshots[len(snapshots)-1].Currency, trades)
+	if err != nil {
+		logger.Warn("無法取得匯率，略過區間報酬率", "userID", userID, "error", err)
+		return text, nil
+	}
+
+	returns, err := PortfolioReturns(snapshots, trades, rates)
+	if err != nil {
+		logger.Warn("無法計算區間報酬率", "userID", userID, "error", err)
+		return text, nil
+	}
+
+	return text + "\n" + formatReturns(returns), nil
+}
+
+// historyRates 交易幣別與快照幣別不同時才取得匯率，否則回傳 nil
+func historyRates(ctx context.Context, currency string, trades []*dto.Stock) (exchange.Rates, error) {
+	if currency == "" {
+		return nil, nil
+	}
+	for _, trade := range trades {
+		if holdingCurrency(trade.Currency) != currency {
+			return getRates(ctx)
+		}
+	}
+	return nil, nil
+}
+
+// formatHistory 區間表現顯示格式，snapshots 需依日期由舊到新排序
+func formatHistory(code string, snapshots []*dto.PortfolioSnapshot) string {
+	first := snapshots[0]
+	last := snapshots[len(snapshots)-1]
+
+	high, low := first, first
+	for _, snapshot := range snapshots {
+		if snapshot.Value > high.Value {
+			high = snapshot
+		}
+		if snapshot.Value < low.Value {
+			low = snapshot
+		}
+	}
+
+	// 區間損益 = 未實現、已實現損益與累計股息收入的變化（與今日損益、時間加權報酬率一致計入股息），不受期間買賣金流影響
+	dividends := last.DividendIncome - first.DividendIncome
+	change := (last.Profit + last.RealizedProfit) - (first.Profit + first.RealizedProfit) + dividends
+	var changePercent float64
+	if first.Value > 0 {
+		changePercent = change / first.Value * 100
+	}
+
+	var b strings.Builder
+	currency := ""
+	if last.Currency != "" {
+		currency = "，" + last.Currency
+	}
+	fmt.Fprintf(&b, "%s 投資組合表現（%s ~ %s，%d 筆快照%s）\n", code, first.Date.Format("2006-01-02"), last.Date.Format("2006-01-02"), len(snapshots), currency)
+	fmt.Fprintf(&b, "期初市值: %.2f, 期末市值: %.2f\n", first.Value, last.Value)
+	fmt.Fprintf(&b, "最高市值: %.2f (%s), 最低市值: %.2f (%s)\n", high.Value, high.Date.Format("2006-01-02"), low.Value, low.Date.Format("2006-01-02"))
+	fmt.Fprintf(&b, "區間損益: %.2f (%+.2f %%)，其中股息收入: %.2f", change, changePercent, dividends)
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/model/dto"
+)
+
+func Test_historyRangeStart(t *testing.T) {
+	today := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		code    string
+		want    time.Time
+		wantErr bool
+	}{
+		{code: "1W", want: time.Date(2026, 3, 24, 0, 0, 0, 0, time.UTC)},
+		{code: "1m", want: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{code: "YTD", want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{code: "1Y", want: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{code: "5Y", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := historyRangeStart(tt.code, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("historyRangeStart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("historyRangeStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_snapshotDate(t *testing.T) {
+	// 美東 7/15 20:00 收盤後結算，台北已是 7/16
+	got := snapshotDate(time.Date(2026, 7, 16, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("snapshotDate() = %v, want %v", got, want)
+	}
+}
+
+func Test_history(t *testing.T) {
+	originalNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 22, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = originalNow }()
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	repo := &MockSnapshotRepository{
+		Snapshots: []*dto.PortfolioSnapshot{
+			{UserID: "u1", Date: day(6, 1), Symbol: dto.PortfolioSnapshotTotal, Value: 500, Profit: 0},
+			{UserID: "u1", Date: day(7, 8), Symbol: dto.PortfolioSnapshotTotal, Value: 1000, Profit: 100, DividendIncome: 10},
+			{UserID: "u1", Date: day(7, 10), Symbol: dto.PortfolioSnapshotTotal, Value: 1300, Profit: 300},
+			{UserID: "u1", Date: day(7, 13), Symbol: dto.PortfolioSnapshotTotal, Value: 900, Profit: 0},
+			{UserID: "u1", Date: day(7, 15), Symbol: dto.PortfolioSnapshotTotal, Value: 1100, Profit: 100, RealizedProfit: 50, DividendIncome: 30},
+			{UserID: "u2", Date: day(7, 15), Symbol: dto.PortfolioSnapshotTotal, Value: 1},
+		},
+	}
+
+	got, err := history(context.Background(), &MockStockRepository{}, repo, "u1", "1w")
+	if err != nil {
+		t.Fatalf("history() unexpected error = %v", err)
+	}
+	for _, want := range []string{
+		"1W 投資組合表現（2026-07-08 ~ 2026-07-15，4 筆快照）",
+		"期初市值: 1000.00, 期末市值: 1100.00",
+		"最高市值: 1300.00 (2026-07-10), 最低市值: 900.00 (2026-07-13)",
+		// 損益變化 50 加上區間股息 20
+		"區間損益: 70.00 (+7.00 %)，其中股息收入: 20.00",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("history() = %q, want contains %q", got, want)
+		}
+	}
+
+	if _, err := history(context.Background(), &MockStockRepository{}, repo, "u3", "1M"); err == nil {
+		t.Errorf("history() error = nil, want no data error")
+	}
+}
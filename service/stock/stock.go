@@ -35,8 +35,18 @@ func Quote(ctx context.Context, message string) (string, error) {
 		return "", fmt.Errorf("搜尋失敗")
 	}
 
-	resStr1 := fmt.Sprintf("Finnhub 查詢標的為:%s 目前價格為:%v 今天漲跌幅:%v%s", symbol, res.CurrentPrice, res.PercentChange, "%")
-	logger.Info("股票查詢成功", "symbol", symbol, "price", res.CurrentPrice, "change", res.PercentChange)
+	session := SessionAt(nowFunc())
+	quote := NewSessionQuote(res, session, getLastPrice(symbol))
+
+	var resStr1 string
+	if quote.RegularFallback {
+		resStr1 = fmt.Sprintf("Finnhub 查詢標的為:%s [%s] 尚無%s成交資料 最近收盤價為:%v 正規時段漲跌幅:%v%s", symbol, session.Label(), session.Label(), res.CurrentPrice, res.PercentChange, "%")
+	} else if session.IsExtended() {
+		resStr1 = fmt.Sprintf("Finnhub 查詢標的為:%s [%s] 目前價格為:%.2f %s漲跌幅:%.2f%s (參考收盤價:%.2f)", symbol, session.Label(), quote.Price, session.changeLabel(), quote.Change, "%", quote.ReferenceClose)
+	} else {
+		resStr1 = fmt.Sprintf("Finnhub 查詢標的為:%s [%s] 目前價格為:%v 今天漲跌幅:%v%s", symbol, session.Label(), res.CurrentPrice, res.PercentChange, "%")
+	}
+	logger.Info("股票查詢成功", "symbol", symbol, "session", session, "price", quote.Price, "change", quote.Change)
 
 	return resStr1, nil
 }
@@ -61,6 +71,44 @@ func GetChange(ctx context.Context, stock string) (float32, error) {
 	return res.PercentChange, nil
 }
 
+// GetSessionChange : 取得目前時段的報價與漲跌幅（延長時段相對前一個收盤價）
+func GetSessionChange(ctx context.Context, stock string) (*SessionQuote, error) {
+	client := GetClient("finnhub")
+
+	symbol := strings.ToUpper(stock)
+
+	res, err := client.GetQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// CurrentPrice
+	if res.CurrentPrice == 0 {
+		return nil, fmt.Errorf("搜尋失敗")
+	}
+
+	return NewSessionQuote(res, SessionAt(nowFunc()), getLastPrice(symbol)), nil
+}
+
+// GetRegularChange : 取得最近一次正規時段的報價與漲跌幅（相對前一個收盤價，不含延長時段成交）
+func GetRegularChange(ctx context.Context, stock string) (*SessionQuote, error) {
+	client := GetClient("finnhub")
+
+	symbol := strings.ToUpper(stock)
+
+	res, err := client.GetQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// CurrentPrice
+	if res.CurrentPrice == 0 {
+		return nil, fmt.Errorf("搜尋失敗")
+	}
+
+	return NewSessionQuote(res, SessionRegular, 0), nil
+}
+
 type CalculateInput struct {
 	Symbol string
 	Units  float64
@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 )
 
 func Test_Quote(t *testing.T) {
 	ctx := context.Background()
 
+	// 固定於美東盤中時間
+	nowFunc = func() time.Time { return time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
 	tests := []struct {
 		name      string
 		message   string
@@ -26,7 +31,7 @@ func Test_Quote(t *testing.T) {
 					PercentChange: 3.7104,
 				})
 			},
-			want:    "Finnhub 查詢標的為:+TSLA 目前價格為:1137.06 今天漲跌幅:3.7104%",
+			want:    "Finnhub 查詢標的為:+TSLA [盤中] 目前價格為:1137.06 今天漲跌幅:3.7104%",
 			wantErr: false,
 		},
 		{
@@ -46,7 +51,7 @@ func Test_Quote(t *testing.T) {
 					PercentChange: 2.5,
 				})
 			},
-			want:    "Finnhub 查詢標的為:+TSLA 目前價格為:200 今天漲跌幅:2.5%",
+			want:    "Finnhub 查詢標的為:+TSLA [盤中] 目前價格為:200 今天漲跌幅:2.5%",
 			wantErr: false,
 		},
 		{
@@ -80,7 +85,7 @@ func Test_Quote(t *testing.T) {
 					PercentChange: 1.5,
 				})
 			},
-			want:    "Finnhub 查詢標的為:+TSLA 目前價格為:100 今天漲跌幅:1.5%",
+			want:    "Finnhub 查詢標的為:+TSLA [盤中] 目前價格為:100 今天漲跌幅:1.5%",
 			wantErr: false,
 		},
 	}
@@ -0,0 +1,140 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// quoteCacheTTL 參考報價快取時間
+const quoteCacheTTL = time.Hour
+
+type quoteCacheEntry struct {
+	quote     *QuoteResponse
+	session   MarketSession
+	fetchedAt time.Time
+}
+
+// TradeAlerter 依即時成交價計算所屬時段的漲跌幅，並沿用排程檢查的警告邏輯
+type TradeAlerter struct {
+	s                *discordgo.Session
+	redisClient      RedisClient
+	evaluateInterval time.Duration
+
+	mu            sync.Mutex
+	quotes        map[string]quoteCacheEntry
+	lastEvaluated map[string]time.Time
+}
+
+// NewTradeAlerter 創建即時成交警告器
+func NewTradeAlerter(s *discordgo.Session, evaluateInterval time.Duration) *TradeAlerter {
+	return NewTradeAlerterWithDeps(s, redisDeps{}, evaluateInterval)
+}
+
+// NewTradeAlerterWithDeps 使用指定依賴創建即時成交警告器（用於測試）
+func NewTradeAlerterWithDeps(s *discordgo.Session, redisClient RedisClient, evaluateInterval time.Duration) *TradeAlerter {
+	return &TradeAlerter{
+		s:                s,
+		redisClient:      redisClient,
+		evaluateInterval: evaluateInterval,
+		quotes:           make(map[string]quoteCacheEntry),
+		lastEvaluated:    make(map[string]time.Time),
+	}
+}
+
+// HandleTrade 處理一筆成交，同一標的在間隔內只判斷一次
+func (a *TradeAlerter) HandleTrade(symbol string, price float64) {
+	symbol = strings.ToUpper(symbol)
+	if !a.shouldEvaluate(symbol, time.Now()) {
+		return
+	}
+
+	// 避免阻塞串流讀取
+	go a.evaluate(symbol, price)
+}
+
+func (a *TradeAlerter) shouldEvaluate(symbol string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if last, ok := a.lastEvaluated[symbol]; ok && now.Sub(last) < a.evaluateInterval {
+		return false
+	}
+
+	a.lastEvaluated[symbol] = now
+	return true
+}
+
+func (a *TradeAlerter) evaluate(symbol string, price float64) {
+	taskConfig := config.GetTaskConfig()
+	externalTimeout := durationFromSeconds(taskConfig.ExternalCallTimeoutSeconds, 15*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*externalTimeout)
+	defer cancel()
+
+	session := SessionAt(nowFunc())
+	if session == SessionClosed {
+		return
+	}
+
+	res, err := a.getQuote(ctx, externalTimeout, symbol, session)
+	if err != nil {
+		logger.Error("取得參考報價失敗", "symbol", symbol, "error", err)
+		return
+	}
+
+	lists, err := loadWatchLists(ctx, a.redisClient, taskConfig)
+	if err != nil {
+		logger.Error("取得觀察列表失敗", "symbol", symbol, "error", err)
+		return
+	}
+
+	_, bySymbol := watchListsBySymbol(lists)
+	quote := NewSessionQuote(res, session, price)
+	for _, list := range bySymbol[symbol] {
+		alertOnChange(ctx, a.s, a.redisClient, taskConfig, externalTimeout, list, symbol, quote)
+	}
+}
+
+// getQuote 取得參考收盤價所需的報價（帶快取，避免每筆成交都呼叫 API）
+// 快取依時段區分，收盤後不會把盤中的 c 當作盤後的參考收盤價
+func (a *TradeAlerter) getQuote(ctx context.Context, externalTimeout time.Duration, symbol string, session MarketSession) (*QuoteResponse, error) {
+	a.mu.Lock()
+	entry, ok := a.quotes[symbol]
+	a.mu.Unlock()
+	if ok && entry.session == session && time.Since(entry.fetchedAt) < quoteCacheTTL {
+		return entry.quote, nil
+	}
+
+	quoteCtx, quoteCancel := context.WithTimeout(ctx, externalTimeout)
+	res, err := GetClient("finnhub").GetQuote(quoteCtx, symbol)
+	quoteCancel()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.CurrentPrice == 0 || res.PreviousClose == 0 {
+		return nil, fmt.Errorf("搜尋失敗")
+	}
+
+	a.mu.Lock()
+	a.quotes[symbol] = quoteCacheEntry{quote: res, session: session, fetchedAt: time.Now()}
+	a.mu.Unlock()
+
+	return res, nil
+}
+
+// changePercent 計算相對昨收價的漲跌幅（%）
+func changePercent(price, previousClose float64) float32 {
+	if previousClose == 0 {
+		return 0
+	}
+	return float32((price - previousClose) / previousClose * 100)
+}
@@ -0,0 +1,81 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_changePercent(t *testing.T) {
+	tests := []struct {
+		name          string
+		price         float64
+		previousClose float64
+		want          float32
+	}{
+		{name: "up", price: 105, previousClose: 100, want: 5},
+		{name: "down", price: 90, previousClose: 100, want: -10},
+		{name: "flat", price: 100, previousClose: 100, want: 0},
+		{name: "missing previous close", price: 100, previousClose: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changePercent(tt.price, tt.previousClose); got != tt.want {
+				t.Errorf("changePercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_TradeAlerter_shouldEvaluate(t *testing.T) {
+	alerter := NewTradeAlerterWithDeps(nil, NewMockRedisClient(), 5*time.Second)
+	now := time.Now()
+
+	if !alerter.shouldEvaluate("TSLA", now) {
+		t.Errorf("shouldEvaluate() first trade = false, want true")
+	}
+	if alerter.shouldEvaluate("TSLA", now.Add(time.Second)) {
+		t.Errorf("shouldEvaluate() within interval = true, want false")
+	}
+	if !alerter.shouldEvaluate("AAPL", now.Add(time.Second)) {
+		t.Errorf("shouldEvaluate() other symbol = false, want true")
+	}
+	if !alerter.shouldEvaluate("TSLA", now.Add(6*time.Second)) {
+		t.Errorf("shouldEvaluate() after interval = false, want true")
+	}
+}
+
+func Test_TradeAlerter_getQuote(t *testing.T) {
+	ctx := context.Background()
+
+	mock := NewMockFinnhubClient()
+	mock.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 110, PreviousClose: 100})
+	SetDefaultClient(mock)
+	defer ResetDefaultClient()
+
+	alerter := NewTradeAlerterWithDeps(nil, NewMockRedisClient(), time.Second)
+
+	got, err := alerter.getQuote(ctx, time.Second, "TSLA", SessionRegular)
+	if err != nil || got.PreviousClose != 100 {
+		t.Fatalf("getQuote() = %v, %v, want previous close 100", got, err)
+	}
+
+	// 快取期間內不再呼叫 API
+	mock.Err = errors.New("should use cache")
+	got, err = alerter.getQuote(ctx, time.Second, "TSLA", SessionRegular)
+	if err != nil || got.PreviousClose != 100 {
+		t.Errorf("getQuote() cached = %v, %v, want previous close 100", got, err)
+	}
+
+	// 時段改變時重新取得報價，盤中的 c 不沿用為盤後參考收盤價
+	if _, err := alerter.getQuote(ctx, time.Second, "TSLA", SessionAfterHours); err == nil {
+		t.Errorf("getQuote() new session error = nil, want refetch")
+	}
+
+	mock.Err = nil
+	if _, err := alerter.getQuote(ctx, time.Second, "INVALID", SessionRegular); err == nil {
+		t.Errorf("getQuote() unknown symbol error = nil, want error")
+	}
+}
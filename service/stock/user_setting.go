@@ -0,0 +1,124 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/model/dao/usersetting"
+	"discordBot/model/dto"
+)
+
+// UserSettingRepository 使用者設定倉庫接口類型
+type UserSettingRepository interface {
+	GetSetting(ctx context.Context, userID string) (*dto.UserSetting, error)
+}
+
+// userSettingDaoDeps 封裝 UserSetting DAO 依賴
+type userSettingDaoDeps struct{}
+
+func (d userSettingDaoDeps) GetSetting(ctx context.Context, userID string) (*dto.UserSetting, error) {
+	return usersetting.Get(ctx, userID)
+}
+
+// getCostMethod 取得使用者的成本計算方式，未設定時使用平均成本
+func getCostMethod(ctx context.Context, repo UserSettingRepository, userID string) (CostMethod, error) {
+	setting, err := repo.GetSetting(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if setting == nil {
+		return CostMethodAverage, nil
+	}
+
+	return ParseCostMethod(setting.CostMethod)
+}
+
+// CostMethodCommand : 查詢或設定成本計算方式
+func CostMethodCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $cost_method [fifo|lifo|average]
+	strSlice := strings.Fields(m.Content)
+
+	switch len(strSlice) {
+	case 1:
+		method, err := getCostMethod(ctx, userSettingDaoDeps{}, m.Author.ID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("目前成本計算方式: %s", method.Label()), nil
+	case 2:
+		method, err := ParseCostMethod(strSlice[1])
+		if err != nil {
+			return "", err
+		}
+		if err := usersetting.UpsertCostMethod(ctx, nil, m.Author.ID, string(method)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("成本計算方式已設定為: %s", method.Label()), nil
+	default:
+		return "", fmt.Errorf("參數錯誤，格式: $cost_method [fifo|lifo|average]")
+	}
+}
+
+// ReportCommand : 查詢或設定每日收益報告
+func ReportCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $report [on|off|dm|here|default]
+	strSlice := strings.Fields(m.Content)
+	if len(strSlice) > 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $report [on|off|dm|here|default]")
+	}
+
+	setting, err := userSettingDaoDeps{}.GetSetting(ctx, m.Author.ID)
+	if err != nil {
+		return "", err
+	}
+	if setting == nil {
+		setting = &dto.UserSetting{UserID: m.Author.ID, ReportEnabled: true}
+	}
+
+	if len(strSlice) == 1 {
+		return formatReportSetting(setting), nil
+	}
+
+	switch strings.ToLower(strSlice[1]) {
+	case "on":
+		setting.ReportEnabled = true
+	case "off":
+		setting.ReportEnabled = false
+	case "dm":
+		setting.ReportEnabled = true
+		setting.ReportChannelID = dto.ReportChannelDM
+	case "here":
+		setting.ReportEnabled = true
+		setting.ReportChannelID = m.ChannelID
+	case "default":
+		setting.ReportEnabled = true
+		setting.ReportChannelID = ""
+	default:
+		return "", fmt.Errorf("參數錯誤，格式: $report [on|off|dm|here|default]")
+	}
+
+	if err := usersetting.UpsertReport(ctx, nil, m.Author.ID, setting.ReportEnabled, setting.ReportChannelID); err != nil {
+		return "", err
+	}
+
+	return "已更新，" + formatReportSetting(setting), nil
+}
+
+// formatReportSetting 收益報告設定說明
+func formatReportSetting(setting *dto.UserSetting) string {
+	if !setting.ReportEnabled {
+		return "每日收益報告: 關閉"
+	}
+
+	switch setting.ReportChannelID {
+	case "":
+		return "每日收益報告: 開啟（預設頻道）"
+	case dto.ReportChannelDM:
+		return "每日收益報告: 開啟（私訊）"
+	default:
+		return fmt.Sprintf("每日收益報告: 開啟（<#%s>）", setting.ReportChannelID)
+	}
+}
@@ -0,0 +1,216 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+)
+
+// volumeAverage 標的近期日均量（Redis 快取，當日有效）
+type volumeAverage struct {
+	// 平均成交量
+	Average float64 `json:"average"`
+	// 實際納入計算的交易日數
+	Days int `json:"days"`
+	// 計算日期 (YYYY-MM-DD，美東時間)
+	Date string `json:"date"`
+}
+
+// volumeMinSessionFraction 換算日均量時已交易時間比例的下限（約開盤後 40 分鐘），避免開盤初期成交量集中而誤判
+const volumeMinSessionFraction = 0.1
+
+// volumePlanRestrictedKey Finnhub 方案不支援日線時的 Redis 標記，當日不再查詢成交量
+const volumePlanRestrictedKey = "volume_plan_restricted"
+
+// volumeStats 標的今日成交量與日均量
+type volumeStats struct {
+	Volume  float64
+	Average float64
+	Days    int
+	// 正規時段已交易時間比例（0~1），日均量依此換算為截至目前的預期成交量
+	Fraction float64
+}
+
+// Expected 依已交易時間比例換算的截至目前預期成交量
+func (v *volumeStats) Expected() float64 {
+	if v == nil {
+		return 0
+	}
+	return v.Average * v.Fraction
+}
+
+// Ratio 今日累計成交量為預期成交量的倍數
+func (v *volumeStats) Ratio() float64 {
+	if v.Expected() <= 0 {
+		return 0
+	}
+	return v.Volume / v.Expected()
+}
+
+// sessionFraction 正規時段已交易時間比例，下限為 volumeMinSessionFraction，收盤後或非交易日為 1
+func sessionFraction(now time.Time) float64 {
+	open, close, ok := usExchange().SessionBounds(now)
+	if !ok || !now.Before(close) {
+		return 1
+	}
+	fraction := float64(now.Sub(open)) / float64(close.Sub(open))
+	return math.Max(fraction, volumeMinSessionFraction)
+}
+
+// onDate 日線時間戳是否為指定的美東日期（date 為 snapshotDate 的 UTC 00:00），相容 UTC 與美東午夜的時間戳
+func onDate(ts int64, date time.Time) bool {
+	t := time.Unix(ts, 0)
+	day := date.Format("2006-01-02")
+	return t.UTC().Format("2006-01-02") == day || t.In(usExchange().Location).Format("2006-01-02") == day
+}
+
+// volumeAverageKey 日均量快取的 Redis key
+func volumeAverageKey(symbol string) string {
+	return "volume_avg:" + symbol
+}
+
+// dailyVolumes 日線成交量（依日期排序，key 為日線時間戳）
+func dailyVolumes(ctx context.Context, client FinnhubClient, symbol string, from, to time.Time) ([]int64, map[int64]float64, error) {
+	candles, err := client.GetCandles(ctx, symbol, "D", from.Unix(), to.Unix())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dates []int64
+	volumes := make(map[int64]float64, len(candles.Timestamp))
+	for i, ts := range candles.Timestamp {
+		if i >= len(candles.Volume) {
+			break
+		}
+		dates = append(dates, ts)
+		volumes[ts] = float64(candles.Volume[i])
+	}
+	return dates, volumes, nil
+}
+
+// getAverageVolume 取得今日之前 days 個交易日的日均量，同一天只向資料來源計算一次
+// 交易日數不足一半時回傳 0（新上市等資料不足的標的不判斷）
+func getAverageVolume(ctx context.Context, redisClient RedisClient, client FinnhubClient, symbol string, days int, now time.Time) (*volumeAverage, error) {
+	today := snapshotDate(now)
+
+	cached, err := redisClient.Get(ctx, volumeAverageKey(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if cached != "" {
+		average := &volumeAverage{}
+		if err := json.Unmarshal([]byte(cached), average); err == nil && average.Date == today.Format("2006-01-02") {
+			return average, nil
+		}
+	}
+
+	// 假日與週末約佔 1/3，多取一些日曆天
+	dates, volumes, err := dailyVolumes(ctx, client, symbol, today.AddDate(0, 0, -days*2-7), today.Add(-time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("取得 %s 日線成交量錯誤: %w", symbol, err)
+	}
+
+	average := &volumeAverage{Date: today.Format("2006-01-02")}
+	var total float64
+	for i := len(dates) - 1; i >= 0 && average.Days < days; i-- {
+		if dates[i] >= today.Unix() || volumes[dates[i]] <= 0 {
+			continue
+		}
+		total += volumes[dates[i]]
+		average.Days++
+	}
+	if average.Days*2 >= days && average.Days > 0 {
+		average.Average = total / float64(average.Days)
+	}
+
+	value, err := json.Marshal(average)
+	if err != nil {
+		return nil, err
+	}
+	if err := redisClient.Set(ctx, volumeAverageKey(symbol), string(value), alertRecordTTL(now)); err != nil {
+		logger.Error("寫入日均量快取失敗", "symbol", symbol, "error", err)
+	}
+	return average, nil
+}
+
+// getVolumeStats 取得標的今日（美東時間）累計成交量與日均量
+// 今日成交量取時間戳落在今日的最後一根日線，日均量依正規時段已交易時間比例換算後比較
+func getVolumeStats(ctx context.Context, redisClient RedisClient, client FinnhubClient, symbol string, days int, now time.Time) (*volumeStats, error) {
+	average, err := getAverageVolume(ctx, redisClient, client, symbol, days, now)
+	if err != nil {
+		return nil, err
+	}
+	if average.Average <= 0 {
+		return &volumeStats{Days: average.Days}, nil
+	}
+
+	today := snapshotDate(now)
+	dates, volumes, err := dailyVolumes(ctx, client, symbol, today, now)
+	if err != nil {
+		return nil, fmt.Errorf("取得 %s 今日成交量錯誤: %w", symbol, err)
+	}
+
+	stats := &volumeStats{
+		Average:  average.Average,
+		Days:     average.Days,
+		Fraction: sessionFraction(now),
+	}
+	for i := len(dates) - 1; i >= 0; i-- {
+		if onDate(dates[i], today) {
+			stats.Volume = volumes[dates[i]]
+			break
+		}
+	}
+	return stats, nil
+}
+
+// volumeAlert 今日成交量超過日均量指定倍數時的警告，未達到時回傳 nil
+func volumeAlert(owner string, symbol string, session MarketSession, stats *volumeStats, multiplier float64) *watchAlert {
+	if multiplier <= 0 || stats.Ratio() < multiplier {
+		return nil
+	}
+
+	return &watchAlert{
+		RecordKey: alertRecordKey(owner, symbol, session) + ":volume",
+		Message: fmt.Sprintf("成交量異常: 今日 %s 為 %d 日均量 %s 依已交易時間（%.0f%%）換算 %s 的 %.1f 倍",
+			formatVolume(stats.Volume), stats.Days, formatVolume(stats.Average), stats.Fraction*100, formatVolume(stats.Expected()), stats.Ratio()),
+	}
+}
+
+// pendingVolumeLists 今日尚未發出成交量警告的觀察清單（全部已通知時不需再查詢成交量）
+func pendingVolumeLists(ctx context.Context, redisClient RedisClient, lists []*watchList, symbol string, session MarketSession) ([]*watchList, error) {
+	var pending []*watchList
+	for _, list := range lists {
+		record, err := redisClient.Get(ctx, alertRecordKey(list.Owner, symbol, session)+":volume")
+		if err != nil {
+			return nil, err
+		}
+		if record == "" {
+			pending = append(pending, list)
+		}
+	}
+	return pending, nil
+}
+
+// volumeSpikeEnabled 是否在此時段檢查成交量（只在正規交易時段，延長時段成交量不具參考性）
+func volumeSpikeEnabled(taskConfig *config.TaskConfig, session MarketSession) bool {
+	return taskConfig.VolumeSpikeMultiplier > 0 && taskConfig.VolumeAverageDays > 0 && session == SessionRegular
+}
+
+// formatVolume 成交量顯示格式（K/M/B）
+func formatVolume(volume float64) string {
+	switch {
+	case volume >= 1e9:
+		return fmt.Sprintf("%.2fB", volume/1e9)
+	case volume >= 1e6:
+		return fmt.Sprintf("%.2fM", volume/1e6)
+	case volume >= 1e3:
+		return fmt.Sprintf("%.1fK", volume/1e3)
+	}
+	return fmt.Sprintf("%.0f", volume)
+}
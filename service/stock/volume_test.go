@@ -0,0 +1,166 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/pkg/config"
+)
+
+func Test_getVolumeStats(t *testing.T) {
+	// 2026-07-15 (週三) 美東 11:00
+	now := time.Date(2026, 7, 15, 15, 0, 0, 0, time.UTC)
+	today := snapshotDate(now)
+
+	// 今日之前 30 個日曆天的日線，週末無資料；最近 20 個交易日成交量為 1M，更早為 5M
+	candles := &Candles{}
+	tradingDays := 0
+	for d := today.AddDate(0, 0, -1); d.After(today.AddDate(0, 0, -40)); d = d.AddDate(0, 0, -1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		volume := float32(1e6)
+		if tradingDays >= 20 {
+			volume = 5e6
+		}
+		candles.Timestamp = append([]int64{d.Unix()}, candles.Timestamp...)
+		candles.Close = append(candles.Close, 100)
+		candles.Volume = append([]float32{volume}, candles.Volume...)
+		tradingDays++
+	}
+	// 今日日線的時間戳為美東午夜而非 UTC 00:00
+	candles.Timestamp = append(candles.Timestamp, time.Date(2026, 7, 15, 0, 0, 0, 0, usExchange().Location).Unix())
+	candles.Close = append(candles.Close, 100)
+	candles.Volume = append(candles.Volume, 3.5e6)
+
+	mockClient := NewMockFinnhubClient()
+	mockClient.Candles["TSLA"] = candles
+
+	redisClient := NewMockRedisClient()
+	stats, err := getVolumeStats(context.Background(), redisClient, mockClient, "TSLA", 20, now)
+	if err != nil {
+		t.Fatalf("getVolumeStats() unexpected error = %v", err)
+	}
+	// 開盤後 90 分鐘（共 390 分鐘），預期成交量為日均量的 90/390
+	if !floatEqual(stats.Average, 1e6) || stats.Days != 20 || !floatEqual(stats.Volume, 3.5e6) || !floatEqual(stats.Fraction, 90.0/390) || !floatEqual(stats.Ratio(), 3.5*390/90) {
+		t.Errorf("stats = %+v, want average 1M over 20 days and volume 3.5M", stats)
+	}
+	if redisClient.Data[volumeAverageKey("TSLA")] == "" {
+		t.Errorf("average not cached")
+	}
+
+	// 日均量使用當日快取，只查詢今日成交量
+	candles.Volume[len(candles.Volume)-2] = 9e9
+	if stats, err := getVolumeStats(context.Background(), redisClient, mockClient, "TSLA", 20, now); err != nil || !floatEqual(stats.Average, 1e6) {
+		t.Errorf("cached stats = %+v, %v, want average 1M", stats, err)
+	}
+
+	// 隔日快取失效，重新計算
+	if stats, err := getVolumeStats(context.Background(), redisClient, mockClient, "TSLA", 20, now.AddDate(0, 0, 1)); err != nil || floatEqual(stats.Average, 1e6) {
+		t.Errorf("next day stats = %+v, %v, want recalculated average", stats, err)
+	}
+
+	// 資料不足一半的交易日不判斷
+	mockClient.Candles["NEW"] = &Candles{Timestamp: []int64{today.AddDate(0, 0, -1).Unix(), today.Unix()}, Close: []float32{10, 10}, Volume: []float32{1e5, 9e5}}
+	if stats, err := getVolumeStats(context.Background(), redisClient, mockClient, "NEW", 20, now); err != nil || stats.Ratio() != 0 {
+		t.Errorf("NEW stats = %+v, %v, want no ratio", stats, err)
+	}
+
+	mockClient.Err = errors.New("rate limited")
+	if _, err := getVolumeStats(context.Background(), NewMockRedisClient(), mockClient, "TSLA", 20, now); err == nil {
+		t.Errorf("getVolumeStats() error = nil, want error")
+	}
+}
+
+func Test_sessionFraction(t *testing.T) {
+	loc := usExchange().Location
+	tests := []struct {
+		name string
+		now  time.Time
+		want float64
+	}{
+		{name: "half session", now: time.Date(2026, 7, 15, 12, 45, 0, 0, loc), want: 0.5},
+		{name: "minimum after open", now: time.Date(2026, 7, 15, 9, 35, 0, 0, loc), want: volumeMinSessionFraction},
+		{name: "after close", now: time.Date(2026, 7, 15, 17, 0, 0, 0, loc), want: 1},
+		{name: "early close", now: time.Date(2026, 11, 27, 11, 15, 0, 0, loc), want: 0.5},
+		{name: "weekend", now: time.Date(2026, 7, 18, 12, 0, 0, 0, loc), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionFraction(tt.now); !floatEqual(got, tt.want) {
+				t.Errorf("sessionFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_alertOnVolume_PlanRestricted(t *testing.T) {
+	nowFunc = func() time.Time { return time.Date(2026, 7, 15, 15, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	mockClient := NewMockFinnhubClient()
+	mockClient.Err = fmt.Errorf("%w: 403", ErrPlanRestricted)
+
+	redisClient := NewMockRedisClient()
+	taskConfig := &config.TaskConfig{VolumeSpikeMultiplier: 3, VolumeAverageDays: 20}
+	lists := []*watchList{{Owner: "user:u1", ChannelID: "c1"}}
+	quote := &SessionQuote{Session: SessionRegular}
+
+	alertOnVolume(context.Background(), nil, redisClient, mockClient, taskConfig, time.Second, lists, "TSLA", quote)
+	if redisClient.Data[volumePlanRestrictedKey] != "true" {
+		t.Fatalf("plan restricted flag not set")
+	}
+
+	// 當日不再查詢日線
+	mockClient.Err = nil
+	alertOnVolume(context.Background(), nil, redisClient, mockClient, taskConfig, time.Second, lists, "TSLA", quote)
+	if _, ok := redisClient.Data[volumeAverageKey("TSLA")]; ok {
+		t.Errorf("volume queried after plan restriction")
+	}
+}
+
+func Test_volumeAlert(t *testing.T) {
+	stats := &volumeStats{Volume: 3.5e6, Average: 1e6, Days: 20, Fraction: 1}
+
+	alert := volumeAlert("user:u1", "TSLA", SessionRegular, stats, 3)
+	if alert == nil || alert.RecordKey != "watch_alert:user:u1:TSLA:volume" || alert.Tier != 0 {
+		t.Fatalf("volumeAlert() = %+v, want volume alert", alert)
+	}
+	if !strings.Contains(alert.Message, "今日 3.50M 為 20 日均量 1.00M 依已交易時間（100%）換算 1.00M 的 3.5 倍") {
+		t.Errorf("Message = %q", alert.Message)
+	}
+
+	if got := volumeAlert("user:u1", "TSLA", SessionRegular, stats, 4); got != nil {
+		t.Errorf("below multiplier = %+v, want nil", got)
+	}
+	if got := volumeAlert("user:u1", "TSLA", SessionRegular, &volumeStats{Volume: 1e6, Fraction: 1}, 3); got != nil {
+		t.Errorf("no average = %+v, want nil", got)
+	}
+
+	redisClient := NewMockRedisClient()
+	redisClient.Data[alert.RecordKey] = alert.record("")
+	lists := []*watchList{{Owner: "user:u1"}, {Owner: "guild:g1"}}
+	pending, err := pendingVolumeLists(context.Background(), redisClient, lists, "TSLA", SessionRegular)
+	if err != nil || len(pending) != 1 || pending[0].Owner != "guild:g1" {
+		t.Errorf("pendingVolumeLists() = %+v, %v, want guild list only", pending, err)
+	}
+}
+
+func Test_formatVolume(t *testing.T) {
+	tests := map[float64]string{
+		950:    "950",
+		12500:  "12.5K",
+		3.5e6:  "3.50M",
+		1.25e9: "1.25B",
+	}
+	for volume, want := range tests {
+		if got := formatVolume(volume); got != want {
+			t.Errorf("formatVolume(%v) = %q, want %q", volume, got, want)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/pkg/config"
+	"discordBot/pkg/logger"
+)
+
+const (
+	// watchRegistryKey 所有個人/伺服器觀察清單擁有者的集合
+	watchRegistryKey = "watch_lists"
+	// legacyWatchListKey 舊版全域觀察清單（Redis List），警告發送到 WATCH_LIST_CHANNEL_ID
+	legacyWatchListKey = "watch_list"
+	// watchOwnerGlobal 舊版全域觀察清單的擁有者
+	watchOwnerGlobal = "global"
+	// watchListMaxSymbols 單一觀察清單的標的上限
+	watchListMaxSymbols = 50
+)
+
+// watchList 一份觀察清單與其警告發送位置
+type watchList struct {
+	// 擁有者: user:<userID>、guild:<guildID> 或 global
+	Owner     string
+	ChannelID string
+	Symbols   []string
+	// 各標的自訂門檻
+	Thresholds map[string]*watchThreshold
+}
+
+// userWatchOwner 個人觀察清單擁有者
+func userWatchOwner(userID string) string {
+	return "user:" + userID
+}
+
+// guildWatchOwner 伺服器觀察清單擁有者
+func guildWatchOwner(guildID string) string {
+	return "guild:" + guildID
+}
+
+// watchSymbolsKey 觀察清單標的集合的 Redis key
+func watchSymbolsKey(owner string) string {
+	return "watch:" + owner
+}
+
+// watchChannelKey 觀察清單警告頻道的 Redis key（最後一次執行 $watch add 的頻道）
+func watchChannelKey(owner string) string {
+	return "watch:" + owner + ":channel"
+}
+
+// Mention 警告訊息開頭提及的對象，伺服器清單不提及特定使用者
+func (w *watchList) Mention(defaultUserID string) string {
+	if userID, ok := strings.CutPrefix(w.Owner, "user:"); ok {
+		return fmt.Sprintf("<@%s> ", userID)
+	}
+	if w.Owner == watchOwnerGlobal && defaultUserID != "" {
+		return fmt.Sprintf("<@%s> ", defaultUserID)
+	}
+	return ""
+}
+
+// WatchCommand : 管理呼叫者或所在伺服器的觀察清單
+func WatchCommand(ctx context.Context, m *discordgo.MessageCreate) (string, error) {
+	// example : $watch add TSLA NVDA / $watch guild remove TSLA / $watch set TSLA up=8 down=5 / $watch list
+	return watchCommand(ctx, redisDeps{}, m)
+}
+
+func watchCommand(ctx context.Context, redisClient RedisClient, m *discordgo.MessageCreate) (string, error) {
+	usage := fmt.Errorf("參數錯誤，格式: $watch [guild] add|remove <symbol> ...、$watch [guild] set <symbol> [up=<%%>] [down=<%%>] [above=<價格>] [below=<價格>] 或 $watch [guild] list")
+
+	args := strings.Fields(m.Content)[1:]
+	owner, label := userWatchOwner(m.Author.ID), "個人"
+	if len(args) > 0 && strings.ToLower(args[0]) == "guild" {
+		if m.GuildID == "" {
+			return "", fmt.Errorf("伺服器觀察清單只能在伺服器頻道中使用")
+		}
+		owner, label = guildWatchOwner(m.GuildID), "伺服器"
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", usage
+	}
+
+	action := strings.ToLower(args[0])
+	symbols := make([]string, 0, len(args)-1)
+	for _, symbol := range args[1:] {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+
+	switch action {
+	case "list":
+		if len(symbols) > 0 {
+			return "", usage
+		}
+		return listWatchSymbols(ctx, redisClient, owner, label)
+	case "add":
+		if len(symbols) == 0 {
+			return "", usage
+		}
+		return addWatchSymbols(ctx, redisClient, owner, label, m.ChannelID, symbols)
+	case "remove":
+		if len(symbols) == 0 {
+			return "", usage
+		}
+		return removeWatchSymbols(ctx, redisClient, owner, label, symbols)
+	case "set":
+		return setWatchThreshold(ctx, redisClient, owner, label, args[1:])
+	}
+
+	return "", usage
+}
+
+// listWatchSymbols 列出觀察清單，自訂門檻的標的附上門檻
+func listWatchSymbols(ctx context.Context, redisClient RedisClient, owner string, label string) (string, error) {
+	current, err := redisClient.SMembers(ctx, watchSymbolsKey(owner))
+	if err != nil {
+		return "", err
+	}
+	if len(current) == 0 {
+		return fmt.Sprintf("%s觀察清單是空的，使用 $watch add <symbol> 新增", label), nil
+	}
+	thresholds, err := loadWatchThresholds(ctx, redisClient, owner)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(current)
+
+	taskConfig := config.GetTaskConfig()
+	list := &watchList{Thresholds: thresholds}
+	items := make([]string, 0, len(current))
+	for _, symbol := range current {
+		if _, ok := thresholds[symbol]; ok {
+			items = append(items, fmt.Sprintf("%s（%s）", symbol, list.Threshold(symbol, taskConfig)))
+			continue
+		}
+		items = append(items, symbol)
+	}
+
+	return fmt.Sprintf("%s觀察清單（%d，預設門檻: 上漲 %s%% / 下跌 %s%%）: %s", label, len(current), formatWeight(taskConfig.WatchAlertUpPercent), formatWeight(taskConfig.WatchAlertDownPercent), strings.Join(items, ", ")), nil
+}
+
+// addWatchSymbols 驗證標的後加入觀察清單，已存在的標的略過，並記錄警告頻道
+func addWatchSymbols(ctx context.Context, redisClient RedisClient, owner string, label string, channelID string, symbols []string) (string, error) {
+	current, err := redisClient.SMembers(ctx, watchSymbolsKey(owner))
+	if err != nil {
+		return "", err
+	}
+	exists := make(map[string]bool, len(current))
+	for _, symbol := range current {
+		exists[symbol] = true
+	}
+
+	var added, duplicated []string
+	externalTimeout := durationFromSeconds(config.GetTaskConfig().ExternalCallTimeoutSeconds, 15*time.Second)
+	for _, symbol := range symbols {
+		if exists[symbol] {
+			duplicated = append(duplicated, symbol)
+			continue
+		}
+
+		quoteCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+		quote, err := GetClient("finnhub").GetQuote(quoteCtx, symbol)
+		cancel()
+		if err != nil {
+			return "", fmt.Errorf("驗證 %s 錯誤: %w", symbol, err)
+		}
+		if quote.CurrentPrice == 0 {
+			return "", fmt.Errorf("查無標的: %s", symbol)
+		}
+
+		exists[symbol] = true
+		added = append(added, symbol)
+	}
+
+	if len(current)+len(added) > watchListMaxSymbols {
+		return "", fmt.Errorf("觀察清單最多 %d 個標的（目前 %d 個）", watchListMaxSymbols, len(current))
+	}
+
+	if len(added) > 0 {
+		if _, err := redisClient.SAdd(ctx, watchSymbolsKey(owner), added...); err != nil {
+			return "", err
+		}
+		if _, err := redisClient.SAdd(ctx, watchRegistryKey, owner); err != nil {
+			return "", err
+		}
+	}
+	if err := redisClient.Set(ctx, watchChannelKey(owner), channelID, 0); err != nil {
+		return "", err
+	}
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, fmt.Sprintf("已加入%s觀察清單: %s", label, strings.Join(added, ", ")))
+	}
+	if len(duplicated) > 0 {
+		parts = append(parts, fmt.Sprintf("已在清單中: %s", strings.Join(duplicated, ", ")))
+	}
+	parts = append(parts, "警告將發送到此頻道")
+	return strings.Join(parts, "\n"), nil
+}
+
+// removeWatchSymbols 從觀察清單移除標的，清單清空時一併移除登記
+func removeWatchSymbols(ctx context.Context, redisClient RedisClient, owner string, label string, symbols []string) (string, error) {
+	removed, err := redisClient.SRem(ctx, watchSymbolsKey(owner), symbols...)
+	if err != nil {
+		return "", err
+	}
+	if removed == 0 {
+		return "", fmt.Errorf("%s不在%s觀察清單中", strings.Join(symbols, ", "), label)
+	}
+
+	remaining, err := redisClient.SMembers(ctx, watchSymbolsKey(owner))
+	if err != nil {
+		return "", err
+	}
+
+	// 移除標的的自訂門檻
+	thresholds, err := loadWatchThresholds(ctx, redisClient, owner)
+	if err != nil {
+		return "", err
+	}
+	for _, symbol := range symbols {
+		delete(thresholds, symbol)
+	}
+	if err := saveWatchThresholds(ctx, redisClient, owner, thresholds); err != nil {
+		return "", err
+	}
+
+	if len(remaining) == 0 {
+		if _, err := redisClient.SRem(ctx, watchRegistryKey, owner); err != nil {
+			return "", err
+		}
+		if err := redisClient.Del(ctx, watchChannelKey(owner)); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("已從%s觀察清單移除 %d 個標的，剩餘 %d 個", label, removed, len(remaining)), nil
+}
+
+// loadWatchLists 取得舊版全域清單與所有已登記的個人/伺服器觀察清單
+// 舊版全域清單沒有指令可設定門檻，一律使用預設門檻；單一清單讀取失敗時略過並記錄，不影響其他清單
+func loadWatchLists(ctx context.Context, redisClient RedisClient, taskConfig *config.TaskConfig) ([]*watchList, error) {
+	var lists []*watchList
+
+	legacy, err := redisClient.LRange(ctx, legacyWatchListKey, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("取得全域觀察清單錯誤: %w", err)
+	}
+	if len(legacy) > 0 {
+		lists = append(lists, &watchList{Owner: watchOwnerGlobal, ChannelID: taskConfig.WatchListChannelID, Symbols: normalizeSymbols(legacy)})
+	}
+
+	owners, err := redisClient.SMembers(ctx, watchRegistryKey)
+	if err != nil {
+		return nil, fmt.Errorf("取得觀察清單登記錯誤: %w", err)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		symbols, err := redisClient.SMembers(ctx, watchSymbolsKey(owner))
+		if err != nil {
+			logger.Error("取得觀察清單失敗", "owner", owner, "error", err)
+			continue
+		}
+		channelID, err := redisClient.Get(ctx, watchChannelKey(owner))
+		if err != nil {
+			logger.Error("取得觀察清單頻道失敗", "owner", owner, "error", err)
+			continue
+		}
+		if len(symbols) == 0 || channelID == "" {
+			continue
+		}
+		lists = append(lists, &watchList{Owner: owner, ChannelID: channelID, Symbols: normalizeSymbols(symbols), Thresholds: loadWatchThresholdsOrDefault(ctx, redisClient, owner)})
+	}
+
+	return lists, nil
+}
+
+// loadWatchThresholdsOrDefault 取得自訂門檻，失敗時記錄並全部使用預設門檻
+func loadWatchThresholdsOrDefault(ctx context.Context, redisClient RedisClient, owner string) map[string]*watchThreshold {
+	thresholds, err := loadWatchThresholds(ctx, redisClient, owner)
+	if err != nil {
+		logger.Error("取得觀察清單門檻失敗，使用預設門檻", "owner", owner, "error", err)
+		return nil
+	}
+	return thresholds
+}
+
+// watchListsBySymbol 依標的分組觀察清單，回傳排序後的標的與各標的所屬清單
+func watchListsBySymbol(lists []*watchList) ([]string, map[string][]*watchList) {
+	bySymbol := make(map[string][]*watchList)
+	for _, list := range lists {
+		for _, symbol := range list.Symbols {
+			bySymbol[symbol] = append(bySymbol[symbol], list)
+		}
+	}
+
+	symbols := make([]string, 0, len(bySymbol))
+	for symbol := range bySymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols, bySymbol
+}
+
+// allWatchSymbols 所有觀察清單的標的（去重並排序）
+func allWatchSymbols(ctx context.Context, redisClient RedisClient) ([]string, error) {
+	lists, err := loadWatchLists(ctx, redisClient, config.GetTaskConfig())
+	if err != nil {
+		return nil, err
+	}
+	symbols, _ := watchListsBySymbol(lists)
+	return symbols, nil
+}
+
+// WatchSymbols : 所有觀察清單的標的（即時串流訂閱用）
+func WatchSymbols(ctx context.Context) ([]string, error) {
+	return allWatchSymbols(ctx, redisDeps{})
+}
+
+// normalizeSymbols 轉大寫並去除空白與重複
+func normalizeSymbols(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	ret := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		ret = append(ret, symbol)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,141 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discordBot/pkg/config"
+)
+
+func newWatchMessage(content string, guildID string, channelID string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content:   content,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Author:    &discordgo.User{ID: "u1"},
+	}}
+}
+
+func Test_watchCommand(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	mockClient.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 250})
+	mockClient.AddQuote("NVDA", &QuoteResponse{CurrentPrice: 120})
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	redisClient := NewMockRedisClient()
+	owner := userWatchOwner("u1")
+
+	tests := []struct {
+		name     string
+		content  string
+		guildID  string
+		want     string
+		wantErr  bool
+		wantSyms []string
+	}{
+		{name: "add", content: "$watch add tsla nvda", want: "已加入個人觀察清單: TSLA, NVDA", wantSyms: []string{"NVDA", "TSLA"}},
+		{name: "duplicate", content: "$watch add TSLA", want: "已在清單中: TSLA", wantSyms: []string{"NVDA", "TSLA"}},
+		{name: "invalid symbol", content: "$watch add NOPE", wantErr: true, wantSyms: []string{"NVDA", "TSLA"}},
+		{name: "list", content: "$watch list", want: "個人觀察清單（2，預設門檻: 上漲 3% / 下跌 3%）: NVDA, TSLA", wantSyms: []string{"NVDA", "TSLA"}},
+		{name: "remove", content: "$watch remove tsla", want: "剩餘 1 個", wantSyms: []string{"NVDA"}},
+		{name: "remove missing", content: "$watch remove TSLA", wantErr: true, wantSyms: []string{"NVDA"}},
+		{name: "guild in DM", content: "$watch guild add TSLA", wantErr: true, wantSyms: []string{"NVDA"}},
+		{name: "unknown action", content: "$watch clear", wantErr: true, wantSyms: []string{"NVDA"}},
+		{name: "remove last", content: "$watch remove NVDA", want: "剩餘 0 個"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := watchCommand(context.Background(), redisClient, newWatchMessage(tt.content, tt.guildID, "c1"))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("watchCommand() error = nil, wantErr = true")
+				}
+			} else if err != nil || !strings.Contains(got, tt.want) {
+				t.Errorf("watchCommand() = %q, %v, want contains %q", got, err, tt.want)
+			}
+
+			symbols, _ := redisClient.SMembers(context.Background(), watchSymbolsKey(owner))
+			if strings.Join(normalizeSymbols(symbols), ",") != strings.Join(tt.wantSyms, ",") {
+				t.Errorf("symbols = %v, want %v", symbols, tt.wantSyms)
+			}
+		})
+	}
+
+	// 清單清空後移除登記與頻道
+	if owners, _ := redisClient.SMembers(context.Background(), watchRegistryKey); len(owners) != 0 {
+		t.Errorf("registry = %v, want empty", owners)
+	}
+	if channelID, _ := redisClient.Get(context.Background(), watchChannelKey(owner)); channelID != "" {
+		t.Errorf("channel = %q, want empty", channelID)
+	}
+}
+
+func Test_loadWatchLists(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	mockClient.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 250})
+	mockClient.AddQuote("AAPL", &QuoteResponse{CurrentPrice: 180})
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	redisClient := NewMockRedisClient()
+	redisClient.Lists[legacyWatchListKey] = []string{"tsla", "AMD"}
+	// 舊版全域清單不讀取自訂門檻
+	redisClient.Data[watchThresholdsKey(watchOwnerGlobal)] = `{"TSLA":{"up":1}}`
+	ctx := context.Background()
+
+	if _, err := watchCommand(ctx, redisClient, newWatchMessage("$watch add TSLA AAPL", "", "dm")); err != nil {
+		t.Fatalf("watchCommand() unexpected error = %v", err)
+	}
+	if _, err := watchCommand(ctx, redisClient, newWatchMessage("$watch guild add AAPL", "g1", "c1")); err != nil {
+		t.Fatalf("watchCommand() unexpected error = %v", err)
+	}
+
+	lists, err := loadWatchLists(ctx, redisClient, &config.TaskConfig{WatchListChannelID: "global-channel"})
+	if err != nil {
+		t.Fatalf("loadWatchLists() unexpected error = %v", err)
+	}
+
+	var got []string
+	for _, list := range lists {
+		got = append(got, list.Owner+"@"+list.ChannelID+"="+strings.Join(list.Symbols, ","))
+	}
+	want := []string{"global@global-channel=AMD,TSLA", "guild:g1@c1=AAPL", "user:u1@dm=AAPL,TSLA"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("lists = %v, want %v", got, want)
+	}
+
+	symbols, bySymbol := watchListsBySymbol(lists)
+	if strings.Join(symbols, ",") != "AAPL,AMD,TSLA" {
+		t.Errorf("symbols = %v, want AAPL,AMD,TSLA", symbols)
+	}
+	if len(bySymbol["AAPL"]) != 2 || len(bySymbol["TSLA"]) != 2 || len(bySymbol["AMD"]) != 1 {
+		t.Errorf("bySymbol AAPL=%d TSLA=%d AMD=%d, want 2, 2, 1", len(bySymbol["AAPL"]), len(bySymbol["TSLA"]), len(bySymbol["AMD"]))
+	}
+
+	if got := lists[1].Mention("u0"); got != "" {
+		t.Errorf("guild Mention() = %q, want empty", got)
+	}
+	if got := lists[2].Mention("u0"); got != "<@u1> " {
+		t.Errorf("user Mention() = %q, want <@u1>", got)
+	}
+	if got := lists[0].Mention("u0"); got != "<@u0> " {
+		t.Errorf("global Mention() = %q, want <@u0>", got)
+	}
+	if lists[0].Thresholds != nil {
+		t.Errorf("global thresholds = %v, want defaults", lists[0].Thresholds)
+	}
+}
+
+func Test_alertRecordKey(t *testing.T) {
+	if got := alertRecordKey("user:u1", "TSLA", SessionRegular); got != "watch_alert:user:u1:TSLA" {
+		t.Errorf("alertRecordKey() = %q", got)
+	}
+	if got := alertRecordKey("guild:g1", "TSLA", SessionPreMarket); got != "watch_alert:guild:g1:TSLA:"+string(SessionPreMarket) {
+		t.Errorf("alertRecordKey() = %q", got)
+	}
+}
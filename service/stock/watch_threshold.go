@@ -0,0 +1,327 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"discordBot/pkg/config"
+)
+
+// watchThreshold 觀察清單單一標的的警告門檻，零值表示使用預設或不檢查
+type watchThreshold struct {
+	// 上漲警告門檻（%），0 使用 WATCH_ALERT_UP_PERCENT
+	Up float64 `json:"up,omitempty"`
+	// 下跌警告門檻（%，正數），0 使用 WATCH_ALERT_DOWN_PERCENT
+	Down float64 `json:"down,omitempty"`
+	// 價格高於等於此值時警告，0 不檢查
+	Above float64 `json:"above,omitempty"`
+	// 價格低於等於此值時警告，0 不檢查
+	Below float64 `json:"below,omitempty"`
+}
+
+// watchAlert 一則觸發的警告
+type watchAlert struct {
+	// 通知紀錄 key
+	RecordKey string
+	// 漲跌幅達到的級距（%，下跌為負數），價格警告為 0
+	Tier float64
+	// 警告內容（不含提及與標的）
+	Message string
+}
+
+// notified 通知紀錄是否已涵蓋此警告
+// 漲跌幅警告只在同方向已通知相同或更高級距時略過，上漲與下跌各自記錄最高級距
+func (a *watchAlert) notified(record string) bool {
+	if record == "" {
+		return false
+	}
+	if a.Tier == 0 {
+		return true
+	}
+
+	up, down := parseAlertRecord(record)
+	if a.Tier > 0 {
+		return a.Tier <= up
+	}
+	return -a.Tier <= down
+}
+
+// record 寫入通知紀錄的值：漲跌幅警告合併先前紀錄後為各方向已通知的最高級距（例如 up:5,down:10），價格警告為 true
+func (a *watchAlert) record(previous string) string {
+	if a.Tier == 0 {
+		return "true"
+	}
+
+	up, down := parseAlertRecord(previous)
+	if a.Tier > 0 {
+		up = math.Max(up, a.Tier)
+	} else {
+		down = math.Max(down, -a.Tier)
+	}
+
+	var parts []string
+	if up > 0 {
+		parts = append(parts, "up:"+formatWeight(up))
+	}
+	if down > 0 {
+		parts = append(parts, "down:"+formatWeight(down))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseAlertRecord 解析通知紀錄中上漲與下跌已通知的最高級距（正數）
+// 相容舊版只記錄單一級距的紀錄（下跌為負數），無法解析的部分視為未通知
+func parseAlertRecord(record string) (up float64, down float64) {
+	for _, part := range strings.Split(record, ",") {
+		direction, value, ok := strings.Cut(part, ":")
+		if !ok {
+			if tier, err := strconv.ParseFloat(part, 64); err == nil {
+				if tier > 0 {
+					up = tier
+				} else {
+					down = -tier
+				}
+			}
+			continue
+		}
+
+		tier, err := strconv.ParseFloat(value, 64)
+		if err != nil || tier <= 0 {
+			continue
+		}
+		switch direction {
+		case "up":
+			up = tier
+		case "down":
+			down = tier
+		}
+	}
+	return up, down
+}
+
+// watchThresholdsKey 觀察清單各標的門檻的 Redis key（JSON，symbol -> watchThreshold）
+func watchThresholdsKey(owner string) string {
+	return "watch:" + owner + ":thresholds"
+}
+
+// Threshold 取得標的的門檻，未自訂的漲跌幅門檻以設定的預設值補齊
+func (w *watchList) Threshold(symbol string, taskConfig *config.TaskConfig) watchThreshold {
+	var threshold watchThreshold
+	if custom, ok := w.Thresholds[symbol]; ok && custom != nil {
+		threshold = *custom
+	}
+	if threshold.Up <= 0 {
+		threshold.Up = taskConfig.WatchAlertUpPercent
+	}
+	if threshold.Down <= 0 {
+		threshold.Down = taskConfig.WatchAlertDownPercent
+	}
+	return threshold
+}
+
+// alertTiers 漲跌幅級距：自訂或預設門檻為第一級，設定中更大的級距依序往上
+func alertTiers(base float64, tiers []float64) []float64 {
+	ret := []float64{base}
+	for _, tier := range tiers {
+		if tier > base {
+			ret = append(ret, tier)
+		}
+	}
+	sort.Float64s(ret[1:])
+	return ret
+}
+
+// reachedTier 漲跌幅（取絕對值）達到的最高級距，未達第一級時回傳 0
+func reachedTier(change float64, tiers []float64) float64 {
+	var reached float64
+	for _, tier := range tiers {
+		if change >= tier {
+			reached = tier
+		}
+	}
+	return reached
+}
+
+// alerts 依門檻與級距判斷報價觸發的警告，延長時段沒有成交資料時不判斷
+func (t watchThreshold) alerts(owner string, symbol string, quote *SessionQuote, tiers []float64) []*watchAlert {
+	if quote.RegularFallback {
+		return nil
+	}
+
+	key := alertRecordKey(owner, symbol, quote.Session)
+	change := float64(quote.Change)
+
+	var alerts []*watchAlert
+	var tier float64
+	if change > 0 {
+		tier = reachedTier(change, alertTiers(t.Up, tiers))
+	} else {
+		tier = -reachedTier(-change, alertTiers(t.Down, tiers))
+	}
+	if tier != 0 {
+		alerts = append(alerts, &watchAlert{
+			RecordKey: key,
+			Tier:      tier,
+			Message:   fmt.Sprintf("%s漲跌幅為 %.2f %%（達 %s%% 級距）", quote.Session.changeLabel(), change, formatWeight(math.Abs(tier))),
+		})
+	}
+	if t.Above > 0 && quote.Price >= t.Above {
+		alerts = append(alerts, &watchAlert{
+			RecordKey: key + ":above",
+			Message:   fmt.Sprintf("價格 %.2f 已高於 %.2f", quote.Price, t.Above),
+		})
+	}
+	if t.Below > 0 && quote.Price > 0 && quote.Price <= t.Below {
+		alerts = append(alerts, &watchAlert{
+			RecordKey: key + ":below",
+			Message:   fmt.Sprintf("價格 %.2f 已低於 %.2f", quote.Price, t.Below),
+		})
+	}
+	return alerts
+}
+
+// String 門檻說明，未自訂時回傳空字串
+func (t watchThreshold) String() string {
+	var parts []string
+	if t.Up > 0 {
+		parts = append(parts, fmt.Sprintf("上漲 %s%%", formatWeight(t.Up)))
+	}
+	if t.Down > 0 {
+		parts = append(parts, fmt.Sprintf("下跌 %s%%", formatWeight(t.Down)))
+	}
+	if t.Above > 0 {
+		parts = append(parts, fmt.Sprintf("高於 %.2f", t.Above))
+	}
+	if t.Below > 0 {
+		parts = append(parts, fmt.Sprintf("低於 %.2f", t.Below))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// parseWatchThreshold 解析 up=<%> down=<%> above=<價格> below=<價格>，只覆寫有指定的欄位
+func parseWatchThreshold(current watchThreshold, args []string) (watchThreshold, error) {
+	threshold := current
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return threshold, fmt.Errorf("參數錯誤，門檻格式: up=<%%> down=<%%> above=<價格> below=<價格>")
+		}
+
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || number < 0 {
+			return threshold, fmt.Errorf("無效的門檻: %s", arg)
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			threshold.Up = number
+		case "down":
+			threshold.Down = number
+		case "above":
+			threshold.Above = number
+		case "below":
+			threshold.Below = number
+		default:
+			return threshold, fmt.Errorf("不支援的參數: %s", key)
+		}
+	}
+
+	if threshold.Above > 0 && threshold.Below > 0 && threshold.Below >= threshold.Above {
+		return threshold, fmt.Errorf("below 需小於 above")
+	}
+	return threshold, nil
+}
+
+// loadWatchThresholds 取得觀察清單各標的的自訂門檻
+func loadWatchThresholds(ctx context.Context, redisClient RedisClient, owner string) (map[string]*watchThreshold, error) {
+	thresholds := make(map[string]*watchThreshold)
+
+	value, err := redisClient.Get(ctx, watchThresholdsKey(owner))
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return thresholds, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &thresholds); err != nil {
+		return nil, fmt.Errorf("解析觀察清單門檻錯誤: %w", err)
+	}
+	return thresholds, nil
+}
+
+// saveWatchThresholds 寫入觀察清單各標的的自訂門檻，沒有自訂門檻時刪除 key
+func saveWatchThresholds(ctx context.Context, redisClient RedisClient, owner string, thresholds map[string]*watchThreshold) error {
+	if len(thresholds) == 0 {
+		return redisClient.Del(ctx, watchThresholdsKey(owner))
+	}
+
+	value, err := json.Marshal(thresholds)
+	if err != nil {
+		return err
+	}
+	return redisClient.Set(ctx, watchThresholdsKey(owner), string(value), 0)
+}
+
+// setWatchThreshold 設定或重設觀察清單中標的的門檻
+func setWatchThreshold(ctx context.Context, redisClient RedisClient, owner string, label string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("參數錯誤，格式: $watch [guild] set <symbol> [up=<%%>] [down=<%%>] [above=<價格>] [below=<價格>] 或 $watch [guild] set <symbol> default")
+	}
+
+	symbol := strings.ToUpper(args[0])
+	isMember := false
+	current, err := redisClient.SMembers(ctx, watchSymbolsKey(owner))
+	if err != nil {
+		return "", err
+	}
+	for _, v := range current {
+		if v == symbol {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		return "", fmt.Errorf("%s不在%s觀察清單中", symbol, label)
+	}
+
+	thresholds, err := loadWatchThresholds(ctx, redisClient, owner)
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) == 2 && strings.ToLower(args[1]) == "default" {
+		delete(thresholds, symbol)
+		if err := saveWatchThresholds(ctx, redisClient, owner, thresholds); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s 已恢復預設門檻", symbol), nil
+	}
+
+	var existing watchThreshold
+	if custom, ok := thresholds[symbol]; ok && custom != nil {
+		existing = *custom
+	}
+	threshold, err := parseWatchThreshold(existing, args[1:])
+	if err != nil {
+		return "", err
+	}
+
+	if threshold == (watchThreshold{}) {
+		delete(thresholds, symbol)
+	} else {
+		thresholds[symbol] = &threshold
+	}
+	if err := saveWatchThresholds(ctx, redisClient, owner, thresholds); err != nil {
+		return "", err
+	}
+
+	taskConfig := config.GetTaskConfig()
+	resolved := (&watchList{Thresholds: thresholds}).Threshold(symbol, taskConfig)
+	return fmt.Sprintf("%s %s觀察門檻: %s", symbol, label, resolved), nil
+}
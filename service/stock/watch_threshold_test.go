@@ -0,0 +1,179 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"discordBot/pkg/config"
+	"discordBot/service/market"
+)
+
+func Test_parseWatchThreshold(t *testing.T) {
+	tests := []struct {
+		name    string
+		current watchThreshold
+		args    []string
+		want    watchThreshold
+		wantErr bool
+	}{
+		{name: "percent", args: []string{"up=8%", "down=5"}, want: watchThreshold{Up: 8, Down: 5}},
+		{name: "keeps existing", current: watchThreshold{Up: 8}, args: []string{"above=300"}, want: watchThreshold{Up: 8, Above: 300}},
+		{name: "zero resets field", current: watchThreshold{Up: 8, Below: 100}, args: []string{"UP=0"}, want: watchThreshold{Below: 100}},
+		{name: "below not under above", args: []string{"above=100", "below=100"}, wantErr: true},
+		{name: "negative", args: []string{"down=-3"}, wantErr: true},
+		{name: "unknown key", args: []string{"volume=2"}, wantErr: true},
+		{name: "missing value", args: []string{"8"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWatchThreshold(tt.current, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseWatchThreshold() error = nil, wantErr = true")
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseWatchThreshold() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchThreshold_alerts(t *testing.T) {
+	taskConfig := &config.TaskConfig{WatchAlertUpPercent: 3, WatchAlertDownPercent: 3, WatchAlertTiers: []float64{3, 5, 10}}
+	list := &watchList{Owner: "user:u1", Thresholds: map[string]*watchThreshold{
+		"TSLA": {Up: 8, Down: 5, Above: 300},
+		"VOO":  {Below: 400},
+	}}
+
+	tests := []struct {
+		name   string
+		symbol string
+		quote  *SessionQuote
+		want   []string
+	}{
+		{name: "default threshold", symbol: "AAPL", quote: &SessionQuote{Session: SessionRegular, Price: 100, Change: -3.5}, want: []string{"watch_alert:user:u1:AAPL -3"}},
+		{name: "below default", symbol: "AAPL", quote: &SessionQuote{Session: SessionRegular, Price: 100, Change: 2.9}},
+		{name: "higher tier", symbol: "AAPL", quote: &SessionQuote{Session: SessionRegular, Price: 100, Change: 7.2}, want: []string{"watch_alert:user:u1:AAPL 5"}},
+		{name: "top tier", symbol: "AAPL", quote: &SessionQuote{Session: SessionRegular, Price: 100, Change: -12}, want: []string{"watch_alert:user:u1:AAPL -10"}},
+		{name: "custom up not reached", symbol: "TSLA", quote: &SessionQuote{Session: SessionRegular, Price: 250, Change: 6}},
+		{name: "custom down reached", symbol: "TSLA", quote: &SessionQuote{Session: SessionRegular, Price: 250, Change: -5}, want: []string{"watch_alert:user:u1:TSLA -5"}},
+		{name: "price above and change", symbol: "TSLA", quote: &SessionQuote{Session: SessionPreMarket, Price: 310, Change: 9}, want: []string{"watch_alert:user:u1:TSLA:pre 8", "watch_alert:user:u1:TSLA:pre:above 0"}},
+		{name: "extended without trade", symbol: "TSLA", quote: &SessionQuote{Session: SessionAfterHours, Price: 310, Change: 9, RegularFallback: true}},
+		{name: "price below with default change", symbol: "VOO", quote: &SessionQuote{Session: SessionRegular, Price: 399, Change: -1}, want: []string{"watch_alert:user:u1:VOO:below 0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, alert := range list.Threshold(tt.symbol, taskConfig).alerts(list.Owner, tt.symbol, tt.quote, taskConfig.WatchAlertTiers) {
+				got = append(got, fmt.Sprintf("%s %v", alert.RecordKey, alert.Tier))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("alerts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := alertTiers(8, []float64{10, 3, 20, 5}); fmt.Sprint(got) != "[8 10 20]" {
+		t.Errorf("alertTiers() = %v, want [8 10 20]", got)
+	}
+}
+
+func Test_watchAlert_notified(t *testing.T) {
+	// 同一天內依序達到 -3%、-5%、-5%、+3%、-3%、-5%、-10%、+5%、+3% 級距，每個方向的新級距各通知一次，來回擺盪不重複通知
+	record := ""
+	var sent []string
+	for _, tier := range []float64{-3, -5, -5, 3, -3, -5, -10, 5, 3} {
+		alert := &watchAlert{Tier: tier}
+		if alert.notified(record) {
+			continue
+		}
+		sent = append(sent, formatWeight(tier))
+		record = alert.record(record)
+	}
+	if strings.Join(sent, ",") != "-3,-5,3,-10,5" {
+		t.Errorf("sent = %v, want -3,-5,3,-10,5", sent)
+	}
+	if record != "up:5,down:10" {
+		t.Errorf("record = %q, want up:5,down:10", record)
+	}
+
+	level := &watchAlert{}
+	if level.notified("") || !level.notified("true") || level.record("") != "true" {
+		t.Errorf("price alert notified/record mismatch")
+	}
+	// 舊版 true 紀錄不擋漲跌幅級距
+	if (&watchAlert{Tier: 3}).notified("true") {
+		t.Errorf("legacy record blocks tier alert")
+	}
+	// 舊版單一級距紀錄仍可判斷並合併
+	if !(&watchAlert{Tier: -3}).notified("-5") || (&watchAlert{Tier: 3}).notified("-5") {
+		t.Errorf("legacy tier record mismatch")
+	}
+	if got := (&watchAlert{Tier: 3}).record("-5"); got != "up:3,down:5" {
+		t.Errorf("record(-5) = %q, want up:3,down:5", got)
+	}
+}
+
+func Test_alertRecordTTL(t *testing.T) {
+	loc := market.US().Location
+	tests := []struct {
+		now  time.Time
+		want time.Duration
+	}{
+		{now: time.Date(2026, 3, 10, 10, 0, 0, 0, loc), want: 14 * time.Hour},
+		{now: time.Date(2026, 3, 10, 23, 59, 30, 0, loc), want: time.Minute},
+		{now: time.Date(2026, 3, 7, 20, 0, 0, 0, loc), want: 4 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := alertRecordTTL(tt.now); got != tt.want {
+			t.Errorf("alertRecordTTL(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func Test_watchCommand_set(t *testing.T) {
+	mockClient := NewMockFinnhubClient()
+	mockClient.AddQuote("TSLA", &QuoteResponse{CurrentPrice: 250})
+	SetDefaultClient(mockClient)
+	defer ResetDefaultClient()
+
+	redisClient := NewMockRedisClient()
+	ctx := context.Background()
+	owner := userWatchOwner("u1")
+
+	if _, err := watchCommand(ctx, redisClient, newWatchMessage("$watch set TSLA up=8", "", "c1")); err == nil {
+		t.Errorf("set before add error = nil, want error")
+	}
+	if _, err := watchCommand(ctx, redisClient, newWatchMessage("$watch add TSLA", "", "c1")); err != nil {
+		t.Fatalf("watchCommand() unexpected error = %v", err)
+	}
+
+	got, err := watchCommand(ctx, redisClient, newWatchMessage("$watch set tsla up=8 below=200", "", "c1"))
+	if err != nil || !strings.Contains(got, "上漲 8%") || !strings.Contains(got, "低於 200.00") {
+		t.Errorf("set = %q, %v, want resolved threshold", got, err)
+	}
+	thresholds, _ := loadWatchThresholds(ctx, redisClient, owner)
+	if thresholds["TSLA"] == nil || *thresholds["TSLA"] != (watchThreshold{Up: 8, Below: 200}) {
+		t.Errorf("stored = %+v, want up 8 below 200", thresholds["TSLA"])
+	}
+
+	if got, _ := watchCommand(ctx, redisClient, newWatchMessage("$watch list", "", "c1")); !strings.Contains(got, "TSLA（上漲 8%") {
+		t.Errorf("list = %q, want custom threshold shown", got)
+	}
+
+	// 移除標的時一併移除門檻
+	if _, err := watchCommand(ctx, redisClient, newWatchMessage("$watch remove TSLA", "", "c1")); err != nil {
+		t.Fatalf("remove unexpected error = %v", err)
+	}
+	if _, ok := redisClient.Data[watchThresholdsKey(owner)]; ok {
+		t.Errorf("thresholds key not removed")
+	}
+}
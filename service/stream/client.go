@@ -0,0 +1,285 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"discordBot/pkg/logger"
+)
+
+// Trade 即時成交資料
+type Trade struct {
+	Symbol    string
+	Price     float64
+	Volume    float64
+	Timestamp int64 // 毫秒
+}
+
+// TradeHandler 收到成交資料時的回呼函數類型
+type TradeHandler func(Trade)
+
+type (
+	// message Finnhub WebSocket 訊息
+	message struct {
+		Type string         `json:"type"`
+		Data []tradePayload `json:"data"`
+		Msg  string         `json:"msg"`
+	}
+
+	tradePayload struct {
+		Symbol    string  `json:"s"`
+		Price     float64 `json:"p"`
+		Volume    float64 `json:"v"`
+		Timestamp int64   `json:"t"`
+	}
+
+	subscription struct {
+		Type   string `json:"type"`
+		Symbol string `json:"symbol"`
+	}
+)
+
+// Client Finnhub WebSocket 串流客戶端，斷線時以指數退避重新連線
+type Client struct {
+	url        string
+	handler    TradeHandler
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu         sync.Mutex
+	conn       *websocket.Conn
+	symbols    map[string]struct{}
+	lastPrices map[string]float64
+}
+
+// Option 用於配置 Client 的函數類型
+type Option func(*Client)
+
+// WithBackoff 設置重新連線的最短與最長等待時間
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		if min > 0 {
+			c.minBackoff = min
+		}
+		if max >= c.minBackoff {
+			c.maxBackoff = max
+		}
+	}
+}
+
+// NewClient 創建串流客戶端
+func NewClient(rawURL string, token string, handler TradeHandler, options ...Option) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream URL: %w", err)
+	}
+	if token != "" {
+		q := u.Query()
+		q.Set("token", token)
+		u.RawQuery = q.Encode()
+	}
+
+	c := &Client{
+		url:        u.String(),
+		handler:    handler,
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+		symbols:    make(map[string]struct{}),
+		lastPrices: make(map[string]float64),
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Run 連線並持續接收資料，直到 ctx 結束
+func (c *Client) Run(ctx context.Context) {
+	backoff := c.minBackoff
+
+	for {
+		connected, err := c.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 曾成功連線則重置退避時間
+		if connected {
+			backoff = c.minBackoff
+		}
+
+		logger.Warn("即時串流中斷，準備重新連線", "error", err, "backoff", backoff.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// runOnce 建立一次連線並讀取至斷線，回傳是否曾成功連線
+func (c *Client) runOnce(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial stream: %w", err)
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	symbols := c.sortedSymbolsLocked()
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	// ctx 結束時關閉連線以中斷 ReadMessage
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	for _, symbol := range symbols {
+		if err := c.send(subscription{Type: "subscribe", Symbol: symbol}); err != nil {
+			return true, err
+		}
+	}
+
+	logger.Info("即時串流已連線", "symbols", len(symbols))
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		c.handleMessage(data)
+	}
+}
+
+func (c *Client) handleMessage(data []byte) {
+	msg := &message{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		logger.Warn("無法解析串流訊息", "error", err)
+		return
+	}
+
+	switch msg.Type {
+	case "trade":
+		for _, t := range msg.Data {
+			trade := Trade{
+				Symbol:    t.Symbol,
+				Price:     t.Price,
+				Volume:    t.Volume,
+				Timestamp: t.Timestamp,
+			}
+
+			c.mu.Lock()
+			c.lastPrices[trade.Symbol] = trade.Price
+			c.mu.Unlock()
+
+			if c.handler != nil {
+				c.handler(trade)
+			}
+		}
+	case "error":
+		logger.Warn("串流服務回傳錯誤", "msg", msg.Msg)
+	}
+}
+
+// SetSymbols 設定訂閱標的，已連線時立即訂閱新增標的並取消移除的標的
+func (c *Client) SetSymbols(symbols []string) {
+	next := make(map[string]struct{}, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol != "" {
+			next[symbol] = struct{}{}
+		}
+	}
+
+	c.mu.Lock()
+	var added, removed []string
+	for symbol := range next {
+		if _, ok := c.symbols[symbol]; !ok {
+			added = append(added, symbol)
+		}
+	}
+	for symbol := range c.symbols {
+		if _, ok := next[symbol]; !ok {
+			removed = append(removed, symbol)
+			delete(c.lastPrices, symbol)
+		}
+	}
+	c.symbols = next
+	connected := c.conn != nil
+	c.mu.Unlock()
+
+	if !connected {
+		return
+	}
+
+	for _, symbol := range added {
+		if err := c.send(subscription{Type: "subscribe", Symbol: symbol}); err != nil {
+			logger.Error("訂閱標的失敗", "symbol", symbol, "error", err)
+		}
+	}
+	for _, symbol := range removed {
+		if err := c.send(subscription{Type: "unsubscribe", Symbol: symbol}); err != nil {
+			logger.Error("取消訂閱標的失敗", "symbol", symbol, "error", err)
+		}
+	}
+}
+
+// LastPrice 取得標的最新成交價
+func (c *Client) LastPrice(symbol string) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	price, ok := c.lastPrices[strings.ToUpper(symbol)]
+	return price, ok
+}
+
+// send 寫入訊息（WebSocket 不允許併發寫入，需持鎖）
+func (c *Client) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return fmt.Errorf("stream not connected")
+	}
+
+	return c.conn.WriteJSON(v)
+}
+
+func (c *Client) sortedSymbolsLocked() []string {
+	symbols := make([]string, 0, len(c.symbols))
+	for symbol := range c.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
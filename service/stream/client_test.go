@@ -0,0 +1,199 @@
+package stream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// stubServer 本地 WebSocket 串流 stub
+type stubServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	connections int
+	subscribed  []subscription
+	tokens      []string
+	conns       chan *websocket.Conn
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	t.Helper()
+
+	stub := &stubServer{conns: make(chan *websocket.Conn, 10)}
+	upgrader := websocket.Upgrader{}
+
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error = %v", err)
+			return
+		}
+
+		stub.mu.Lock()
+		stub.connections++
+		stub.tokens = append(stub.tokens, r.URL.Query().Get("token"))
+		stub.mu.Unlock()
+		stub.conns <- conn
+
+		for {
+			sub := subscription{}
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			stub.mu.Lock()
+			stub.subscribed = append(stub.subscribed, sub)
+			stub.mu.Unlock()
+		}
+	}))
+	t.Cleanup(stub.Close)
+
+	return stub
+}
+
+func (s *stubServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *stubServer) nextConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for connection")
+		return nil
+	}
+}
+
+func (s *stubServer) waitSubscribed(t *testing.T, want int) []subscription {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		got := append([]subscription(nil), s.subscribed...)
+		s.mu.Unlock()
+		if len(got) >= want {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d subscriptions", want)
+	return nil
+}
+
+func Test_Client_SubscribeAndReceiveTrades(t *testing.T) {
+	stub := newStubServer(t)
+
+	trades := make(chan Trade, 10)
+	client, err := NewClient(stub.wsURL(), "secret", func(trade Trade) {
+		trades <- trade
+	})
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error = %v", err)
+	}
+	client.SetSymbols([]string{"tsla", "AAPL", " "})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Run(ctx)
+
+	conn := stub.nextConn(t)
+	subs := stub.waitSubscribed(t, 2)
+	if subs[0].Symbol != "AAPL" || subs[1].Symbol != "TSLA" || subs[0].Type != "subscribe" {
+		t.Errorf("subscriptions = %v, want subscribe AAPL and TSLA", subs)
+	}
+
+	stub.mu.Lock()
+	token := stub.tokens[0]
+	stub.mu.Unlock()
+	if token != "secret" {
+		t.Errorf("token = %v, want secret", token)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"trade","data":[{"s":"TSLA","p":251.5,"t":1760000000000,"v":10}]}`)); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+
+	select {
+	case trade := <-trades:
+		if trade.Symbol != "TSLA" || trade.Price != 251.5 || trade.Volume != 10 {
+			t.Errorf("trade = %+v, want TSLA 251.5 x10", trade)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for trade")
+	}
+
+	if price, ok := client.LastPrice("tsla"); !ok || price != 251.5 {
+		t.Errorf("LastPrice() = %v, %v, want 251.5, true", price, ok)
+	}
+
+	// 連線中更新訂閱清單
+	client.SetSymbols([]string{"TSLA"})
+	subs = stub.waitSubscribed(t, 3)
+	if subs[2].Type != "unsubscribe" || subs[2].Symbol != "AAPL" {
+		t.Errorf("subscription = %v, want unsubscribe AAPL", subs[2])
+	}
+}
+
+func Test_Client_Reconnect(t *testing.T) {
+	stub := newStubServer(t)
+
+	client, err := NewClient(stub.wsURL(), "", nil, WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error = %v", err)
+	}
+	client.SetSymbols([]string{"TSLA"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+
+	// 伺服器主動斷線後應重新連線並重新訂閱
+	first := stub.nextConn(t)
+	stub.waitSubscribed(t, 1)
+	_ = first.Close()
+
+	stub.nextConn(t)
+	subs := stub.waitSubscribed(t, 2)
+	if subs[1].Symbol != "TSLA" {
+		t.Errorf("resubscribe = %v, want TSLA", subs[1])
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+}
+
+func Test_Client_InvalidMessage(t *testing.T) {
+	client, err := NewClient("ws://localhost", "", func(trade Trade) {
+		t.Errorf("unexpected trade %+v", trade)
+	})
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error = %v", err)
+	}
+
+	client.handleMessage([]byte(`not json`))
+	client.handleMessage([]byte(`{"type":"ping"}`))
+	client.handleMessage([]byte(`{"type":"error","msg":"invalid token"}`))
+
+	if _, ok := client.LastPrice("TSLA"); ok {
+		t.Errorf("LastPrice() ok = true, want false")
+	}
+}